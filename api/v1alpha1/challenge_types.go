@@ -18,7 +18,9 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ChallengeSpec defines the desired state of Challenge
@@ -35,23 +37,60 @@ type ChallengeSpec struct {
 	// +kubebuilder:default=600
 	// +optional
 	Timeout int64 `json:"timeout,omitempty"`
+
+	// DestroyOnFlag deletes an instance once its flag is validated (default: true)
+	// When false the instance is only marked solved and keeps running, so players can keep poking
+	// +kubebuilder:default=true
+	// +optional
+	DestroyOnFlag *bool `json:"destroyOnFlag,omitempty"`
+
+	// Shared gives a whole team one instance: instances are keyed on the team_id additional field,
+	// or on the challenge alone without one, instead of on each member's source ID
+	// +optional
+	Shared bool `json:"shared,omitempty"`
+
+	// ReconcileIntervalSeconds overrides the periodic requeue of this challenge's instances
+	// until they are Ready with connection info (default: 10)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReconcileIntervalSeconds int32 `json:"reconcileIntervalSeconds,omitempty"`
+
+	// ConnectionInstructions is author-provided text shown to players alongside the connection info
+	// e.g. "SSH as user ctf, the password is in /flag"
+	// +optional
+	ConnectionInstructions string `json:"connectionInstructions,omitempty"`
 }
 
 // ChallengeScenarioSpec defines the container configuration for a challenge
+// +kubebuilder:validation:XValidation:rule="(has(self.exposeType) && self.exposeType == 'None') || has(self.port)",message="port is required unless exposeType is None"
 type ChallengeScenarioSpec struct {
 	// Image is the container image to deploy
 	// +kubebuilder:validation:Required
 	Image string `json:"image"`
 
-	// Port is the container port to expose
-	// +kubebuilder:validation:Required
+	// ImagePullPolicy overrides the pull policy of the challenge image
+	// Defaults to Always for :latest (or untagged) images and IfNotPresent otherwise
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Port is the container port to expose, required unless ExposeType is None
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=65535
-	Port int32 `json:"port"`
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Protocol is the transport protocol of the challenge port (TCP or UDP)
+	// +kubebuilder:validation:Enum=TCP;UDP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
 
-	// ExposeType defines how to expose the service (NodePort, LoadBalancer, or Ingress)
-	// +kubebuilder:validation:Enum=NodePort;LoadBalancer;Ingress
-	// +kubebuilder:default=NodePort
+	// ExposeType defines how to expose the service (NodePort, LoadBalancer, ClusterIP, Ingress, or None)
+	// ClusterIP keeps the Service cluster-internal, reachable through the Ingress or the attack box
+	// None is for worker-only challenges: no Service or Ingress is created
+	// Defaults to ClusterIP when the Ingress is enabled and NodePort otherwise
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer;ClusterIP;Ingress;None
 	// +optional
 	ExposeType string `json:"exposeType,omitempty"`
 
@@ -59,12 +98,36 @@ type ChallengeScenarioSpec struct {
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
 
+	// EnvFrom populates environment variables from ConfigMaps or Secrets,
+	// so sensitive values don't have to live in the Challenge in plaintext
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// FlagTemplate is a Go template for generating unique flags per instance
 	// Available variables: .InstanceID, .SourceID, .ChallengeID, .RandomString
 	// Example: "FLAG{{{.ChallengeID}}_{{.SourceID}}_{{.RandomString}}}"
+	// Flags with quotes, backslashes, $ or control characters are rejected
 	// +optional
 	FlagTemplate string `json:"flagTemplate,omitempty"`
 
+	// FlagDelivery defines how the flag reaches the challenge container (env or file)
+	// env sets the FLAG variable; file mounts the instance flag Secret at FlagPath,
+	// which keeps the flag out of /proc/*/environ and kubectl describe
+	// +kubebuilder:validation:Enum=env;file
+	// +kubebuilder:default=env
+	// +optional
+	FlagDelivery string `json:"flagDelivery,omitempty"`
+
+	// FlagPath is the file the flag is mounted at when FlagDelivery is file
+	// +kubebuilder:default=/flag
+	// +optional
+	FlagPath string `json:"flagPath,omitempty"`
+
+	// FlagOutputs lists every place the flag is written to in the challenge container
+	// When set, it replaces FlagDelivery and FlagPath
+	// +optional
+	FlagOutputs []FlagOutputSpec `json:"flagOutputs,omitempty"`
+
 	// Resources defines the resource requirements for the container
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
@@ -84,6 +147,258 @@ type ChallengeScenarioSpec struct {
 	// NetworkPolicy enables network isolation for the challenge
 	// +optional
 	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// FailurePolicy defines how the operator reacts to a crash-looping challenge container
+	// +optional
+	FailurePolicy *FailurePolicySpec `json:"failurePolicy,omitempty"`
+
+	// StartupTimeoutSeconds is the expected time for the challenge to become ready
+	// The API gateway waits at least this long before returning a not-yet-ready instance
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartupTimeoutSeconds int32 `json:"startupTimeoutSeconds,omitempty"`
+
+	// ReadinessProbe checks the challenge is accepting connections (default: TCP on Port)
+	// +optional
+	ReadinessProbe *ProbeSpec `json:"readinessProbe,omitempty"`
+
+	// LivenessProbe restarts the challenge container when it stops responding
+	// +optional
+	LivenessProbe *ProbeSpec `json:"livenessProbe,omitempty"`
+
+	// StartupProbe holds off the readiness and liveness probes until a slow-starting challenge is up
+	// +optional
+	StartupProbe *ProbeSpec `json:"startupProbe,omitempty"`
+
+	// Warmup is an HTTP request the operator sends once to a new instance before marking it ready,
+	// so the first player doesn't hit a cold app (compilation, JIT, caches)
+	// It goes straight to the challenge port of the pod, and is skipped when networkPolicy is enabled
+	// since the policy doesn't admit the operator
+	// +optional
+	Warmup *WarmupSpec `json:"warmup,omitempty"`
+
+	// Readiness selects when an instance is declared ready: Replicas once every desired replica is ready,
+	// Endpoints once the challenge Service also has a ready endpoint, so the URL is routable when handed out
+	// +kubebuilder:validation:Enum=Replicas;Endpoints
+	// +kubebuilder:default=Replicas
+	// +optional
+	Readiness string `json:"readiness,omitempty"`
+
+	// Volumes lists the configMap, secret or emptyDir volumes available to the challenge container
+	// +optional
+	Volumes []VolumeSpec `json:"volumes,omitempty"`
+
+	// VolumeMounts mounts entries of Volumes into the challenge container
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Replicas is the number of challenge pods per instance, for stateless challenges serving many users
+	// Persistent challenges are limited to a single replica
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Persistence mounts a PersistentVolumeClaim that survives challenge pod restarts
+	// +optional
+	Persistence *PersistenceSpec `json:"persistence,omitempty"`
+
+	// Prepull pulls the image onto every node with a DaemonSet, ahead of instance creation
+	// +optional
+	Prepull bool `json:"prepull,omitempty"`
+
+	// ImagePullSecrets lists Secrets in the instance namespace used to pull images from private registries
+	// +optional
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
+	// SecurityContext overrides the challenge container security context
+	// Defaults to a hardened non-root context; set it for challenges that need root or capabilities
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// Privileged opts out of the default hardening: the image runs with its own user, capabilities and writable root
+	// It does not make the container privileged; ignored when SecurityContext is set
+	// +optional
+	Privileged bool `json:"privileged,omitempty"`
+
+	// PodSecurityContext sets the challenge pod security context (fsGroup, sysctls, ...)
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// InitContainers run to completion before the challenge container starts (seed a database, unpack data, ...)
+	// They receive the same instance metadata env vars as the challenge container
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// DisruptionBudget creates a PodDisruptionBudget so node maintenance can't evict every challenge pod at once
+	// Meant for long-running shared or multi-replica challenges
+	// +optional
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+
+	// SessionAffinity pins each client to one challenge pod, for multi-replica challenges without shared sessions
+	// +optional
+	SessionAffinity *SessionAffinitySpec `json:"sessionAffinity,omitempty"`
+
+	// ExternalTrafficPolicy of NodePort and LoadBalancer Services; Local preserves the client source IP
+	// for ACLs and logging, but only nodes running a challenge pod answer
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
+	// NodeSelector pins the challenge and attack box pods to matching nodes
+	// Overrides the operator DEFAULT_NODE_SELECTOR
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations let the challenge and attack box pods run on tainted nodes
+	// Overrides the operator DEFAULT_TOLERATIONS
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity sets node and pod affinity rules of the challenge and attack box pods
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+}
+
+// FlagOutputSpec is one place the instance flag is delivered to
+type FlagOutputSpec struct {
+	// Type is env (an environment variable), file (a read-only file from the flag Secret)
+	// or none (the flag is only kept by the operator, e.g. when the platform prints it)
+	// +kubebuilder:validation:Enum=env;file;none
+	Type string `json:"type"`
+
+	// Name is the environment variable of an env output (default: FLAG)
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Path is the file of a file output (default: /flag)
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// SessionAffinitySpec configures ClientIP session affinity on the challenge Service
+type SessionAffinitySpec struct {
+	// Enabled turns on ClientIP session affinity
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+
+	// TimeoutSeconds is how long a client sticks to the same pod after its last request (default: 10800)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +kubebuilder:default=10800
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// DisruptionBudgetSpec configures the PodDisruptionBudget of the challenge pods
+type DisruptionBudgetSpec struct {
+	// MinAvailable is the number or percentage of challenge pods kept running during voluntary disruptions
+	// +kubebuilder:default=1
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+}
+
+// PersistenceSpec defines a per-instance PersistentVolumeClaim for stateful challenges
+// The PVC is owned by the ChallengeInstance: it survives pod restarts and Deployment updates,
+// and is garbage-collected with the instance (expiry, flag validation or deletion). Whether the
+// underlying volume data is then deleted or retained follows the storage class reclaimPolicy.
+type PersistenceSpec struct {
+	// Size is the requested storage capacity
+	// +kubebuilder:default="1Gi"
+	// +optional
+	Size resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName selects the storage class (default: the cluster default class)
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// MountPath is where the volume is mounted in the challenge container
+	// +kubebuilder:validation:Required
+	MountPath string `json:"mountPath"`
+}
+
+// VolumeSpec defines a volume for the challenge pod; exactly one source must be set
+type VolumeSpec struct {
+	// Name of the volume, referenced by VolumeMounts
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// ConfigMap populates the volume with the keys of a ConfigMap
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+
+	// Secret populates the volume with the keys of a Secret
+	// +optional
+	Secret *corev1.SecretVolumeSource `json:"secret,omitempty"`
+
+	// EmptyDir is a scratch directory sharing the pod lifetime
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
+// ProbeSpec defines a TCP or HTTP probe against the challenge container
+type ProbeSpec struct {
+	// Type is the probe kind (TCP or HTTP)
+	// +kubebuilder:validation:Enum=TCP;HTTP
+	// +kubebuilder:default=TCP
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Path is the HTTP GET path, only used by HTTP probes (default: /)
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Port is the probed port (default: the challenge port)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// InitialDelaySeconds is the delay before the first probe
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is the interval between probes (default: 10)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures before giving up (default: 3)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// WarmupSpec defines the warmup request sent to the challenge before the instance is ready
+type WarmupSpec struct {
+	// Path is the HTTP GET path of the warmup request (default: /)
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// TimeoutSeconds bounds the warmup request; the instance is marked ready even if it fails
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=60
+	// +kubebuilder:default=10
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// FailurePolicySpec defines when a restarting challenge is given up on
+type FailurePolicySpec struct {
+	// MaxRestarts is the container restart count after which the instance is marked Failed
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	// +optional
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+
+	// RecreateOnce recreates the instance Deployment once before marking it Failed
+	// +optional
+	RecreateOnce bool `json:"recreateOnce,omitempty"`
 }
 
 // AuthProxySpec defines the auth-proxy sidecar configuration
@@ -113,6 +428,12 @@ type AttackBoxSpec struct {
 	// +optional
 	Image string `json:"image,omitempty"`
 
+	// ImagePullPolicy overrides the pull policy of the attack box image
+	// Defaults to Always for :latest (or untagged) images and IfNotPresent otherwise
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
 	// Port is the ttyd port (default: 7681)
 	// +kubebuilder:default=7681
 	// +optional
@@ -121,6 +442,12 @@ type AttackBoxSpec struct {
 	// Resources for the attack box container
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// InjectFlag exposes the instance flag as FLAG in the attack box, e.g. for debugging
+	// Players use the attack box, so the flag stays out of it unless explicitly enabled
+	// +kubebuilder:default=false
+	// +optional
+	InjectFlag bool `json:"injectFlag,omitempty"`
 }
 
 // IngressSpec defines the Ingress configuration
@@ -164,10 +491,24 @@ type NetworkPolicySpec struct {
 	// +optional
 	AllowInternet bool `json:"allowInternet,omitempty"`
 
+	// EgressMode Strict denies all egress except DNS (AllowDNS), the instance's challenge and AllowedEgressCIDRs,
+	// whatever AllowInternet says; Open (default) follows AllowInternet
+	// +kubebuilder:validation:Enum=Open;Strict
+	// +optional
+	EgressMode string `json:"egressMode,omitempty"`
+
 	// AllowDNS allows egress to kube-dns
 	// +kubebuilder:default=true
 	// +optional
 	AllowDNS bool `json:"allowDNS,omitempty"`
+
+	// AllowedEgressCIDRs are extra reachable destinations, even inside the private ranges (e.g. an internal scoring API)
+	// +optional
+	AllowedEgressCIDRs []string `json:"allowedEgressCIDRs,omitempty"`
+
+	// DeniedEgressCIDRs are carved out of the internet and allowed egress (e.g. the 169.254.169.254/32 metadata service)
+	// +optional
+	DeniedEgressCIDRs []string `json:"deniedEgressCIDRs,omitempty"`
 }
 
 // ChallengeStatus defines the observed state of Challenge
@@ -185,6 +526,11 @@ type ChallengeStatus struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="ID",type=string,JSONPath=`.spec.id`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.scenario.image`
+// +kubebuilder:printcolumn:name="Timeout",type=integer,JSONPath=`.spec.timeout`
+// +kubebuilder:printcolumn:name="Active",type=integer,JSONPath=`.status.activeInstances`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Challenge is the Schema for the challenges API
 type Challenge struct {
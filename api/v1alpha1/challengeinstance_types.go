@@ -38,6 +38,11 @@ type ChallengeInstanceSpec struct {
 	// +optional
 	Additional map[string]string `json:"additional,omitempty"`
 
+	// Hostname overrides the templated Ingress host for this instance
+	// Must be a single DNS label under the base domain (e.g. "myteam.devleo.local")
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
 	// Since is the time when the instance was created
 	// +kubebuilder:validation:Required
 	Since metav1.Time `json:"since"`
@@ -62,6 +67,15 @@ type ChallengeInstanceStatus struct {
 	// +optional
 	Flags []string `json:"flags,omitempty"`
 
+	// FlagHashes contains salted SHA-256 hashes of the flags when flag hashing is enabled
+	// The plaintext is then only kept in the instance flag Secret
+	// +optional
+	FlagHashes []string `json:"flagHashes,omitempty"`
+
+	// FlagSalt is the per-instance salt used for FlagHashes
+	// +optional
+	FlagSalt string `json:"flagSalt,omitempty"`
+
 	// DeploymentName is the name of the created Deployment
 	// +optional
 	DeploymentName string `json:"deploymentName,omitempty"`
@@ -70,15 +84,29 @@ type ChallengeInstanceStatus struct {
 	// +optional
 	ServiceName string `json:"serviceName,omitempty"`
 
+	// NodeName is the node the challenge pod is scheduled on
+	// NodePort connection info uses this node's external IP when it has one
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
 	// Ready indicates if the instance is fully operational
 	// +optional
 	Ready bool `json:"ready,omitempty"`
 
-	// FlagValidated indicates if the flag has been submitted correctly
-	// When true, the instance will be deleted by the janitor
+	// FlagValidated indicates if the flag has been submitted correctly, i.e. the instance is solved
+	// When true, the instance will be deleted by the janitor unless its Challenge disables destroyOnFlag
 	// +optional
 	FlagValidated bool `json:"flagValidated,omitempty"`
 
+	// Recreated indicates the Deployment was already recreated once by the failure policy
+	// +optional
+	Recreated bool `json:"recreated,omitempty"`
+
+	// Draining indicates the instance is within its warning period before expiry
+	// Clients should warn the user to save their work
+	// +optional
+	Draining bool `json:"draining,omitempty"`
+
 	// Conditions represent the current state of the ChallengeInstance
 	// +listType=map
 	// +listMapKey=type
@@ -88,6 +116,12 @@ type ChallengeInstanceStatus struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Challenge",type=string,JSONPath=`.spec.challengeId`
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.sourceId`
+// +kubebuilder:printcolumn:name="Until",type=date,JSONPath=`.spec.until`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ChallengeInstance is the Schema for the challengeinstances API
 type ChallengeInstance struct {
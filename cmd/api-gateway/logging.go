@@ -0,0 +1,72 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// newLogger builds the JSON gateway logger for a LOG_LEVEL (debug|info|warn|error, default info)
+func newLogger(w io.Writer, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})), nil
+}
+
+// requestLogFormatter makes the chi request logger emit one JSON line per request
+type requestLogFormatter struct {
+	logger *slog.Logger
+}
+
+// NewLogEntry captures the request attributes, logged once the response is written
+func (f *requestLogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	return &requestLogEntry{logger: f.logger.With(
+		"request_id", middleware.GetReqID(r.Context()),
+		"method", r.Method,
+		"path", r.URL.Path,
+		"remote_addr", r.RemoteAddr,
+	)}
+}
+
+// requestLogEntry is the log entry of a single request
+type requestLogEntry struct {
+	logger *slog.Logger
+}
+
+// Write logs the completed request
+func (e *requestLogEntry) Write(status, bytes int, _ http.Header, elapsed time.Duration, _ interface{}) {
+	e.logger.Info("Request completed",
+		"status", status,
+		"bytes", bytes,
+		"duration_ms", float64(elapsed.Microseconds())/1000,
+	)
+}
+
+// Panic logs a panic recovered by middleware.Recoverer
+func (e *requestLogEntry) Panic(v interface{}, stack []byte) {
+	e.logger.Error("Request panicked", "panic", fmt.Sprint(v), "stack", string(stack))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestNewLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "warn")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("quota reached", "source_id", "team-1")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a single JSON log line, got %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "quota reached" || line["source_id"] != "team-1" {
+		t.Errorf("Expected the warn line with its attributes, got %v", line)
+	}
+
+	if _, err := newLogger(&buf, "loud"); err == nil {
+		t.Error("Expected unknown level to be rejected")
+	}
+}
+
+func TestRequestLogFormatter_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RequestLogger(&requestLogFormatter{logger: logger}))
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON request log line, got %q: %v", buf.String(), err)
+	}
+	if line["method"] != "GET" || line["path"] != "/health" {
+		t.Errorf("Expected method and path attributes, got %v", line)
+	}
+	if line["status"] != float64(http.StatusTeapot) {
+		t.Errorf("Expected status %d, got %v", http.StatusTeapot, line["status"])
+	}
+	if id, _ := line["request_id"].(string); id == "" {
+		t.Errorf("Expected a request_id attribute, got %v", line)
+	}
+}
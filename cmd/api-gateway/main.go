@@ -17,18 +17,20 @@ limitations under the License.
 package main
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 	_ "github.com/leo/chall-operator/docs" // Import generated docs
@@ -59,11 +61,20 @@ func init() {
 }
 
 func main() {
-	// Setup K8s client
+	// JSON logs for the central log system; slog.SetDefault also routes the standard log package through it
+	logger, err := newLogger(os.Stderr, os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Setup K8s client, able to watch for the instance status stream
 	cfg := ctrl.GetConfigOrDie()
-	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	k8sClient, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme})
 	if err != nil {
-		log.Fatalf("Failed to create K8s client: %v", err)
+		slog.Error("Failed to create K8s client", "error", err)
+		os.Exit(1)
 	}
 
 	// Create handler
@@ -72,10 +83,10 @@ func main() {
 	// Setup router
 	r := chi.NewRouter()
 
-	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	// Middleware; RequestID comes first so request log lines carry it
 	r.Use(middleware.RequestID)
+	r.Use(middleware.RequestLogger(&requestLogFormatter{logger: logger}))
+	r.Use(middleware.Recoverer)
 	r.Use(corsMiddleware)
 
 	// Health check (multiple routes for compatibility)
@@ -83,6 +94,9 @@ func main() {
 	r.Get("/healthz", handler.Health)
 	r.Get("/healthcheck", handler.Health)
 
+	// Prometheus metrics (instance lifecycle counters from pkg/metrics)
+	r.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+
 	// Swagger documentation
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
@@ -90,17 +104,26 @@ func main() {
 
 	// CTFd-compatible API endpoints
 	r.Route("/api/v1", func(r chi.Router) {
+		// Resolves the caller identity from API_TOKENS, recorded on created instances
+		r.Use(handler.Authenticate)
+
 		// Challenge management (CRD CRUD)
 		r.Post("/challenge", handler.CreateChallenge)
 		r.Get("/challenge", handler.ListChallenges)
+		r.Get("/challenge/schema", handler.GetChallengeSchema)
 		r.Get("/challenge/{challengeId}", handler.GetChallenge)
 		r.Patch("/challenge/{challengeId}", handler.UpdateChallenge)
 		r.Delete("/challenge/{challengeId}", handler.DeleteChallenge)
 
 		// Instance management
 		r.Post("/instance", handler.CreateInstance)
+		r.Post("/instance/bulk", handler.BulkCreateInstance)
+		r.Post("/instance/batch", handler.BatchCreateInstance)
+		r.Post("/instance/status/batch", handler.BatchInstanceStatus)
 		r.Get("/instance", handler.ListInstances)
+		r.Delete("/instance", handler.DeleteSourceInstances)
 		r.Get("/instance/{challengeId}/{sourceId}", handler.GetInstance)
+		r.Get("/instance/{challengeId}/{sourceId}/watch", handler.WatchInstance)
 		r.Delete("/instance/{challengeId}/{sourceId}", handler.DeleteInstance)
 		r.Patch("/instance/{challengeId}/{sourceId}", handler.RenewInstance) // CTFd plugin uses PATCH for renew
 		r.Post("/instance/{challengeId}/{sourceId}/validate", handler.ValidateFlag)
@@ -113,11 +136,11 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("API Gateway starting on :%s", port)
-	log.Printf("Instance namespace: %s", os.Getenv("INSTANCE_NAMESPACE"))
+	slog.Info("API Gateway starting", "port", port, "instance_namespace", os.Getenv("INSTANCE_NAMESPACE"))
 
 	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		slog.Error("Failed to start server", "error", err)
+		os.Exit(1)
 	}
 }
 
@@ -127,6 +150,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Continue-Token")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,66 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// leaderElectionOptions applies the LEADER_ELECTION_* lease durations to the manager options
+// Empty values keep the controller-runtime defaults (15s lease, 10s renew deadline, 2s retry period)
+func leaderElectionOptions(opts *ctrl.Options, leaseDuration, renewDeadline, retryPeriod string) error {
+	lease, err := parseLeaderElectionDuration("LEADER_ELECTION_LEASE_DURATION", leaseDuration, 15*time.Second)
+	if err != nil {
+		return err
+	}
+	renew, err := parseLeaderElectionDuration("LEADER_ELECTION_RENEW_DEADLINE", renewDeadline, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	retry, err := parseLeaderElectionDuration("LEADER_ELECTION_RETRY_PERIOD", retryPeriod, 2*time.Second)
+	if err != nil {
+		return err
+	}
+
+	// Same constraints as client-go: the leader must renew before the lease expires, retrying in between
+	if renew >= lease {
+		return fmt.Errorf("leader election renew deadline %s must be shorter than the lease duration %s", renew, lease)
+	}
+	if retry >= renew {
+		return fmt.Errorf("leader election retry period %s must be shorter than the renew deadline %s", retry, renew)
+	}
+
+	opts.LeaseDuration = &lease
+	opts.RenewDeadline = &renew
+	opts.RetryPeriod = &retry
+	return nil
+}
+
+// parseLeaderElectionDuration parses a positive duration, returning fallback when value is empty
+func parseLeaderElectionDuration(name, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a positive duration", name, value)
+	}
+	return d, nil
+}
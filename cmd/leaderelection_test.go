@@ -0,0 +1,74 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestLeaderElectionOptions(t *testing.T) {
+	opts := ctrl.Options{LeaderElection: true}
+	if err := leaderElectionOptions(&opts, "60s", "40s", "5s"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]struct {
+		got  *time.Duration
+		want time.Duration
+	}{
+		"lease duration": {opts.LeaseDuration, 60 * time.Second},
+		"renew deadline": {opts.RenewDeadline, 40 * time.Second},
+		"retry period":   {opts.RetryPeriod, 5 * time.Second},
+	}
+	for name, tt := range expected {
+		if tt.got == nil || *tt.got != tt.want {
+			t.Errorf("Expected %s %s, got %v", name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestLeaderElectionOptions_Defaults(t *testing.T) {
+	var opts ctrl.Options
+	if err := leaderElectionOptions(&opts, "", "", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *opts.LeaseDuration != 15*time.Second || *opts.RenewDeadline != 10*time.Second || *opts.RetryPeriod != 2*time.Second {
+		t.Errorf("Expected controller-runtime defaults, got %s/%s/%s", *opts.LeaseDuration, *opts.RenewDeadline, *opts.RetryPeriod)
+	}
+}
+
+func TestLeaderElectionOptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name                string
+		lease, renew, retry string
+	}{
+		{"unparsable lease", "forever", "", ""},
+		{"negative retry", "", "", "-1s"},
+		{"renew not shorter than lease", "10s", "10s", ""},
+		{"retry not shorter than renew", "30s", "5s", "5s"},
+	}
+
+	for _, tt := range tests {
+		var opts ctrl.Options
+		if err := leaderElectionOptions(&opts, tt.lease, tt.renew, tt.retry); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
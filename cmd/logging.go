@@ -0,0 +1,47 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// loggerOptions builds the zap options for a LOG_FORMAT (text|json) and LOG_LEVEL
+// text keeps the development console logger; json emits one JSON object per line for log aggregation
+func loggerOptions(format, level string) (zap.Options, error) {
+	var opts zap.Options
+	switch format {
+	case "", "text":
+		opts.Development = true
+	case "json":
+		zap.JSONEncoder()(&opts)
+	default:
+		return opts, fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+
+	if level != "" {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return opts, fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+		opts.Level = lvl
+	}
+	return opts, nil
+}
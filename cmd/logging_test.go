@@ -0,0 +1,72 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoggerOptions_JSON(t *testing.T) {
+	opts, err := loggerOptions("json", "debug")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts.Development {
+		t.Error("Expected production mode for json format")
+	}
+	if opts.Encoder == nil {
+		t.Fatal("Expected a JSON encoder to be configured")
+	}
+
+	buf, err := opts.Encoder.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "reconciled"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to encode entry: %v", err)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected JSON log line, got %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "reconciled" {
+		t.Errorf("Expected msg reconciled, got %v", line["msg"])
+	}
+
+	if opts.Level == nil || !opts.Level.Enabled(zapcore.DebugLevel) {
+		t.Errorf("Expected debug level to be enabled, got %v", opts.Level)
+	}
+}
+
+func TestLoggerOptions_Text(t *testing.T) {
+	opts, err := loggerOptions("", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !opts.Development || opts.Encoder != nil {
+		t.Errorf("Expected the default development console logger, got %+v", opts)
+	}
+}
+
+func TestLoggerOptions_Invalid(t *testing.T) {
+	if _, err := loggerOptions("xml", ""); err == nil {
+		t.Error("Expected unknown format to be rejected")
+	}
+	if _, err := loggerOptions("json", "loud"); err == nil {
+		t.Error("Expected unknown level to be rejected")
+	}
+}
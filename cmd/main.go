@@ -19,6 +19,7 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -37,6 +38,8 @@ import (
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 	"github.com/leo/chall-operator/internal/controller"
+	webhookv1alpha1 "github.com/leo/chall-operator/internal/webhook/v1alpha1"
+	"github.com/leo/chall-operator/pkg/builder"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -79,8 +82,11 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
-	opts := zap.Options{
-		Development: true,
+	// LOG_FORMAT (text|json) and LOG_LEVEL set the defaults; --zap-* flags still override them
+	opts, err := loggerOptions(os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging configuration: %v\n", err)
+		os.Exit(1)
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
@@ -154,7 +160,7 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	mgrOpts := ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
@@ -172,19 +178,58 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		// LeaderElectionReleaseOnCancel: true,
-	})
+	}
+	// LEADER_ELECTION_LEASE_DURATION, _RENEW_DEADLINE and _RETRY_PERIOD tune failover for HA deployments
+	if err := leaderElectionOptions(&mgrOpts,
+		os.Getenv("LEADER_ELECTION_LEASE_DURATION"),
+		os.Getenv("LEADER_ELECTION_RENEW_DEADLINE"),
+		os.Getenv("LEADER_ELECTION_RETRY_PERIOD")); err != nil {
+		setupLog.Error(err, "invalid leader election configuration")
+		os.Exit(1)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mgrOpts)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
 	if err := (&controller.ChallengeInstanceReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		HashFlags:         os.Getenv("FLAG_HASHING") == "true",
+		DeletePropagation: builder.DeletePropagationPolicy(),
+		DrainWarning:      controller.GetDrainWarningPeriod(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ChallengeInstance")
+		os.Exit(1)
+	}
+	if err := (&controller.ChallengeReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ChallengeInstance")
+		setupLog.Error(err, "unable to create controller", "controller", "Challenge")
 		os.Exit(1)
 	}
+	if err := (&controller.InstanceJanitor{
+		Client:            mgr.GetClient(),
+		Interval:          controller.GetJanitorInterval(),
+		DeletePropagation: builder.DeletePropagationPolicy(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to set up instance janitor")
+		os.Exit(1)
+	}
+	// Webhooks are opt-in: they need serving certificates (see config/webhook)
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err := webhookv1alpha1.SetupChallengeWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Challenge")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha1.SetupChallengeInstanceWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ChallengeInstance")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,126 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+	"github.com/leo/chall-operator/pkg/builder"
+)
+
+// ChallengeReconciler manages per-challenge resources shared by all instances
+type ChallengeReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile keeps the image prepull DaemonSet in line with the Challenge
+func (r *ChallengeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := r.Get(ctx, req.NamespacedName, challenge); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Owned resources are garbage-collected through owner references
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get Challenge")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ensurePrepull(ctx, challenge); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// ensurePrepull creates, updates or removes the prepull DaemonSet depending on spec.scenario.prepull
+func (r *ChallengeReconciler) ensurePrepull(ctx context.Context, challenge *ctfv1alpha1.Challenge) error {
+	log := logf.FromContext(ctx)
+
+	key := types.NamespacedName{Name: builder.PrepullDaemonSetName(challenge), Namespace: challenge.Namespace}
+	existing := &appsv1.DaemonSet{}
+	err := r.Get(ctx, key, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "Failed to get prepull DaemonSet")
+		return err
+	}
+	found := err == nil
+
+	daemonSet := builder.BuildPrepullDaemonSet(challenge)
+	if daemonSet == nil {
+		if found {
+			log.Info("Removing prepull DaemonSet", "daemonset", key.Name)
+			if err := r.Delete(ctx, existing); err != nil && !apierrors.IsNotFound(err) {
+				log.Error(err, "Failed to delete prepull DaemonSet")
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := controllerutil.SetControllerReference(challenge, daemonSet, r.Scheme); err != nil {
+		log.Error(err, "Failed to set owner reference on prepull DaemonSet")
+		return err
+	}
+	if err := builder.SetSpecHash(daemonSet, daemonSet.Spec); err != nil {
+		log.Error(err, "Failed to hash prepull DaemonSet spec")
+		return err
+	}
+
+	if !found {
+		log.Info("Creating prepull DaemonSet", "daemonset", daemonSet.Name, "image", challenge.Spec.Scenario.Image)
+		if err := r.Create(ctx, daemonSet); err != nil {
+			log.Error(err, "Failed to create prepull DaemonSet")
+			return err
+		}
+		return nil
+	}
+
+	if builder.SpecHashChanged(existing, daemonSet) {
+		log.Info("Updating drifted prepull DaemonSet", "daemonset", daemonSet.Name)
+		existing.Labels = daemonSet.Labels
+		mergeAnnotations(existing, daemonSet)
+		existing.Spec.Template = daemonSet.Spec.Template
+		if err := r.Update(ctx, existing); err != nil {
+			log.Error(err, "Failed to update prepull DaemonSet")
+			return err
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ChallengeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ctfv1alpha1.Challenge{}).
+		Owns(&appsv1.DaemonSet{}).
+		Named("challenge").
+		Complete(r)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+var _ = Describe("Challenge Controller", func() {
+	Context("When a challenge is flagged for prepulling", func() {
+		const challengeName = "prepull-challenge"
+
+		ctx := context.Background()
+		challengeKey := types.NamespacedName{Name: challengeName, Namespace: "default"}
+		daemonSetKey := types.NamespacedName{Name: challengeName + "-prepull", Namespace: "default"}
+
+		BeforeEach(func() {
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID: challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:   "registry.local/big:latest",
+						Port:    1337,
+						Prepull: true,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, challengeKey, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+			daemonSet := &appsv1.DaemonSet{}
+			if err := k8sClient.Get(ctx, daemonSetKey, daemonSet); err == nil {
+				Expect(k8sClient.Delete(ctx, daemonSet)).To(Succeed())
+			}
+		})
+
+		It("should create the prepull DaemonSet and remove it when disabled", func() {
+			controllerReconciler := &ChallengeReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: challengeKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			daemonSet := &appsv1.DaemonSet{}
+			Expect(k8sClient.Get(ctx, daemonSetKey, daemonSet)).To(Succeed())
+			Expect(daemonSet.Spec.Template.Spec.InitContainers).To(HaveLen(1))
+			Expect(daemonSet.Spec.Template.Spec.InitContainers[0].Image).To(Equal("registry.local/big:latest"))
+
+			By("Disabling prepull")
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, challengeKey, challenge)).To(Succeed())
+			challenge.Spec.Scenario.Prepull = false
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: challengeKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, daemonSetKey, daemonSet)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
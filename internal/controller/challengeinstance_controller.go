@@ -3,45 +3,105 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 	"github.com/leo/chall-operator/pkg/builder"
 	"github.com/leo/chall-operator/pkg/flaggen"
+	"github.com/leo/chall-operator/pkg/metrics"
 )
 
+// instanceFinalizer guarantees cleanup runs before a ChallengeInstance disappears
+const instanceFinalizer = "ctf.io/instance-cleanup"
+
+// conditionHealthy reports whether the challenge container is within its failure policy
+const conditionHealthy = "Healthy"
+
+// conditionSolved marks a validated instance kept running because its challenge disables destroyOnFlag
+const conditionSolved = "Solved"
+
+// errCleanupPending signals a cleanup step is waiting for a resource to disappear; the instance is requeued
+var errCleanupPending = errors.New("cleanup pending")
+
+// cleanupFunc tears down something an instance depends on; it must be idempotent
+type cleanupFunc func(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error
+
 // ChallengeInstanceReconciler reconciles a ChallengeInstance object
 type ChallengeInstanceReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
-	NodeIP string // Node IP for connection info (set via env or config)
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	NodeIP   string // Node IP for connection info (set via env or config)
+	// HashFlags keeps only salted flag hashes in status; the plaintext goes to the instance flag Secret
+	HashFlags bool
+	// DeletePropagation is the propagation policy of expiry and solve deletes; empty uses the API server default
+	DeletePropagation metav1.DeletionPropagation
+	// DrainWarning is how long before expiry the instance is flagged as draining; 0 disables it
+	DrainWarning time.Duration
+	// HTTPClient sends challenge warmup requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+
+	// warmups holds the done channel of each started warmup request, keyed by instance UID
+	warmups sync.Map
 }
 
 // +kubebuilder:rbac:groups=ctf.ctf.io,resources=challengeinstances,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ctf.ctf.io,resources=challengeinstances/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=ctf.ctf.io,resources=challengeinstances/finalizers,verbs=update
 // +kubebuilder:rbac:groups=ctf.ctf.io,resources=challenges,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ctf.ctf.io,resources=challenges/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile handles the reconciliation loop for ChallengeInstance resources
-func (r *ChallengeInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ChallengeInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	log := logf.FromContext(ctx)
+	defer func() {
+		if err != nil {
+			metrics.ReconcileErrors.Inc()
+		}
+	}()
 
 	// 1. Fetch the ChallengeInstance
 	instance := &ctfv1alpha1.ChallengeInstance{}
@@ -54,24 +114,79 @@ func (r *ChallengeInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return ctrl.Result{}, err
 	}
 
+	// 1b. Instance is being deleted - run cleanup and release the finalizer
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.finalizeInstance(ctx, instance)
+	}
+
+	// 1c. Ensure the cleanup finalizer is present
+	if !controllerutil.ContainsFinalizer(instance, instanceFinalizer) {
+		controllerutil.AddFinalizer(instance, instanceFinalizer)
+		if err := r.Update(ctx, instance); err != nil {
+			log.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
 	// 2. Check expiry - delete if expired
 	if instance.Spec.Until != nil && time.Now().After(instance.Spec.Until.Time) {
 		log.Info("Instance expired, deleting", "instance", instance.Name)
-		if err := r.Delete(ctx, instance); err != nil {
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "Expired", "Instance expired, deleting")
+		result, err := r.deleteInstance(ctx, instance)
+		if err != nil {
 			log.Error(err, "Failed to delete expired instance")
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		metrics.InstancesExpired.WithLabelValues(instance.Spec.ChallengeID).Inc()
+		return result, nil
+	}
+
+	// 2a. Flag the instance as draining ahead of expiry so clients can warn the user
+	if draining := r.inDrainWindow(instance); draining != instance.Status.Draining {
+		if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+			status.Draining = draining
+		}); err != nil {
+			log.Error(err, "Failed to update instance draining status")
+			return ctrl.Result{}, err
+		}
+		if draining {
+			log.Info("Instance is draining before expiry", "instance", instance.Name, "until", instance.Spec.Until.Time)
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "Draining", "Instance expires at %s", instance.Spec.Until.Format(time.RFC3339))
+		}
 	}
 
-	// 2b. Check if flag was validated - delete instance (janitor cleanup)
+	// 2b. Check if flag was validated - delete instance (janitor cleanup) unless the challenge keeps it running
 	if instance.Status.FlagValidated {
-		log.Info("Flag validated, deleting instance", "instance", instance.Name)
-		if err := r.Delete(ctx, instance); err != nil {
-			log.Error(err, "Failed to delete validated instance")
+		destroy, err := destroyOnFlag(ctx, r.Client, instance)
+		if err != nil {
+			log.Error(err, "Failed to get Challenge of validated instance", "challengeName", instance.Spec.ChallengeName)
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		if destroy {
+			log.Info("Flag validated, deleting instance", "instance", instance.Name)
+			r.Recorder.Event(instance, corev1.EventTypeNormal, "FlagValidated", "Flag validated, deleting instance")
+			result, err := r.deleteInstance(ctx, instance)
+			if err != nil {
+				log.Error(err, "Failed to delete validated instance")
+				return ctrl.Result{}, err
+			}
+			return result, nil
+		}
+		if !meta.IsStatusConditionTrue(instance.Status.Conditions, conditionSolved) {
+			if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+					Type:    conditionSolved,
+					Status:  metav1.ConditionTrue,
+					Reason:  "FlagValidated",
+					Message: "Flag validated, the challenge keeps solved instances running",
+				})
+			}); err != nil {
+				log.Error(err, "Failed to mark instance solved")
+				return ctrl.Result{}, err
+			}
+			log.Info("Flag validated, keeping instance running", "instance", instance.Name)
+			r.Recorder.Event(instance, corev1.EventTypeNormal, "Solved", "Flag validated, instance kept running")
+		}
 	}
 
 	// 3. Fetch the Challenge template
@@ -82,35 +197,106 @@ func (r *ChallengeInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	}
 	if err := r.Get(ctx, challengeKey, challenge); err != nil {
 		log.Error(err, "Failed to get Challenge", "challengeName", instance.Spec.ChallengeName)
-		instance.Status.Phase = "Failed"
-		if updateErr := r.Status().Update(ctx, instance); updateErr != nil {
+		if apierrors.IsNotFound(err) {
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ChallengeNotFound",
+				"Challenge %s not found", instance.Spec.ChallengeName)
+		}
+		if updateErr := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+			status.Phase = "Failed"
+		}); updateErr != nil {
 			log.Error(updateErr, "Failed to update instance status")
 		}
 		return ctrl.Result{}, err
 	}
 
+	// 3a. Kill-switch: a disabled challenge loses all its instances
+	if builder.ChallengeDisabled(challenge) {
+		log.Info("Challenge disabled, deleting instance", "instance", instance.Name, "challenge", challenge.Name)
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ChallengeDisabled", "Challenge %s is disabled, deleting instance", challenge.Name)
+		result, err := r.deleteInstance(ctx, instance)
+		if err != nil {
+			log.Error(err, "Failed to delete instance of disabled challenge")
+			return ctrl.Result{}, err
+		}
+		return result, nil
+	}
+
+	// Ensure the per-source namespace and quota before anything is created in them, the flag Secret included
+	if err := r.ensureSourceNamespace(ctx, instance); errors.Is(err, errCleanupPending) {
+		return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// 4. Generate flag if not exists
-	if len(instance.Status.Flags) == 0 {
+	if len(instance.Status.Flags) == 0 && len(instance.Status.FlagHashes) == 0 {
 		flag, err := flaggen.Generate(
 			challenge.Spec.Scenario.FlagTemplate,
 			instance.Name,
 			instance.Spec.SourceID,
 			instance.Spec.ChallengeID,
 		)
+		if errors.Is(err, flaggen.ErrUnsafeFlag) {
+			// Retrying can't help; the Challenge watch brings the instance back once the template is fixed
+			log.Error(err, "Flag template yields an unsafe flag", "challenge", challenge.Name)
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidFlagTemplate", "Flag template rejected: %v", err)
+			if updateErr := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				status.Phase = "Failed"
+			}); updateErr != nil {
+				log.Error(updateErr, "Failed to update instance status")
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, nil
+		}
 		if err != nil {
 			log.Error(err, "Failed to generate flag")
 			return ctrl.Result{}, err
 		}
-		instance.Status.Flags = []string{flag}
-		instance.Status.Phase = "Pending"
-		if err := r.Status().Update(ctx, instance); err != nil {
+		if r.HashFlags {
+			if err := r.storeFlagHash(ctx, instance, flag); err != nil {
+				return ctrl.Result{}, err
+			}
+		} else {
+			instance.Status.Flags = []string{flag}
+		}
+		generated := instance.Status.DeepCopy()
+		if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+			status.Flags = generated.Flags
+			status.FlagSalt = generated.FlagSalt
+			status.FlagHashes = generated.FlagHashes
+			status.Phase = "Pending"
+		}); err != nil {
 			log.Error(err, "Failed to update instance status with flag")
 			return ctrl.Result{}, err
 		}
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "FlagGenerated", "Generated flag for instance")
 		// Requeue to continue with deployment creation
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// File delivery mounts the flag from the instance flag Secret; hashed flags already wrote it
+	if builder.FlagDeliveredAsFile(challenge) && len(instance.Status.Flags) > 0 {
+		if err := r.ensureFlagSecret(ctx, instance, instance.Status.Flags[0]); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Apply the failure policy to crash-looping challenge containers
+	if stop, err := r.checkFailurePolicy(ctx, instance, challenge); err != nil {
+		return ctrl.Result{}, err
+	} else if stop {
+		// Keep honouring expiry for instances that were given up on
+		if instance.Spec.Until != nil {
+			return ctrl.Result{RequeueAfter: r.withDrainWakeup(instance, time.Until(instance.Spec.Until.Time))}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Ensure PersistentVolumeClaim before the Deployment that mounts it
+	if err := r.ensurePersistentVolumeClaim(ctx, instance, challenge); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Ensure Deployment
 	if err := r.ensureDeployment(ctx, instance, challenge); err != nil {
 		return ctrl.Result{}, err
@@ -136,36 +322,364 @@ func (r *ChallengeInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return ctrl.Result{}, err
 	}
 
+	// Ensure PodDisruptionBudget
+	if err := r.ensurePodDisruptionBudget(ctx, instance, challenge); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Check if Deployment is ready & update status
-	if err := r.checkAndUpdateReady(ctx, instance); err != nil {
+	if err := r.checkAndUpdateReady(ctx, instance, challenge); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Publish the resolved connection info to the instance pods
+	if err := r.ensureInstanceInfo(ctx, instance); err != nil {
 		return ctrl.Result{}, err
 	}
+	r.recordActiveInstances(ctx, instance, false)
 
 	// Requeue to check status periodically
-	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	return ctrl.Result{RequeueAfter: r.withDrainWakeup(instance, requeueInterval(instance, challenge))}, nil
+}
+
+// defaultReconcileInterval is the periodic requeue used until an instance is stably Ready
+const defaultReconcileInterval = 10 * time.Second
+
+// readyResyncInterval is the periodic requeue of stably Ready instances
+// It re-evaluates the failure policy and repairs drift in per-source namespaces, which Owns() watches don't cover
+const readyResyncInterval = 5 * time.Minute
+
+// requeueInterval returns when to reconcile an instance again
+// Once the instance is Ready with connection info, watches on owned resources and the Challenge
+// carry most changes: it is only resynced every readyResyncInterval, or sooner for its expiry
+func requeueInterval(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) time.Duration {
+	if instance.Status.Ready && instance.Status.ConnectionInfo != "" {
+		if instance.Spec.Until != nil {
+			return min(max(time.Until(instance.Spec.Until.Time), time.Second), readyResyncInterval)
+		}
+		return readyResyncInterval
+	}
+
+	if challenge.Spec.ReconcileIntervalSeconds > 0 {
+		return time.Duration(challenge.Spec.ReconcileIntervalSeconds) * time.Second
+	}
+	return defaultReconcileInterval
+}
+
+// GetDrainWarningPeriod reads how long before expiry instances are flagged as draining from DRAIN_WARNING_PERIOD
+func GetDrainWarningPeriod() time.Duration {
+	if v := os.Getenv("DRAIN_WARNING_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// inDrainWindow reports whether the instance expires within the drain warning period
+func (r *ChallengeInstanceReconciler) inDrainWindow(instance *ctfv1alpha1.ChallengeInstance) bool {
+	return r.DrainWarning > 0 && instance.Spec.Until != nil && time.Until(instance.Spec.Until.Time) <= r.DrainWarning
+}
+
+// withDrainWakeup shortens a requeue so the instance is reconciled when its drain window opens
+func (r *ChallengeInstanceReconciler) withDrainWakeup(instance *ctfv1alpha1.ChallengeInstance, requeue time.Duration) time.Duration {
+	if r.DrainWarning <= 0 || instance.Spec.Until == nil || instance.Status.Draining {
+		return requeue
+	}
+	wakeup := time.Until(instance.Spec.Until.Time) - r.DrainWarning
+	if wakeup > 0 && (requeue == 0 || wakeup < requeue) {
+		return wakeup
+	}
+	return requeue
+}
+
+// instancesForChallenge maps a Challenge change to reconcile requests for its instances
+func (r *ChallengeInstanceReconciler) instancesForChallenge(ctx context.Context, obj client.Object) []reconcile.Request {
+	challenge, ok := obj.(*ctfv1alpha1.Challenge)
+	if !ok {
+		return nil
+	}
+
+	instanceList := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := r.List(ctx, instanceList, client.InNamespace(challenge.Namespace)); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list instances for Challenge", "challenge", challenge.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, item := range instanceList.Items {
+		if item.Spec.ChallengeName == challenge.Name {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// storeFlagHash writes the plaintext flag to the instance flag Secret and its salted hash to status
+// The caller persists the status
+func (r *ChallengeInstanceReconciler) storeFlagHash(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, flag string) error {
+	log := logf.FromContext(ctx)
+
+	salt, err := flaggen.GenerateSalt()
+	if err != nil {
+		log.Error(err, "Failed to generate flag salt")
+		return err
+	}
+
+	if err := r.ensureFlagSecret(ctx, instance, flag); err != nil {
+		return err
+	}
+
+	instance.Status.FlagSalt = salt
+	instance.Status.FlagHashes = []string{flaggen.HashFlag(flag, salt)}
+	return nil
+}
+
+// updateStatus applies mutate to the instance status and persists it; on a conflict the
+// instance is read again and mutate re-applied, so a concurrent write doesn't abort the reconcile
+func (r *ChallengeInstanceReconciler) updateStatus(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, mutate func(*ctfv1alpha1.ChallengeInstanceStatus)) error {
+	stale := false
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if stale {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(instance), instance); err != nil {
+				return err
+			}
+		}
+		stale = true
+		mutate(&instance.Status)
+		return r.Status().Update(ctx, instance)
+	})
+}
+
+// ensureFlagSecret creates the instance flag Secret, or rewrites it when it holds another flag
+func (r *ChallengeInstanceReconciler) ensureFlagSecret(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, flag string) error {
+	log := logf.FromContext(ctx)
+
+	secret := builder.BuildFlagSecret(instance, flag)
+	if err := r.setOwner(instance, secret); err != nil {
+		log.Error(err, "Failed to set owner reference on flag Secret")
+		return err
+	}
+
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		if err := r.Create(ctx, secret); err != nil {
+			log.Error(err, "Failed to create flag Secret")
+			return err
+		}
+	case err != nil:
+		log.Error(err, "Failed to get flag Secret")
+		return err
+	case string(existing.Data[builder.FlagSecretKey]) != flag:
+		// Left over from a generation whose status update failed: replace the flag
+		existing.Data = nil
+		existing.StringData = secret.StringData
+		if err := r.Update(ctx, existing); err != nil {
+			log.Error(err, "Failed to update flag Secret")
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteInstance deletes the instance and runs its cleanup right away through the finalizer path
+func (r *ChallengeInstanceReconciler) deleteInstance(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if err := r.Delete(ctx, instance, builder.DeleteOptions(r.DeletePropagation)...); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Re-read to pick up the deletion timestamp set by the API server
+	if err := r.Get(ctx, client.ObjectKeyFromObject(instance), instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	return r.finalizeInstance(ctx, instance)
+}
+
+// finalizeInstance runs the cleanup steps for a deleted instance, then removes the finalizer
+// Owned resources (Deployments, Services, ...) are garbage-collected through owner references;
+// cleanup steps cover anything that isn't owned. Safe to run more than once.
+func (r *ChallengeInstanceReconciler) finalizeInstance(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(instance, instanceFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	for _, cleanup := range r.cleanupSteps() {
+		if err := cleanup(ctx, instance); err != nil {
+			if errors.Is(err, errCleanupPending) {
+				log.Info("Waiting for instance resources to be deleted", "instance", instance.Name)
+				return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+			}
+			log.Error(err, "Failed to clean up instance", "instance", instance.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	r.warmups.Delete(instance.UID)
+	controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+	if err := r.Update(ctx, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	log.Info("Instance cleaned up", "instance", instance.Name)
+	return ctrl.Result{}, nil
+}
+
+// cleanupSteps returns the teardown steps run before an instance is removed, in order
+func (r *ChallengeInstanceReconciler) cleanupSteps() []cleanupFunc {
+	return []cleanupFunc{
+		r.cleanupServices,
+		r.cleanupSourceNamespace,
+		r.cleanupMetrics,
+	}
+}
+
+// cleanupServices deletes the instance Services and waits until they are gone,
+// so their NodePorts are released instead of leaking until garbage collection catches up
+func (r *ChallengeInstanceReconciler) cleanupServices(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error {
+	pending := false
+	for _, name := range []string{builder.ServiceName(instance), builder.AttackBoxServiceName(instance)} {
+		service := &corev1.Service{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: builder.TargetNamespace(instance)}, service); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		pending = true
+		if service.DeletionTimestamp.IsZero() {
+			if err := r.Delete(ctx, service); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	if pending {
+		return errCleanupPending
+	}
+	return nil
+}
+
+// cleanupSourceNamespace removes the instance resources from its per-source namespace
+// Owner references can't cross namespaces, so garbage collection doesn't cover them:
+// the namespace is deleted with the last instance of the source, otherwise the instance resources are
+func (r *ChallengeInstanceReconciler) cleanupSourceNamespace(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error {
+	if !builder.NamespaceIsolation() {
+		return nil
+	}
+	log := logf.FromContext(ctx)
+	namespace := builder.TargetNamespace(instance)
+
+	instanceList := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := r.List(ctx, instanceList, client.InNamespace(instance.Namespace)); err != nil {
+		log.Error(err, "Failed to list instances of the source")
+		return err
+	}
+	last := true
+	for _, item := range instanceList.Items {
+		if item.Name != instance.Name && item.DeletionTimestamp.IsZero() && builder.TargetNamespace(&item) == namespace {
+			last = false
+			break
+		}
+	}
+
+	if last {
+		log.Info("Deleting source namespace with its last instance", "namespace", namespace)
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+		if err := r.Delete(ctx, ns); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete source namespace")
+			return err
+		}
+		return nil
+	}
+
+	objMeta := metav1.ObjectMeta{Namespace: namespace}
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: withName(objMeta, builder.DeploymentName(instance))},
+		&appsv1.Deployment{ObjectMeta: withName(objMeta, builder.AttackBoxDeploymentName(instance))},
+		&networkingv1.Ingress{ObjectMeta: withName(objMeta, builder.IngressName(instance))},
+		&networkingv1.NetworkPolicy{ObjectMeta: withName(objMeta, builder.NetworkPolicyName(instance))},
+		&networkingv1.NetworkPolicy{ObjectMeta: withName(objMeta, builder.ChallengeNetworkPolicyName(instance))},
+		&policyv1.PodDisruptionBudget{ObjectMeta: withName(objMeta, builder.PodDisruptionBudgetName(instance))},
+		&corev1.ConfigMap{ObjectMeta: withName(objMeta, builder.InstanceInfoConfigMapName(instance))},
+		&corev1.Secret{ObjectMeta: withName(objMeta, builder.FlagSecretName(instance))},
+		&corev1.PersistentVolumeClaim{ObjectMeta: withName(objMeta, builder.PersistentVolumeClaimName(instance))},
+	}
+	for _, obj := range objects {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete instance resource", "name", obj.GetName())
+			return err
+		}
+	}
+	return nil
+}
+
+// withName returns a copy of objMeta with the given name
+func withName(objMeta metav1.ObjectMeta, name string) metav1.ObjectMeta {
+	objMeta.Name = name
+	return objMeta
+}
+
+// cleanupMetrics drops the deleted instance from the running instances gauge and Challenge status
+func (r *ChallengeInstanceReconciler) cleanupMetrics(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error {
+	r.recordActiveInstances(ctx, instance, true)
+	return nil
 }
 
 // ensureDeployment creates/updates the primary Deployment for the instance
 func (r *ChallengeInstanceReconciler) ensureDeployment(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) error {
 	log := logf.FromContext(ctx)
 
-	deployment := builder.BuildDeployment(instance, challenge)
-	if err := controllerutil.SetControllerReference(instance, deployment, r.Scheme); err != nil {
+	if err := builder.ValidateVolumes(challenge); err != nil {
+		log.Error(err, "Invalid challenge volumes", "challenge", challenge.Name)
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidVolumes", "Challenge %s has invalid volumes: %v", challenge.Name, err)
+		return err
+	}
+
+	deployment, err := builder.BuildDeployment(instance, challenge)
+	if err != nil {
+		log.Error(err, "Invalid challenge scenario", "challenge", challenge.Name)
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidScenario", "Challenge %s can't be deployed: %v", challenge.Name, err)
+		return err
+	}
+	if err := r.setOwner(instance, deployment); err != nil {
 		log.Error(err, "Failed to set owner reference on Deployment")
 		return err
 	}
+	if err := builder.SetSpecHash(deployment, deployment.Spec); err != nil {
+		log.Error(err, "Failed to hash Deployment spec")
+		return err
+	}
 
 	existingDeployment := &appsv1.Deployment{}
-	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, existingDeployment)
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, existingDeployment)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("Creating Deployment", "deployment", deployment.Name)
+			r.warnClampedResources(ctx, instance, challenge)
 			if err := r.Create(ctx, deployment); err != nil {
 				log.Error(err, "Failed to create Deployment")
 				return err
 			}
-			instance.Status.DeploymentName = deployment.Name
-			if err := r.Status().Update(ctx, instance); err != nil {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DeploymentCreated", "Created Deployment %s", deployment.Name)
+			if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				status.DeploymentName = deployment.Name
+			}); err != nil {
 				log.Error(err, "Failed to update instance status with deployment name")
 				return err
 			}
@@ -173,6 +687,133 @@ func (r *ChallengeInstanceReconciler) ensureDeployment(ctx context.Context, inst
 			log.Error(err, "Failed to get Deployment")
 			return err
 		}
+	} else if !existingDeployment.DeletionTimestamp.IsZero() {
+		// Being replaced below, created back once it is gone
+		return nil
+	} else if !equality.Semantic.DeepEqual(existingDeployment.Spec.Selector, deployment.Spec.Selector) {
+		// The selector is immutable: replace Deployments created under a previous selector contract
+		log.Info("Recreating Deployment with a changed selector", "deployment", deployment.Name)
+		if err := r.Delete(ctx, existingDeployment, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete Deployment with a stale selector")
+			return err
+		}
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DeploymentRecreated", "Recreating Deployment %s, its pod selector changed", deployment.Name)
+	} else if builder.SpecHashChanged(existingDeployment, deployment) {
+		// Challenge spec changed (image, env, resources, ports): roll the pods to the new template
+		log.Info("Updating drifted Deployment", "deployment", deployment.Name)
+		r.warnClampedResources(ctx, instance, challenge)
+		existingDeployment.Labels = deployment.Labels
+		mergeAnnotations(existingDeployment, deployment)
+		existingDeployment.Spec.Replicas = deployment.Spec.Replicas
+		existingDeployment.Spec.Strategy = deployment.Spec.Strategy
+		existingDeployment.Spec.Template = deployment.Spec.Template
+		if err := r.Update(ctx, existingDeployment); err != nil {
+			log.Error(err, "Failed to update Deployment")
+			return err
+		}
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DeploymentUpdated", "Updated Deployment %s to match the Challenge spec", deployment.Name)
+	}
+	return nil
+}
+
+// warnClampedResources reports challenge resources that were clamped down to MAX_RESOURCES
+func (r *ChallengeInstanceReconciler) warnClampedResources(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) {
+	_, clamped := builder.ChallengeResources(challenge)
+	if len(clamped) == 0 {
+		return
+	}
+	logf.FromContext(ctx).Info("Challenge resources exceed the operator cap, clamping",
+		"challenge", challenge.Name, "clamped", clamped)
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "ResourcesClamped",
+		"Challenge %s resources clamped: %s", challenge.Name, strings.Join(clamped, ", "))
+}
+
+// setOwner makes the instance the controller of obj
+// Resources in a per-source namespace can't carry an owner reference to the instance;
+// cleanupSourceNamespace removes them instead
+func (r *ChallengeInstanceReconciler) setOwner(instance *ctfv1alpha1.ChallengeInstance, obj client.Object) error {
+	if obj.GetNamespace() != instance.Namespace {
+		return nil
+	}
+	return controllerutil.SetControllerReference(instance, obj, r.Scheme)
+}
+
+// ensureSourceNamespace creates the per-source namespace and keeps its ResourceQuota in line with SOURCE_QUOTA
+func (r *ChallengeInstanceReconciler) ensureSourceNamespace(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error {
+	if !builder.NamespaceIsolation() {
+		return nil
+	}
+	log := logf.FromContext(ctx)
+
+	namespace := builder.BuildSourceNamespace(instance)
+	existingNamespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace.Name}, existingNamespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to get source namespace")
+			return err
+		}
+		log.Info("Creating source namespace", "namespace", namespace.Name)
+		if err := r.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+			log.Error(err, "Failed to create source namespace")
+			return err
+		}
+	} else if !existingNamespace.DeletionTimestamp.IsZero() {
+		// The previous last instance of the source just left; wait for the namespace to go away
+		return errCleanupPending
+	}
+
+	quota := builder.BuildSourceQuota(instance)
+	if quota == nil {
+		return nil
+	}
+	existingQuota := &corev1.ResourceQuota{}
+	err := r.Get(ctx, types.NamespacedName{Name: quota.Name, Namespace: quota.Namespace}, existingQuota)
+	switch {
+	case apierrors.IsNotFound(err):
+		log.Info("Creating source ResourceQuota", "namespace", quota.Namespace)
+		if err := r.Create(ctx, quota); err != nil && !apierrors.IsAlreadyExists(err) {
+			log.Error(err, "Failed to create source ResourceQuota")
+			return err
+		}
+	case err != nil:
+		log.Error(err, "Failed to get source ResourceQuota")
+		return err
+	case !equality.Semantic.DeepEqual(existingQuota.Spec.Hard, quota.Spec.Hard):
+		log.Info("Updating source ResourceQuota", "namespace", quota.Namespace)
+		existingQuota.Spec.Hard = quota.Spec.Hard
+		if err := r.Update(ctx, existingQuota); err != nil {
+			log.Error(err, "Failed to update source ResourceQuota")
+			return err
+		}
+	}
+	return nil
+}
+
+// ensurePersistentVolumeClaim creates the instance PVC if persistence is configured
+// The claim is owned by the instance, so it is garbage-collected when the instance is deleted
+func (r *ChallengeInstanceReconciler) ensurePersistentVolumeClaim(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) error {
+	log := logf.FromContext(ctx)
+
+	pvc := builder.BuildPersistentVolumeClaim(instance, challenge)
+	if pvc == nil {
+		return nil
+	}
+	if err := r.setOwner(instance, pvc); err != nil {
+		log.Error(err, "Failed to set owner reference on PersistentVolumeClaim")
+		return err
+	}
+
+	existingPVC := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, existingPVC)
+	if err != nil && apierrors.IsNotFound(err) {
+		log.Info("Creating PersistentVolumeClaim", "pvc", pvc.Name)
+		if err := r.Create(ctx, pvc); err != nil {
+			log.Error(err, "Failed to create PersistentVolumeClaim")
+			return err
+		}
+	} else if err != nil {
+		log.Error(err, "Failed to get PersistentVolumeClaim")
+		return err
 	}
 	return nil
 }
@@ -182,10 +823,26 @@ func (r *ChallengeInstanceReconciler) ensureService(ctx context.Context, instanc
 	log := logf.FromContext(ctx)
 
 	service := builder.BuildService(instance, challenge)
-	if err := controllerutil.SetControllerReference(instance, service, r.Scheme); err != nil {
+	if service == nil {
+		// Worker-only challenge: nothing to connect to
+		if instance.Status.ConnectionInfo != builder.ConnectionInfoNone {
+			if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				status.ConnectionInfo = builder.ConnectionInfoNone
+			}); err != nil {
+				log.Error(err, "Failed to update connection info")
+				return err
+			}
+		}
+		return nil
+	}
+	if err := r.setOwner(instance, service); err != nil {
 		log.Error(err, "Failed to set owner reference on Service")
 		return err
 	}
+	if err := builder.SetSpecHash(service, service.Spec); err != nil {
+		log.Error(err, "Failed to hash Service spec")
+		return err
+	}
 
 	existingService := &corev1.Service{}
 	err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, existingService)
@@ -196,8 +853,9 @@ func (r *ChallengeInstanceReconciler) ensureService(ctx context.Context, instanc
 				log.Error(err, "Failed to create Service")
 				return err
 			}
-			instance.Status.ServiceName = service.Name
-			if err := r.Status().Update(ctx, instance); err != nil {
+			if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				status.ServiceName = service.Name
+			}); err != nil {
 				log.Error(err, "Failed to update instance status with service name")
 				return err
 			}
@@ -206,11 +864,28 @@ func (r *ChallengeInstanceReconciler) ensureService(ctx context.Context, instanc
 			return err
 		}
 	} else {
+		if builder.SpecHashChanged(existingService, service) {
+			log.Info("Updating drifted Service", "service", service.Name)
+			updateServiceSpec(existingService, service)
+			if err := r.Update(ctx, existingService); err != nil {
+				log.Error(err, "Failed to update Service")
+				return err
+			}
+		}
+
 		// Service exists, update connection info if NodePort/LoadBalancer is assigned
-		connInfo := builder.GetConnectionInfo(existingService, r.getNodeIP())
-		if connInfo != "" && instance.Status.ConnectionInfo != connInfo {
-			instance.Status.ConnectionInfo = connInfo
-			if err := r.Status().Update(ctx, instance); err != nil {
+		nodeName, nodeIP := r.instanceNode(ctx, instance)
+		connInfo := builder.GetConnectionInfo(existingService, nodeIP)
+		if existingService.Spec.Type == corev1.ServiceTypeClusterIP && builder.IngressEnabled(challenge) {
+			connInfo = "" // the Ingress URL, set by ensureIngress, is the endpoint
+		}
+		if (connInfo != "" && instance.Status.ConnectionInfo != connInfo) || instance.Status.NodeName != nodeName {
+			if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				if connInfo != "" {
+					status.ConnectionInfo = connInfo
+				}
+				status.NodeName = nodeName
+			}); err != nil {
 				log.Error(err, "Failed to update connection info")
 				return err
 			}
@@ -219,12 +894,57 @@ func (r *ChallengeInstanceReconciler) ensureService(ctx context.Context, instanc
 	return nil
 }
 
+// updateServiceSpec applies the desired Service to existing, keeping the allocated ClusterIP and NodePorts
+func updateServiceSpec(existing, desired *corev1.Service) {
+	allocated := map[string]int32{}
+	for _, port := range existing.Spec.Ports {
+		allocated[port.Name] = port.NodePort
+	}
+
+	ports := make([]corev1.ServicePort, len(desired.Spec.Ports))
+	copy(ports, desired.Spec.Ports)
+	if desired.Spec.Type != corev1.ServiceTypeClusterIP {
+		for i := range ports {
+			if ports[i].NodePort == 0 {
+				ports[i].NodePort = allocated[ports[i].Name]
+			}
+		}
+	}
+
+	existing.Labels = desired.Labels
+	mergeAnnotations(existing, desired)
+	existing.Spec.Type = desired.Spec.Type
+	existing.Spec.Selector = desired.Spec.Selector
+	existing.Spec.Ports = ports
+	existing.Spec.SessionAffinity = desired.Spec.SessionAffinity
+	if existing.Spec.SessionAffinity == "" {
+		existing.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	}
+	existing.Spec.SessionAffinityConfig = desired.Spec.SessionAffinityConfig
+	existing.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+	if existing.Spec.ExternalTrafficPolicy == "" && existing.Spec.Type != corev1.ServiceTypeClusterIP {
+		existing.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyCluster
+	}
+}
+
+// mergeAnnotations copies desired annotations onto existing, keeping those set by other controllers
+func mergeAnnotations(existing, desired metav1.Object) {
+	annotations := existing.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for k, v := range desired.GetAnnotations() {
+		annotations[k] = v
+	}
+	existing.SetAnnotations(annotations)
+}
+
 // ensureAttackBox creates attackbox deployment and service if configured
 func (r *ChallengeInstanceReconciler) ensureAttackBox(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) error {
 	log := logf.FromContext(ctx)
 
 	if attackBoxDeploy := builder.BuildAttackBoxDeployment(instance, challenge); attackBoxDeploy != nil {
-		if err := controllerutil.SetControllerReference(instance, attackBoxDeploy, r.Scheme); err != nil {
+		if err := r.setOwner(instance, attackBoxDeploy); err != nil {
 			log.Error(err, "Failed to set owner reference on AttackBox Deployment")
 			return err
 		}
@@ -244,7 +964,7 @@ func (r *ChallengeInstanceReconciler) ensureAttackBox(ctx context.Context, insta
 	}
 
 	if attackBoxSvc := builder.BuildAttackBoxService(instance, challenge); attackBoxSvc != nil {
-		if err := controllerutil.SetControllerReference(instance, attackBoxSvc, r.Scheme); err != nil {
+		if err := r.setOwner(instance, attackBoxSvc); err != nil {
 			log.Error(err, "Failed to set owner reference on AttackBox Service")
 			return err
 		}
@@ -271,10 +991,14 @@ func (r *ChallengeInstanceReconciler) ensureIngress(ctx context.Context, instanc
 	log := logf.FromContext(ctx)
 
 	if ingress := builder.BuildIngress(instance, challenge); ingress != nil {
-		if err := controllerutil.SetControllerReference(instance, ingress, r.Scheme); err != nil {
+		if err := r.setOwner(instance, ingress); err != nil {
 			log.Error(err, "Failed to set owner reference on Ingress")
 			return err
 		}
+		if err := builder.SetSpecHash(ingress, ingress.Spec, ingress.Annotations); err != nil {
+			log.Error(err, "Failed to hash Ingress spec")
+			return err
+		}
 
 		existingIngress := &networkingv1.Ingress{}
 		err := r.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, existingIngress)
@@ -284,28 +1008,30 @@ func (r *ChallengeInstanceReconciler) ensureIngress(ctx context.Context, instanc
 				log.Error(err, "Failed to create Ingress")
 				return err
 			}
+		} else if err == nil && builder.SpecHashChanged(existingIngress, ingress) {
+			log.Info("Updating drifted Ingress", "ingress", ingress.Name)
+			existingIngress.Labels = ingress.Labels
+			existingIngress.Annotations = ingress.Annotations // owned entirely by the Challenge spec
+			existingIngress.Spec = ingress.Spec
+			if err := r.Update(ctx, existingIngress); err != nil {
+				log.Error(err, "Failed to update Ingress")
+				return err
+			}
 		}
 
-		// Always set connection info when Ingress is enabled (whether just created or already exists)
-		// Only update if not already set to avoid overwriting
-		if instance.Status.ConnectionInfo == "" {
-			hostname := builder.GetIngressHostname(instance, challenge)
-			if hostname != "" {
-				if challenge.Spec.Scenario.AttackBox != nil && challenge.Spec.Scenario.AttackBox.Enabled {
-					instance.Status.ConnectionInfo = fmt.Sprintf("Challenge: http://%s\nTerminal: http://%s/terminal", hostname, hostname)
-				} else {
-					instance.Status.ConnectionInfo = fmt.Sprintf("http://%s", hostname)
-				}
-				if err := r.Status().Update(ctx, instance); err != nil {
-					log.Error(err, "Failed to update instance connection info after creating Ingress")
-					return err
-				}
-				log.Info("Set connectionInfo for instance", "instance", instance.Name, "connectionInfo", instance.Status.ConnectionInfo)
-				// Persist connectionInfo immediately
-				if err := r.Status().Update(ctx, instance); err != nil {
-					log.Error(err, "Failed to update instance status with connectionInfo")
-				}
+		// The Ingress URL is the endpoint of ClusterIP challenges: recompute it on every reconcile so
+		// PUBLIC_BASE_URL or host template changes reach existing instances
+		// NodePort/LoadBalancer challenges keep the Service connection info once ensureService set it
+		connInfo := builder.IngressConnectionInfo(instance, challenge)
+		owned := instance.Status.ConnectionInfo == "" || builder.ChallengeServiceType(challenge) == corev1.ServiceTypeClusterIP
+		if connInfo != "" && owned && connInfo != instance.Status.ConnectionInfo {
+			if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+				status.ConnectionInfo = connInfo
+			}); err != nil {
+				log.Error(err, "Failed to update instance connection info after creating Ingress")
+				return err
 			}
+			log.Info("Set connectionInfo for instance", "instance", instance.Name, "connectionInfo", instance.Status.ConnectionInfo)
 		} else if err != nil && !apierrors.IsNotFound(err) {
 			log.Error(err, "Failed to get Ingress")
 			return err
@@ -314,12 +1040,19 @@ func (r *ChallengeInstanceReconciler) ensureIngress(ctx context.Context, instanc
 	return nil
 }
 
-// ensureNetworkPolicy creates networkpolicy if configured
+// ensureNetworkPolicy creates the attackbox and challenge pod network policies if configured
 func (r *ChallengeInstanceReconciler) ensureNetworkPolicy(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) error {
 	log := logf.FromContext(ctx)
 
-	if netpol := builder.BuildNetworkPolicy(instance, challenge); netpol != nil {
-		if err := controllerutil.SetControllerReference(instance, netpol, r.Scheme); err != nil {
+	created := false
+	for _, netpol := range []*networkingv1.NetworkPolicy{
+		builder.BuildNetworkPolicy(instance, challenge),
+		builder.BuildChallengeNetworkPolicy(instance, challenge),
+	} {
+		if netpol == nil {
+			continue
+		}
+		if err := r.setOwner(instance, netpol); err != nil {
 			log.Error(err, "Failed to set owner reference on NetworkPolicy")
 			return err
 		}
@@ -332,16 +1065,88 @@ func (r *ChallengeInstanceReconciler) ensureNetworkPolicy(ctx context.Context, i
 				log.Error(err, "Failed to create NetworkPolicy")
 				return err
 			}
+			created = true
 		} else if err != nil && !apierrors.IsNotFound(err) {
 			log.Error(err, "Failed to get NetworkPolicy")
 			return err
 		}
 	}
+
+	if invalid := builder.InvalidEgressCIDRs(challenge); created && len(invalid) > 0 {
+		log.Info("Skipping invalid egress CIDRs", "challenge", challenge.Name, "cidrs", invalid)
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "InvalidEgressCIDRs",
+			"Challenge %s egress CIDRs skipped: %s", challenge.Name, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// ensurePodDisruptionBudget creates/updates the PodDisruptionBudget of the challenge pods if configured
+func (r *ChallengeInstanceReconciler) ensurePodDisruptionBudget(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) error {
+	log := logf.FromContext(ctx)
+
+	pdb := builder.BuildPodDisruptionBudget(instance, challenge)
+	if pdb == nil {
+		return nil
+	}
+	if err := r.setOwner(instance, pdb); err != nil {
+		log.Error(err, "Failed to set owner reference on PodDisruptionBudget")
+		return err
+	}
+
+	existingPDB := &policyv1.PodDisruptionBudget{}
+	err := r.Get(ctx, types.NamespacedName{Name: pdb.Name, Namespace: pdb.Namespace}, existingPDB)
+	if err != nil && apierrors.IsNotFound(err) {
+		log.Info("Creating PodDisruptionBudget", "poddisruptionbudget", pdb.Name)
+		if err := r.Create(ctx, pdb); err != nil {
+			log.Error(err, "Failed to create PodDisruptionBudget")
+			return err
+		}
+	} else if err != nil {
+		log.Error(err, "Failed to get PodDisruptionBudget")
+		return err
+	} else if !equality.Semantic.DeepEqual(existingPDB.Spec.MinAvailable, pdb.Spec.MinAvailable) {
+		existingPDB.Spec.MinAvailable = pdb.Spec.MinAvailable
+		if err := r.Update(ctx, existingPDB); err != nil {
+			log.Error(err, "Failed to update PodDisruptionBudget")
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureInstanceInfo creates/updates the ConfigMap mounted in the instance pods with its connection details
+func (r *ChallengeInstanceReconciler) ensureInstanceInfo(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error {
+	log := logf.FromContext(ctx)
+
+	configMap := builder.BuildInstanceInfoConfigMap(instance)
+	if err := r.setOwner(instance, configMap); err != nil {
+		log.Error(err, "Failed to set owner reference on instance info ConfigMap")
+		return err
+	}
+
+	existingConfigMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, existingConfigMap)
+	if err != nil && apierrors.IsNotFound(err) {
+		log.Info("Creating instance info ConfigMap", "configmap", configMap.Name)
+		if err := r.Create(ctx, configMap); err != nil {
+			log.Error(err, "Failed to create instance info ConfigMap")
+			return err
+		}
+	} else if err != nil {
+		log.Error(err, "Failed to get instance info ConfigMap")
+		return err
+	} else if !maps.Equal(existingConfigMap.Data, configMap.Data) {
+		existingConfigMap.Data = configMap.Data
+		if err := r.Update(ctx, existingConfigMap); err != nil {
+			log.Error(err, "Failed to update instance info ConfigMap")
+			return err
+		}
+	}
 	return nil
 }
 
 // checkAndUpdateReady checks deployment readiness and updates instance status accordingly
-func (r *ChallengeInstanceReconciler) checkAndUpdateReady(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) error {
+func (r *ChallengeInstanceReconciler) checkAndUpdateReady(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) error {
 	log := logf.FromContext(ctx)
 
 	// If deployment name not set, nothing to do
@@ -350,34 +1155,355 @@ func (r *ChallengeInstanceReconciler) checkAndUpdateReady(ctx context.Context, i
 	}
 
 	deployment := &appsv1.Deployment{}
-	if err := r.Get(ctx, types.NamespacedName{Name: instance.Status.DeploymentName, Namespace: instance.Namespace}, deployment); err != nil {
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Status.DeploymentName, Namespace: builder.TargetNamespace(instance)}, deployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Deployment is being recreated, check again on the next reconcile
+			return nil
+		}
 		return err
 	}
 
-	if deployment.Status.ReadyReplicas > 0 {
-		if instance.Status.Phase != "Running" || !instance.Status.Ready {
-			instance.Status.Phase = "Running"
-			instance.Status.Ready = true
+	// Every desired replica must be ready, so a multi-replica challenge isn't handed out half up
+	if deployment.Status.ReadyReplicas < ptr.Deref(deployment.Spec.Replicas, 1) {
+		return nil
+	}
+	if challenge.Spec.Scenario.Readiness == "Endpoints" && instance.Status.ServiceName != "" {
+		hasEndpoints, err := r.serviceHasReadyEndpoints(ctx, instance)
+		if err != nil {
+			log.Error(err, "Failed to list EndpointSlices")
+			return err
+		}
+		if !hasEndpoints {
+			// The periodic requeue checks again until the Service routes to a pod
+			log.Info("Waiting for Service endpoints", "service", instance.Status.ServiceName)
+			return nil
+		}
+	}
 
-			// Update connection info from service if possible
-			if instance.Status.ServiceName != "" {
-				existingService := &corev1.Service{}
-				if err := r.Get(ctx, types.NamespacedName{Name: instance.Status.ServiceName, Namespace: instance.Namespace}, existingService); err == nil {
-					connInfo := builder.GetConnectionInfo(existingService, r.getNodeIP())
-					if connInfo != "" {
-						instance.Status.ConnectionInfo = connInfo
-					}
-				}
+	if instance.Status.Phase != "Running" || !instance.Status.Ready {
+		// Warm the app up once before handing it out, so no player gets the cold start
+		// The request runs in the background; the periodic requeue marks the instance ready once it is done
+		if !instance.Status.Ready && r.warmingUp(ctx, instance, challenge) {
+			return nil
+		}
+
+		// Connection info comes from the Service, resolved by ensureService earlier in this reconcile
+		if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+			status.Phase = "Running"
+			status.Ready = true
+		}); err != nil {
+			log.Error(err, "Failed to update instance status to Running")
+			return err
+		}
+		log.Info("Instance is now Running", "instance", instance.Name, "connectionInfo", instance.Status.ConnectionInfo)
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "Running", "Instance is now Running")
+	}
+	return nil
+}
+
+// serviceHasReadyEndpoints reports whether any EndpointSlice of the instance Service has a ready endpoint
+func (r *ChallengeInstanceReconciler) serviceHasReadyEndpoints(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) (bool, error) {
+	slices := &discoveryv1.EndpointSliceList{}
+	if err := r.List(ctx, slices,
+		client.InNamespace(builder.TargetNamespace(instance)),
+		client.MatchingLabels{discoveryv1.LabelServiceName: instance.Status.ServiceName},
+	); err != nil {
+		return false, err
+	}
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			// A nil ready condition means ready, per the EndpointSlice API
+			if ptr.Deref(endpoint.Conditions.Ready, true) {
+				return true, nil
 			}
+		}
+	}
+	return false, nil
+}
 
-			if err := r.Status().Update(ctx, instance); err != nil {
-				log.Error(err, "Failed to update instance status to Running")
-				return err
+// warmingUp starts the challenge warmup request, if any, and reports whether it is still in flight
+// Failures are reported but never block readiness
+func (r *ChallengeInstanceReconciler) warmingUp(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) bool {
+	log := logf.FromContext(ctx)
+
+	if done, started := r.warmups.Load(instance.UID); started {
+		select {
+		case <-done.(chan struct{}):
+			r.warmups.Delete(instance.UID)
+			return false
+		default:
+			return true
+		}
+	}
+
+	if challenge.Spec.Scenario.Warmup == nil {
+		return false
+	}
+	if policy := challenge.Spec.Scenario.NetworkPolicy; policy != nil && policy.Enabled {
+		// The challenge policy only admits the ingress controller and the instance pods
+		log.Info("Skipping challenge warmup, the network policy blocks the operator", "instance", instance.Name)
+		return false
+	}
+	podIP := r.challengePodIP(ctx, instance)
+	if podIP == "" {
+		return false
+	}
+	url := builder.WarmupURL(challenge, podIP)
+	if url == "" {
+		return false
+	}
+
+	timeout := 10 * time.Second
+	if seconds := challenge.Spec.Scenario.Warmup.TimeoutSeconds; seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	done := make(chan struct{})
+	r.warmups.Store(instance.UID, done)
+	go func() {
+		defer close(done)
+		// Detached from the reconcile, which returns before the request completes
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		r.warmUp(ctx, instance, url)
+	}()
+	return true
+}
+
+// warmUp sends the challenge warmup request, reporting failures as WarmupFailed events
+func (r *ChallengeInstanceReconciler) warmUp(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, url string) {
+	log := logf.FromContext(ctx)
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = httpClient.Do(req); err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				err = fmt.Errorf("warmup returned %s", resp.Status)
 			}
-			log.Info("Instance is now Running", "instance", instance.Name, "connectionInfo", instance.Status.ConnectionInfo)
 		}
 	}
-	return nil
+	if err != nil {
+		log.Error(err, "Challenge warmup failed", "instance", instance.Name, "url", url)
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "WarmupFailed", fmt.Sprintf("Warmup request to %s failed: %v", url, err))
+		return
+	}
+	log.Info("Challenge warmed up", "instance", instance.Name, "url", url)
+}
+
+// challengePodIP returns the IP of a running challenge pod of the instance, "" if there is none
+func (r *ChallengeInstanceReconciler) challengePodIP(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) string {
+	log := logf.FromContext(ctx)
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(builder.TargetNamespace(instance)), client.MatchingLabels{
+		"app":             "challenge",
+		"ctf.io/instance": instance.Name,
+	}); err != nil {
+		log.Error(err, "Failed to list challenge pods")
+		return ""
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp.IsZero() && pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			return pod.Status.PodIP
+		}
+	}
+	return ""
+}
+
+// recordActiveInstances recomputes the running instances of the instance's challenge,
+// publishing them on the gauge and in the Challenge's status.activeInstances
+// When deleted is true the instance is excluded, since the cache may still return it
+func (r *ChallengeInstanceReconciler) recordActiveInstances(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, deleted bool) {
+	log := logf.FromContext(ctx)
+
+	instanceList := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := r.List(ctx, instanceList,
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"ctf.io/challenge": instance.Spec.ChallengeID},
+	); err != nil {
+		log.Error(err, "Failed to list instances for challenge", "challengeID", instance.Spec.ChallengeID)
+		return
+	}
+
+	running := 0
+	for _, item := range instanceList.Items {
+		if item.DeletionTimestamp != nil {
+			continue
+		}
+		if deleted && item.Name == instance.Name {
+			continue
+		}
+		if item.Status.Phase == "Running" {
+			running++
+		}
+	}
+	metrics.InstancesRunning.WithLabelValues(instance.Spec.ChallengeID).Set(float64(running))
+
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := r.Get(ctx, types.NamespacedName{Name: instance.Spec.ChallengeName, Namespace: instance.Namespace}, challenge); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to get Challenge for active instances", "challengeName", instance.Spec.ChallengeName)
+		}
+		return
+	}
+	if challenge.Status.ActiveInstances == int32(running) {
+		return
+	}
+
+	// Merge patch without resourceVersion: concurrent instance reconciles must not conflict
+	patch := client.MergeFrom(challenge.DeepCopy())
+	challenge.Status.ActiveInstances = int32(running)
+	if err := r.Status().Patch(ctx, challenge, patch); err != nil {
+		log.Error(err, "Failed to update Challenge active instances", "challenge", challenge.Name)
+	}
+}
+
+// checkFailurePolicy inspects challenge container restarts and applies the challenge's failure policy
+// Returns true when the instance has been given up on and must not be reconciled further
+func (r *ChallengeInstanceReconciler) checkFailurePolicy(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	policy := challenge.Spec.Scenario.FailurePolicy
+	if policy == nil {
+		return false, nil
+	}
+	if instance.Status.Phase == "Failed" && meta.IsStatusConditionFalse(instance.Status.Conditions, conditionHealthy) {
+		return true, nil
+	}
+
+	maxRestarts := policy.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = 5
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(builder.TargetNamespace(instance)), client.MatchingLabels{
+		"app":             "challenge",
+		"ctf.io/instance": instance.Name,
+	}); err != nil {
+		log.Error(err, "Failed to list challenge pods")
+		return false, err
+	}
+
+	// After a recreate, only pods of the new Deployment count
+	var recreatedAt metav1.Time
+	if cond := meta.FindStatusCondition(instance.Status.Conditions, conditionHealthy); cond != nil && instance.Status.Recreated {
+		recreatedAt = cond.LastTransitionTime
+	}
+
+	restarts := int32(0)
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || pod.CreationTimestamp.Before(&recreatedAt) {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "challenge" && status.RestartCount > restarts {
+				restarts = status.RestartCount
+			}
+		}
+	}
+	if restarts < maxRestarts {
+		return false, nil
+	}
+
+	if policy.RecreateOnce && !instance.Status.Recreated {
+		log.Info("Challenge container restart limit reached, recreating Deployment", "instance", instance.Name, "restarts", restarts)
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      builder.DeploymentName(instance),
+				Namespace: builder.TargetNamespace(instance),
+			},
+		}
+		if err := r.Delete(ctx, deployment); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete crash-looping Deployment")
+			return false, err
+		}
+
+		if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+			status.Recreated = true
+			status.Ready = false
+			status.Phase = "Pending"
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:    conditionHealthy,
+				Status:  metav1.ConditionFalse,
+				Reason:  "Recreated",
+				Message: fmt.Sprintf("Challenge container restarted %d times, Deployment recreated", restarts),
+			})
+		}); err != nil {
+			log.Error(err, "Failed to update instance status after recreate")
+			return false, err
+		}
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "Recreated",
+			"Challenge container restarted %d times, Deployment recreated", restarts)
+		// Continue reconciling so ensureDeployment brings the Deployment back
+		return false, nil
+	}
+
+	log.Info("Challenge container restart limit reached, marking instance Failed", "instance", instance.Name, "restarts", restarts)
+	if err := r.updateStatus(ctx, instance, func(status *ctfv1alpha1.ChallengeInstanceStatus) {
+		status.Phase = "Failed"
+		status.Ready = false
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    conditionHealthy,
+			Status:  metav1.ConditionFalse,
+			Reason:  "RestartLimitExceeded",
+			Message: fmt.Sprintf("Challenge container restarted %d times (limit %d)", restarts, maxRestarts),
+		})
+	}); err != nil {
+		log.Error(err, "Failed to update instance status to Failed")
+		return false, err
+	}
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "RestartLimitExceeded",
+		"Challenge container restarted %d times (limit %d)", restarts, maxRestarts)
+	return true, nil
+}
+
+// instanceNode returns the node running the challenge pod and the IP users should connect to
+// The IP is the node's external IP, falling back to the configured node IP when the pod isn't
+// scheduled yet or the node has no external address
+func (r *ChallengeInstanceReconciler) instanceNode(ctx context.Context, instance *ctfv1alpha1.ChallengeInstance) (string, string) {
+	log := logf.FromContext(ctx)
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(builder.TargetNamespace(instance)), client.MatchingLabels{
+		"app":             "challenge",
+		"ctf.io/instance": instance.Name,
+	}); err != nil {
+		log.Error(err, "Failed to list challenge pods")
+		return "", r.getNodeIP()
+	}
+
+	nodeName := ""
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		nodeName = pod.Spec.NodeName
+		if pod.Status.Phase == corev1.PodRunning {
+			break
+		}
+	}
+	if nodeName == "" {
+		return "", r.getNodeIP()
+	}
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		log.Error(err, "Failed to get node", "node", nodeName)
+		return nodeName, r.getNodeIP()
+	}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeExternalIP && addr.Address != "" {
+			return nodeName, addr.Address
+		}
+	}
+	return nodeName, r.getNodeIP()
 }
 
 // getNodeIP returns the node IP for connection info
@@ -395,12 +1521,19 @@ func (r *ChallengeInstanceReconciler) getNodeIP() string {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ChallengeInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("challengeinstance-controller")
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ctfv1alpha1.ChallengeInstance{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&corev1.ConfigMap{}).
 		Owns(&networkingv1.Ingress{}).
 		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&ctfv1alpha1.Challenge{}, handler.EnqueueRequestsFromMapFunc(r.instancesForChallenge)).
 		Named("challengeinstance").
 		Complete(r)
 }
@@ -18,16 +18,36 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+	"github.com/leo/chall-operator/pkg/builder"
 )
 
 var _ = Describe("ChallengeInstance Controller", func() {
@@ -83,10 +103,15 @@ var _ = Describe("ChallengeInstance Controller", func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
 			resource := &ctfv1alpha1.ChallengeInstance{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
-			Expect(err).NotTo(HaveOccurred())
-
-			By("Cleanup the specific resource instance ChallengeInstance")
-			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			if err == nil {
+				By("Cleanup the specific resource instance ChallengeInstance")
+				// No controller runs in envtest, so release the finalizer by hand
+				controllerutil.RemoveFinalizer(resource, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
 
 			By("Cleanup the Challenge resource")
 			challenge := &ctfv1alpha1.Challenge{}
@@ -98,17 +123,1362 @@ var _ = Describe("ChallengeInstance Controller", func() {
 
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
+			recorder := record.NewFakeRecorder(100)
 			controllerReconciler := &ChallengeInstanceReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
 			})
 			Expect(err).NotTo(HaveOccurred())
+
+			By("Recording an Event for the generated flag")
+			Expect(recorder.Events).To(Receive(ContainSubstring("FlagGenerated")))
 			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
+
+		It("should retry a status update that conflicts with a concurrent write", func() {
+			base, err := client.NewWithWatch(cfg, client.Options{Scheme: k8sClient.Scheme()})
+			Expect(err).NotTo(HaveOccurred())
+
+			conflicts := 0
+			c := interceptor.NewClient(base, interceptor.Funcs{
+				SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+					if conflicts == 0 {
+						By("Writing the instance behind the reconciler's back, making its copy stale")
+						conflicts++
+						concurrent := &ctfv1alpha1.ChallengeInstance{}
+						Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), concurrent)).To(Succeed())
+						meta.SetStatusCondition(&concurrent.Status.Conditions, metav1.Condition{
+							Type: "Concurrent", Status: metav1.ConditionTrue, Reason: "Test",
+						})
+						Expect(k8sClient.Status().Update(ctx, concurrent)).To(Succeed())
+					}
+					return c.SubResource(subResource).Update(ctx, obj, opts...)
+				},
+			})
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   c,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conflicts).To(Equal(1))
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Flags).To(HaveLen(1))
+			Expect(resource.Status.Phase).To(Equal("Pending"))
+			Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, "Concurrent")).To(BeTrue(), "the concurrent write must survive the retry")
+		})
+
+		It("should add the cleanup finalizer and release it on deletion", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(instanceFinalizer))
+
+			By("Deleting the resource and reconciling twice")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			for i := 0; i < 2; i++ {
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err = k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should keep the finalizer until the instance Service is gone", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Holding the Service with a foreign finalizer")
+			serviceKey := types.NamespacedName{Name: resourceName + "-svc", Namespace: "default"}
+			service := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, serviceKey, service)).To(Succeed())
+			controllerutil.AddFinalizer(service, "test.ctf.io/hold")
+			Expect(k8sClient.Update(ctx, service)).To(Succeed())
+
+			By("Deleting the instance while its Service is still terminating")
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(instanceFinalizer))
+
+			By("Releasing the Service and reconciling again")
+			Expect(k8sClient.Get(ctx, serviceKey, service)).To(Succeed())
+			controllerutil.RemoveFinalizer(service, "test.ctf.io/hold")
+			Expect(k8sClient.Update(ctx, service)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should run the finalizer cleanup when deleting an expired instance", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expiring the instance")
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			past := metav1.NewTime(time.Now().Add(-time.Minute))
+			resource.Spec.Until = &past
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("Reconciling the expired instance")
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			// Cleanup already ran in the same pass: the Service was deleted before the finalizer is released
+			service := &corev1.Service{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-svc", Namespace: "default"}, service)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should flag the instance as draining before deleting it at expiry", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:       k8sClient,
+				Scheme:       k8sClient.Scheme(),
+				Recorder:     record.NewFakeRecorder(100),
+				DrainWarning: time.Minute,
+			}
+
+			By("Setting an expiry outside the warning period")
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			later := metav1.NewTime(time.Now().Add(10 * time.Minute))
+			resource.Spec.Until = &later
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 9*time.Minute))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Draining).To(BeFalse())
+
+			By("Moving the expiry into the warning period")
+			soon := metav1.NewTime(time.Now().Add(30 * time.Second))
+			resource.Spec.Until = &soon
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Draining).To(BeTrue())
+
+			By("Reaching the expiry")
+			past := metav1.NewTime(time.Now().Add(-time.Second))
+			resource.Spec.Until = &past
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			for i := 0; i < 2; i++ {
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			err = k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should refresh the resources derived from the expiry when the instance is renewed", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:       k8sClient,
+				Scheme:       k8sClient.Scheme(),
+				Recorder:     record.NewFakeRecorder(100),
+				DrainWarning: time.Minute,
+			}
+
+			By("Reconciling an instance about to expire")
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			soon := metav1.NewTime(time.Now().Add(30 * time.Second).Truncate(time.Second))
+			resource.Spec.Until = &soon
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Draining).To(BeTrue())
+
+			configMap := &corev1.ConfigMap{}
+			configMapKey := types.NamespacedName{Name: builder.InstanceInfoConfigMapName(resource), Namespace: "default"}
+			Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+			Expect(configMap.Data).To(HaveKeyWithValue("until", soon.UTC().Format(time.RFC3339)))
+
+			By("Renewing the instance, as the API does")
+			renewed := metav1.NewTime(time.Now().Add(10 * time.Minute).Truncate(time.Second))
+			resource.Spec.Until = &renewed
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Draining).To(BeFalse())
+			Expect(k8sClient.Get(ctx, configMapKey, configMap)).To(Succeed())
+			Expect(configMap.Data).To(HaveKeyWithValue("until", renewed.UTC().Format(time.RFC3339)))
+		})
+
+		It("should keep a solved instance running when the challenge disables destroyOnFlag", func() {
+			recorder := record.NewFakeRecorder(100)
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.DestroyOnFlag = ptr.To(false)
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("Validating the flag, as the API does")
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Status.FlagValidated = true
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, conditionSolved)).To(BeTrue())
+			Eventually(recorder.Events).Should(Receive(ContainSubstring("Solved")))
+
+			By("Deleting it once the challenge destroys solved instances again")
+			challenge.Spec.DestroyOnFlag = nil
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			if err := k8sClient.Get(ctx, typeNamespacedName, resource); err == nil {
+				Expect(resource.DeletionTimestamp.IsZero()).To(BeFalse())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("should publish the resolved connection info in the instance ConfigMap", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				NodeIP:   "192.0.2.10",
+			}
+
+			By("Reconciling until the NodePort connection info is resolved")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ConnectionInfo).To(HavePrefix("nc 192.0.2.10 "))
+
+			configMap := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-info", Namespace: "default"}, configMap)).To(Succeed())
+			Expect(configMap.Data).To(HaveKeyWithValue("connection-info", resource.Status.ConnectionInfo))
+			Expect(configMap.Data).To(HaveKeyWithValue("instance-id", resourceName))
+		})
+
+		It("should mount the flag from its Secret when delivered as a file", func() {
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.Scenario.FlagDelivery = "file"
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Flags).To(HaveLen(1))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-flag", Namespace: "default"}, secret)).To(Succeed())
+			Expect(string(secret.Data[builder.FlagSecretKey])).To(Equal(resource.Status.Flags[0]))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.VolumeMounts).To(ContainElement(HaveField("MountPath", builder.DefaultFlagPath)))
+			Expect(container.Env).NotTo(ContainElement(HaveField("Name", "FLAG")))
+		})
+
+		It("should protect the challenge pods with the configured disruption budget", func() {
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			minAvailable := intstr.FromString("50%")
+			challenge.Spec.Scenario.DisruptionBudget = &ctfv1alpha1.DisruptionBudgetSpec{MinAvailable: &minAvailable}
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			pdb := &policyv1.PodDisruptionBudget{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-pdb", Namespace: "default"}, pdb)).To(Succeed())
+			Expect(pdb.Spec.MinAvailable).To(HaveValue(Equal(minAvailable)))
+			Expect(pdb.Spec.Selector.MatchLabels).To(HaveKeyWithValue("ctf.io/instance", resourceName))
+			Expect(pdb.OwnerReferences).To(ContainElement(HaveField("Name", resourceName)))
+		})
+
+		It("should report the NodePort connection info once the Deployment is ready", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				NodeIP:   "192.0.2.10",
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeFalse())
+
+			service := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resource.Status.ServiceName, Namespace: "default"}, service)).To(Succeed())
+			Expect(service.Spec.Ports[0].NodePort).NotTo(BeZero())
+
+			By("Marking the Deployment ready, as the deployment controller would")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, deployment))).To(Succeed())
+			})
+			deployment.Status.Replicas = 1
+			deployment.Status.ReadyReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Phase).To(Equal("Running"))
+			Expect(resource.Status.ConnectionInfo).To(Equal(fmt.Sprintf("nc 192.0.2.10 %d", service.Spec.Ports[0].NodePort)))
+		})
+
+		It("should only report Running once every desired replica is ready", func() {
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.Scenario.Replicas = ptr.To(int32(3))
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, deployment))).To(Succeed())
+			})
+			Expect(deployment.Spec.Replicas).To(HaveValue(Equal(int32(3))))
+
+			By("Marking a single replica ready")
+			deployment.Status.Replicas = 3
+			deployment.Status.ReadyReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeFalse())
+
+			By("Marking every replica ready")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+			deployment.Status.ReadyReplicas = 3
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Phase).To(Equal("Running"))
+		})
+
+		It("should wait for Service endpoints when readiness requires them", func() {
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.Scenario.Readiness = "Endpoints"
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ServiceName).NotTo(BeEmpty())
+
+			By("Marking the Deployment ready before the Service has endpoints")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, deployment))).To(Succeed())
+			})
+			deployment.Status.Replicas = 1
+			deployment.Status.ReadyReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			slice := &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resource.Status.ServiceName + "-abcde",
+					Namespace: "default",
+					Labels:    map[string]string{discoveryv1.LabelServiceName: resource.Status.ServiceName},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{{
+					Addresses:  []string{"10.0.0.12"},
+					Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, slice)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, slice))).To(Succeed())
+			})
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeFalse())
+
+			By("Marking the endpoint ready, as the EndpointSlice controller would")
+			slice.Endpoints[0].Conditions.Ready = ptr.To(true)
+			Expect(k8sClient.Update(ctx, slice)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeTrue())
+			Expect(resource.Status.Phase).To(Equal("Running"))
+		})
+
+		It("should warm the challenge up exactly once before reporting it ready", func() {
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.Scenario.Warmup = &ctfv1alpha1.WarmupSpec{Path: "/warm"}
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			// The warmup runs in the background, guard what it records
+			var mu sync.Mutex
+			var warmups []string
+			readyAtWarmup := false
+			recorded := func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return slices.Clone(warmups)
+			}
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					current := &ctfv1alpha1.ChallengeInstance{}
+					Expect(k8sClient.Get(ctx, typeNamespacedName, current)).To(Succeed())
+					mu.Lock()
+					defer mu.Unlock()
+					warmups = append(warmups, req.URL.String())
+					readyAtWarmup = current.Status.Ready
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+				})},
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(recorded()).To(BeEmpty())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, deployment))).To(Succeed())
+			})
+
+			By("Marking the challenge pod running and ready")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName + "-pod",
+					Namespace: "default",
+					Labels:    map[string]string{"app": "challenge", "ctf.io/instance": resourceName},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "challenge", Image: "nginx:latest"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, pod))).To(Succeed())
+			})
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.PodIP = "10.0.0.7"
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+			deployment.Status.Replicas = 1
+			deployment.Status.ReadyReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			By("Starting the warmup without blocking the reconcile")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(recorded).Should(HaveLen(1))
+
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			// Straight to the challenge port, not the Service
+			Expect(recorded()).To(Equal([]string{"http://10.0.0.7:8080/warm"}))
+			mu.Lock()
+			Expect(readyAtWarmup).To(BeFalse())
+			mu.Unlock()
+
+			resource := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Ready).To(BeTrue())
+		})
+
+		It("should update the Deployment when the Challenge image changes", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			deploymentKey := types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentKey, deployment)).To(Succeed())
+			oldHash := deployment.Annotations[builder.SpecHashAnnotation]
+			Expect(oldHash).NotTo(BeEmpty())
+
+			By("Patching the Challenge image")
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-challenge", Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.Scenario.Image = "nginx:1.27"
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, deploymentKey, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("nginx:1.27"))
+			Expect(deployment.Annotations[builder.SpecHashAnnotation]).NotTo(Equal(oldHash))
+		})
+
+		It("should recreate a Deployment created under a previous selector contract", func() {
+			deploymentKey := types.NamespacedName{Name: resourceName + "-deployment", Namespace: "default"}
+
+			By("Creating a Deployment selecting on ctf.io/instance only, as older releases did")
+			oldLabels := map[string]string{"ctf.io/instance": resourceName}
+			old := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: deploymentKey.Name, Namespace: deploymentKey.Namespace},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: oldLabels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: oldLabels},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "challenge", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, old)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: deploymentKey.Name, Namespace: deploymentKey.Namespace},
+				}))).To(Succeed())
+			})
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling until the Deployment is replaced")
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, deploymentKey, deployment)).To(Succeed())
+			Expect(deployment.UID).NotTo(Equal(old.UID))
+			Expect(deployment.Spec.Selector.MatchLabels).To(Equal(builder.ChallengeSelectorLabels(&ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName},
+			})))
+		})
 	})
-})
+
+	Context("When a challenge container keeps restarting", func() {
+		const (
+			challengeName = "crashy-challenge"
+			instanceName  = "crashy-instance"
+		)
+
+		ctx := context.Background()
+		instanceKey := types.NamespacedName{Name: instanceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			By("creating a Challenge with a failure policy")
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID: challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:         "nginx:latest",
+						Port:          8080,
+						ExposeType:    "NodePort",
+						FailurePolicy: &ctfv1alpha1.FailurePolicySpec{MaxRestarts: 3},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			By("creating the ChallengeInstance")
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      "crashy-user",
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+
+			By("creating a challenge pod reporting many restarts")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      instanceName + "-pod",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app":             "challenge",
+						"ctf.io/instance": instanceName,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "challenge", Image: "nginx:latest"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "challenge", RestartCount: 5}}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			pod := &corev1.Pod{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: instanceName + "-pod", Namespace: "default"}, pod); err == nil {
+				Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+			}
+
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			if err := k8sClient.Get(ctx, instanceKey, instance); err == nil {
+				controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+		})
+
+		It("should mark the instance Failed once the restart limit is reached", func() {
+			recorder := record.NewFakeRecorder(100)
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			By("Reconciling until the failure policy is applied")
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, instanceKey, instance)).To(Succeed())
+			Expect(instance.Status.Phase).To(Equal("Failed"))
+			Expect(instance.Status.Ready).To(BeFalse())
+
+			cond := meta.FindStatusCondition(instance.Status.Conditions, conditionHealthy)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal("RestartLimitExceeded"))
+		})
+	})
+
+	Context("When counting active instances of a challenge", func() {
+		const challengeName = "busy-challenge"
+
+		ctx := context.Background()
+		instanceNames := []string{"busy-instance-1", "busy-instance-2"}
+
+		BeforeEach(func() {
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID:       challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:latest", Port: 8080},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			for _, name := range instanceNames {
+				instance := &ctfv1alpha1.ChallengeInstance{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: "default",
+						Labels:    map[string]string{"ctf.io/challenge": challengeName},
+					},
+					Spec: ctfv1alpha1.ChallengeInstanceSpec{
+						ChallengeID:   challengeName,
+						SourceID:      name,
+						ChallengeName: challengeName,
+						Since:         metav1.Now(),
+					},
+				}
+				Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+				instance.Status.Phase = "Running"
+				Expect(k8sClient.Status().Update(ctx, instance)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			for _, name := range instanceNames {
+				instance := &ctfv1alpha1.ChallengeInstance{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, instance); err == nil {
+					Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+				}
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+		})
+
+		It("should write the running count into the Challenge status", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+			challengeKey := types.NamespacedName{Name: challengeName, Namespace: "default"}
+
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceNames[0], Namespace: "default"}, instance)).To(Succeed())
+
+			By("Counting both running instances")
+			controllerReconciler.recordActiveInstances(ctx, instance, false)
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, challengeKey, challenge)).To(Succeed())
+			Expect(challenge.Status.ActiveInstances).To(Equal(int32(2)))
+
+			By("Excluding an instance being deleted")
+			controllerReconciler.recordActiveInstances(ctx, instance, true)
+			Expect(k8sClient.Get(ctx, challengeKey, challenge)).To(Succeed())
+			Expect(challenge.Status.ActiveInstances).To(Equal(int32(1)))
+		})
+	})
+
+	Context("When reconciling a worker-only challenge", func() {
+		const (
+			challengeName = "worker-challenge"
+			instanceName  = "worker-instance"
+		)
+
+		ctx := context.Background()
+		instanceKey := types.NamespacedName{Name: instanceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID: challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:      "worker:latest",
+						ExposeType: "None",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      "worker-user",
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			if err := k8sClient.Get(ctx, instanceKey, instance); err == nil {
+				controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+		})
+
+		It("should not create a Service and report no connection info", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling past flag generation")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName + "-deployment", Namespace: "default"}, deployment)).To(Succeed())
+
+			service := &corev1.Service{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: instanceName + "-svc", Namespace: "default"}, service)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, instanceKey, instance)).To(Succeed())
+			Expect(instance.Status.ConnectionInfo).To(Equal("N/A"))
+		})
+	})
+
+	Context("When a challenge is disabled", func() {
+		const (
+			challengeName = "disabled-challenge"
+			instanceName  = "disabled-instance"
+		)
+
+		ctx := context.Background()
+		instanceKey := types.NamespacedName{Name: instanceName, Namespace: "default"}
+		challengeKey := types.NamespacedName{Name: challengeName, Namespace: "default"}
+
+		BeforeEach(func() {
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID: challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:      "nginx:latest",
+						Port:       80,
+						ExposeType: "NodePort",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      "disabled-user",
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			if err := k8sClient.Get(ctx, instanceKey, instance); err == nil {
+				controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, challengeKey, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+		})
+
+		It("should tear down the instances of the challenge", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling the running instance")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("Setting the kill-switch annotation on the Challenge")
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, challengeKey, challenge)).To(Succeed())
+			challenge.Annotations = map[string]string{builder.DisabledAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			// The Challenge watch enqueues every instance of the challenge
+			Expect(controllerReconciler.instancesForChallenge(ctx, challenge)).To(ContainElement(
+				reconcile.Request{NamespacedName: instanceKey}))
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, instanceKey, &ctfv1alpha1.ChallengeInstance{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When a challenge sets a reconcile interval", func() {
+		const (
+			challengeName = "lb-challenge"
+			instanceName  = "lb-instance"
+		)
+
+		ctx := context.Background()
+		instanceKey := types.NamespacedName{Name: instanceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID:                       challengeName,
+					ReconcileIntervalSeconds: 3,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:      "nginx:latest",
+						Port:       8080,
+						ExposeType: "LoadBalancer",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      "lb-user",
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			if err := k8sClient.Get(ctx, instanceKey, instance); err == nil {
+				controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+		})
+
+		It("should requeue not-ready instances at the challenge interval", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			By("Reconciling past flag generation")
+			var result reconcile.Result
+			for i := 0; i < 2; i++ {
+				var err error
+				result, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(result.RequeueAfter).To(Equal(3 * time.Second))
+		})
+
+		It("should slow periodic requeues to a resync once stably ready", func() {
+			instance := &ctfv1alpha1.ChallengeInstance{
+				Status: ctfv1alpha1.ChallengeInstanceStatus{Ready: true, ConnectionInfo: "nc 203.0.113.10 8080"},
+			}
+			challenge := &ctfv1alpha1.Challenge{Spec: ctfv1alpha1.ChallengeSpec{ReconcileIntervalSeconds: 3}}
+			Expect(requeueInterval(instance, challenge)).To(Equal(readyResyncInterval))
+
+			until := metav1.NewTime(time.Now().Add(time.Hour))
+			instance.Spec.Until = &until
+			Expect(requeueInterval(instance, challenge)).To(Equal(readyResyncInterval))
+
+			until = metav1.NewTime(time.Now().Add(time.Minute))
+			Expect(requeueInterval(instance, challenge)).To(BeNumerically("~", time.Minute, time.Second))
+		})
+	})
+
+	Context("When the challenge pod runs on a node with an external IP", func() {
+		const (
+			challengeName = "multinode-challenge"
+			instanceName  = "multinode-instance"
+			nodeName      = "ctf-node-2"
+		)
+
+		ctx := context.Background()
+		instanceKey := types.NamespacedName{Name: instanceName, Namespace: "default"}
+
+		BeforeEach(func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			node.Status.Addresses = []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+				{Type: corev1.NodeExternalIP, Address: "203.0.113.2"},
+			}
+			Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      instanceName + "-pod",
+					Namespace: "default",
+					Labels:    map[string]string{"app": "challenge", "ctf.io/instance": instanceName},
+				},
+				Spec: corev1.PodSpec{
+					NodeName:   nodeName,
+					Containers: []corev1.Container{{Name: "challenge", Image: "nginx:latest"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID: challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:      "nginx:latest",
+						Port:       8080,
+						ExposeType: "NodePort",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      "multinode-user",
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			if err := k8sClient.Get(ctx, instanceKey, instance); err == nil {
+				controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+
+			pod := &corev1.Pod{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: instanceName + "-pod", Namespace: "default"}, pod); err == nil {
+				Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+			}
+
+			node := &corev1.Node{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: nodeName}, node); err == nil {
+				Expect(k8sClient.Delete(ctx, node)).To(Succeed())
+			}
+		})
+
+		It("should build NodePort connection info from the pod's node", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				NodeIP:   "192.0.2.10",
+			}
+
+			By("Reconciling until the NodePort connection info is resolved")
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, instanceKey, instance)).To(Succeed())
+			Expect(instance.Status.NodeName).To(Equal(nodeName))
+			Expect(instance.Status.ConnectionInfo).To(HavePrefix("nc 203.0.113.2 "))
+		})
+	})
+
+	Context("When instances are isolated in per-source namespaces", func() {
+		const (
+			challengeName = "isolated-challenge"
+			instanceName  = "isolated-instance"
+			sourceID      = "isolated-team"
+		)
+
+		ctx := context.Background()
+		instanceKey := types.NamespacedName{Name: instanceName, Namespace: "default"}
+		sourceNamespace := builder.SourceNamespaceName(sourceID)
+
+		BeforeEach(func() {
+			DeferCleanup(os.Unsetenv, "NAMESPACE_ISOLATION")
+			DeferCleanup(os.Unsetenv, "SOURCE_QUOTA")
+			Expect(os.Setenv("NAMESPACE_ISOLATION", "true")).To(Succeed())
+			Expect(os.Setenv("SOURCE_QUOTA", "requests.cpu=2,pods=10")).To(Succeed())
+
+			challenge := &ctfv1alpha1.Challenge{
+				ObjectMeta: metav1.ObjectMeta{Name: challengeName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeSpec{
+					ID: challengeName,
+					Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+						Image:      "nginx:latest",
+						Port:       8080,
+						ExposeType: "NodePort",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      sourceID,
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			if err := k8sClient.Get(ctx, instanceKey, instance); err == nil {
+				controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			}
+
+			challenge := &ctfv1alpha1.Challenge{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge); err == nil {
+				Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+			}
+		})
+
+		It("should create the quota before the Deployment and delete the namespace with the last instance", func() {
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+			}
+
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: sourceNamespace}, namespace)).To(Succeed())
+
+			quota := &corev1.ResourceQuota{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: builder.SourceQuotaName, Namespace: sourceNamespace}, quota)).To(Succeed())
+			Expect(quota.Spec.Hard).To(HaveKey(corev1.ResourcePods))
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName + "-deployment", Namespace: sourceNamespace}, deployment)).To(Succeed())
+			Expect(deployment.OwnerReferences).To(BeEmpty())
+
+			By("Deleting the only instance of the source")
+			instance := &ctfv1alpha1.ChallengeInstance{}
+			Expect(k8sClient.Get(ctx, instanceKey, instance)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: instanceKey})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(errors.IsNotFound(k8sClient.Get(ctx, instanceKey, instance))).To(BeTrue())
+			}).Should(Succeed())
+
+			// envtest has no namespace controller, so the namespace stays Terminating
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: sourceNamespace}, namespace)).To(Succeed())
+			Expect(namespace.DeletionTimestamp).NotTo(BeNil())
+		})
+
+		It("should create the source namespace before the flag Secret of hashed and file flags", func() {
+			// A source of its own: the other test leaves its namespace Terminating
+			const hashedInstanceName = "isolated-hashed-instance"
+			hashedKey := types.NamespacedName{Name: hashedInstanceName, Namespace: "default"}
+			hashedNamespace := builder.SourceNamespaceName("isolated-hashed-team")
+
+			challenge := &ctfv1alpha1.Challenge{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: challengeName, Namespace: "default"}, challenge)).To(Succeed())
+			challenge.Spec.Scenario.FlagDelivery = "file"
+			Expect(k8sClient.Update(ctx, challenge)).To(Succeed())
+
+			instance := &ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: hashedInstanceName, Namespace: "default"},
+				Spec: ctfv1alpha1.ChallengeInstanceSpec{
+					ChallengeID:   challengeName,
+					SourceID:      "isolated-hashed-team",
+					ChallengeName: challengeName,
+					Since:         metav1.Now(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(func() {
+				if err := k8sClient.Get(ctx, hashedKey, instance); err == nil {
+					controllerutil.RemoveFinalizer(instance, instanceFinalizer)
+					Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+					Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, instance))).To(Succeed())
+				}
+			})
+
+			controllerReconciler := &ChallengeInstanceReconciler{
+				Client:    k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				Recorder:  record.NewFakeRecorder(100),
+				HashFlags: true,
+			}
+			for i := 0; i < 3; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: hashedKey})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(k8sClient.Get(ctx, hashedKey, instance)).To(Succeed())
+			Expect(instance.Status.FlagHashes).To(HaveLen(1))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: builder.FlagSecretName(instance), Namespace: hashedNamespace}, secret)).To(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: hashedInstanceName + "-deployment", Namespace: hashedNamespace}, deployment)).To(Succeed())
+		})
+	})
+})
+
+// roundTripFunc stubs the transport of an http.Client
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
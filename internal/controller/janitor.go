@@ -0,0 +1,165 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+	"github.com/leo/chall-operator/pkg/builder"
+	"github.com/leo/chall-operator/pkg/metrics"
+)
+
+// defaultJanitorInterval is how often the janitor scans instances when JANITOR_INTERVAL is unset
+const defaultJanitorInterval = 30 * time.Second
+
+// InstanceJanitor periodically deletes expired or solved instances, independently of their reconcile cadence
+// Deletion goes through the cleanup finalizer, which the reconciler then runs
+type InstanceJanitor struct {
+	client.Client
+	Recorder record.EventRecorder
+	Interval time.Duration
+	// DeletePropagation is the propagation policy of instance deletes; empty uses the API server default
+	DeletePropagation metav1.DeletionPropagation
+}
+
+// GetJanitorInterval reads the janitor scan interval from JANITOR_INTERVAL (e.g. "30s")
+func GetJanitorInterval() time.Duration {
+	if v := os.Getenv("JANITOR_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultJanitorInterval
+}
+
+// Start runs the scan loop until the manager stops
+func (j *InstanceJanitor) Start(ctx context.Context) error {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			j.sweep(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection makes only the leader replica delete instances
+func (j *InstanceJanitor) NeedLeaderElection() bool {
+	return true
+}
+
+// sweep deletes every instance past its Until or with a validated flag,
+// then publishes how many expired instances are still around on the overdue gauge
+func (j *InstanceJanitor) sweep(ctx context.Context) {
+	log := logf.FromContext(ctx).WithName("janitor")
+
+	instances := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := j.List(ctx, instances); err != nil {
+		log.Error(err, "Failed to list ChallengeInstances")
+		return
+	}
+
+	now := time.Now()
+	overdue := 0
+	defer func() { metrics.InstancesOverdue.Set(float64(overdue)) }()
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		expired := instance.Spec.Until != nil && now.After(instance.Spec.Until.Time)
+
+		// Already deleted by a previous sweep or the reconciler, but its cleanup hasn't completed
+		if !instance.DeletionTimestamp.IsZero() {
+			if expired {
+				overdue++
+			}
+			continue
+		}
+
+		if !expired {
+			if !instance.Status.FlagValidated {
+				continue
+			}
+			destroy, err := destroyOnFlag(ctx, j.Client, instance)
+			if err != nil {
+				log.Error(err, "Failed to get Challenge of validated instance", "instance", instance.Name)
+				continue
+			}
+			if !destroy {
+				continue
+			}
+		}
+
+		if err := j.Delete(ctx, instance, builder.DeleteOptions(j.DeletePropagation)...); err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Error(err, "Failed to delete instance", "instance", instance.Name)
+				if expired {
+					overdue++
+				}
+			}
+			continue
+		}
+
+		if expired {
+			log.Info("Deleted expired instance", "instance", instance.Name)
+			j.Recorder.Event(instance, corev1.EventTypeNormal, "Expired", "Instance expired, deleted by janitor")
+			metrics.InstancesExpired.WithLabelValues(instance.Spec.ChallengeID).Inc()
+		} else {
+			log.Info("Deleted solved instance", "instance", instance.Name)
+			j.Recorder.Event(instance, corev1.EventTypeNormal, "FlagValidated", "Flag validated, deleted by janitor")
+		}
+	}
+}
+
+// destroyOnFlag reports whether a validated instance is to be deleted: unless its Challenge sets
+// destroyOnFlag to false; instances of a Challenge that is gone are deleted as before
+func destroyOnFlag(ctx context.Context, c client.Reader, instance *ctfv1alpha1.ChallengeInstance) (bool, error) {
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := c.Get(ctx, types.NamespacedName{Name: instance.Spec.ChallengeName, Namespace: instance.Namespace}, challenge); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return builder.DestroyOnFlag(challenge), nil
+}
+
+// SetupWithManager registers the janitor as a manager runnable
+func (j *InstanceJanitor) SetupWithManager(mgr ctrl.Manager) error {
+	if j.Recorder == nil {
+		j.Recorder = mgr.GetEventRecorderFor("instance-janitor")
+	}
+	return mgr.Add(j)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+	"github.com/leo/chall-operator/pkg/metrics"
+)
+
+var _ = Describe("InstanceJanitor", func() {
+	ctx := context.Background()
+
+	newInstance := func(name string, until time.Time) *ctfv1alpha1.ChallengeInstance {
+		untilTime := metav1.NewTime(until)
+		return &ctfv1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: ctfv1alpha1.ChallengeInstanceSpec{
+				ChallengeID:   "janitor-challenge",
+				SourceID:      "janitor-user",
+				ChallengeName: "janitor-challenge",
+				Since:         metav1.Now(),
+				Until:         &untilTime,
+			},
+		}
+	}
+
+	It("should delete expired instances and keep live ones", func() {
+		expired := newInstance("janitor-expired", time.Now().Add(-time.Minute))
+		live := newInstance("janitor-live", time.Now().Add(time.Hour))
+		Expect(k8sClient.Create(ctx, expired)).To(Succeed())
+		Expect(k8sClient.Create(ctx, live)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, live)).To(Succeed())
+		}()
+
+		janitor := &InstanceJanitor{Client: k8sClient, Recorder: record.NewFakeRecorder(100)}
+		janitor.sweep(ctx)
+
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: "janitor-expired", Namespace: "default"}, expired)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "janitor-live", Namespace: "default"}, live)).To(Succeed())
+	})
+
+	It("should delete instances with a validated flag", func() {
+		solved := newInstance("janitor-solved", time.Now().Add(time.Hour))
+		Expect(k8sClient.Create(ctx, solved)).To(Succeed())
+		solved.Status.FlagValidated = true
+		Expect(k8sClient.Status().Update(ctx, solved)).To(Succeed())
+
+		janitor := &InstanceJanitor{Client: k8sClient, Recorder: record.NewFakeRecorder(100)}
+		janitor.sweep(ctx)
+
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: "janitor-solved", Namespace: "default"}, solved)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should keep solved instances of a challenge that disables destroyOnFlag", func() {
+		challenge := &ctfv1alpha1.Challenge{
+			ObjectMeta: metav1.ObjectMeta{Name: "janitor-keep", Namespace: "default"},
+			Spec: ctfv1alpha1.ChallengeSpec{
+				ID:            "janitor-keep",
+				DestroyOnFlag: ptr.To(false),
+				Scenario:      ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:latest", Port: 80},
+			},
+		}
+		Expect(k8sClient.Create(ctx, challenge)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, challenge)).To(Succeed())
+		}()
+
+		solved := newInstance("janitor-solved-kept", time.Now().Add(time.Hour))
+		solved.Spec.ChallengeName = "janitor-keep"
+		Expect(k8sClient.Create(ctx, solved)).To(Succeed())
+		defer func() {
+			Expect(k8sClient.Delete(ctx, solved)).To(Succeed())
+		}()
+		solved.Status.FlagValidated = true
+		Expect(k8sClient.Status().Update(ctx, solved)).To(Succeed())
+
+		janitor := &InstanceJanitor{Client: k8sClient, Recorder: record.NewFakeRecorder(100)}
+		janitor.sweep(ctx)
+
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "janitor-solved-kept", Namespace: "default"}, solved)).To(Succeed())
+	})
+
+	It("should report expired instances that linger as overdue", func() {
+		stuck := newInstance("janitor-stuck", time.Now().Add(-time.Minute))
+		// A foreign finalizer stands in for a cleanup that never completes
+		controllerutil.AddFinalizer(stuck, "test.ctf.io/hold")
+		Expect(k8sClient.Create(ctx, stuck)).To(Succeed())
+
+		janitor := &InstanceJanitor{Client: k8sClient, Recorder: record.NewFakeRecorder(100)}
+
+		By("Deleting the expired instance on the first sweep")
+		janitor.sweep(ctx)
+		Expect(testutil.ToFloat64(metrics.InstancesOverdue)).To(Equal(0.0))
+
+		By("Counting it as overdue while it is still around on the next sweep")
+		janitor.sweep(ctx)
+		Expect(testutil.ToFloat64(metrics.InstancesOverdue)).To(Equal(1.0))
+
+		By("Clearing the gauge once the instance is gone")
+		key := types.NamespacedName{Name: "janitor-stuck", Namespace: "default"}
+		Expect(k8sClient.Get(ctx, key, stuck)).To(Succeed())
+		controllerutil.RemoveFinalizer(stuck, "test.ctf.io/hold")
+		Expect(k8sClient.Update(ctx, stuck)).To(Succeed())
+		Eventually(func() bool {
+			return errors.IsNotFound(k8sClient.Get(ctx, key, stuck))
+		}).Should(BeTrue())
+
+		janitor.sweep(ctx)
+		Expect(testutil.ToFloat64(metrics.InstancesOverdue)).To(Equal(0.0))
+	})
+})
@@ -0,0 +1,157 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+	"github.com/leo/chall-operator/pkg/builder"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var challengelog = logf.Log.WithName("challenge-resource")
+
+// SetupChallengeWebhookWithManager registers the webhook for Challenge in the manager.
+func SetupChallengeWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&ctfv1alpha1.Challenge{}).
+		WithValidator(&ChallengeCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-ctf-ctf-io-v1alpha1-challenge,mutating=false,failurePolicy=fail,sideEffects=None,groups=ctf.ctf.io,resources=challenges,verbs=create;update,versions=v1alpha1,name=vchallenge-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ChallengeCustomValidator rejects Challenges whose options contradict each other
+type ChallengeCustomValidator struct{}
+
+var _ webhook.CustomValidator = &ChallengeCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Challenge.
+func (v *ChallengeCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	challenge, ok := obj.(*ctfv1alpha1.Challenge)
+	if !ok {
+		return nil, fmt.Errorf("expected a Challenge object but got %T", obj)
+	}
+	challengelog.Info("Validation for Challenge upon creation", "name", challenge.GetName())
+
+	return nil, validateChallenge(challenge)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Challenge.
+func (v *ChallengeCustomValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	challenge, ok := newObj.(*ctfv1alpha1.Challenge)
+	if !ok {
+		return nil, fmt.Errorf("expected a Challenge object for the newObj but got %T", newObj)
+	}
+	challengelog.Info("Validation for Challenge upon update", "name", challenge.GetName())
+
+	return nil, validateChallenge(challenge)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Challenge.
+func (v *ChallengeCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateChallenge centralizes the cross-field checks that the CRD schema can't express
+func validateChallenge(challenge *ctfv1alpha1.Challenge) error {
+	allErrs := validateScenario(&challenge.Spec.Scenario, field.NewPath("spec", "scenario"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: ctfv1alpha1.GroupVersion.Group, Kind: "Challenge"},
+		challenge.Name, allErrs)
+}
+
+// validateScenario returns an error per contradicting pair of scenario options
+func validateScenario(scenario *ctfv1alpha1.ChallengeScenarioSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	exposeType := scenario.ExposeType
+	ingressEnabled := scenario.Ingress != nil && scenario.Ingress.Enabled
+	attackBoxEnabled := scenario.AttackBox != nil && scenario.AttackBox.Enabled
+
+	if exposeType == builder.ExposeTypeNone {
+		if attackBoxEnabled {
+			allErrs = append(allErrs, field.Invalid(path.Child("attackBox", "enabled"), true,
+				"the attack box needs a challenge Service to reach; exposeType None creates none"))
+		}
+		if ingressEnabled {
+			allErrs = append(allErrs, field.Invalid(path.Child("ingress", "enabled"), true,
+				"an Ingress needs a challenge Service; exposeType None creates none"))
+		}
+	}
+
+	if exposeType == "Ingress" && !ingressEnabled {
+		allErrs = append(allErrs, field.Required(path.Child("ingress", "enabled"),
+			"exposeType Ingress creates a ClusterIP Service only reachable through the Ingress"))
+	}
+
+	if scenario.Protocol == string(corev1.ProtocolUDP) {
+		if exposeType == "Ingress" || ingressEnabled {
+			allErrs = append(allErrs, field.Invalid(path.Child("protocol"), scenario.Protocol,
+				"an Ingress only routes HTTP; UDP challenges must use NodePort or LoadBalancer"))
+		}
+		if scenario.AuthProxy != nil && scenario.AuthProxy.Enabled {
+			allErrs = append(allErrs, field.Invalid(path.Child("authProxy", "enabled"), true,
+				"the auth proxy only forwards HTTP; it can't front a UDP challenge"))
+		}
+	}
+
+	if policy := scenario.NetworkPolicy; policy != nil {
+		for i, cidr := range policy.AllowedEgressCIDRs {
+			if _, err := netip.ParsePrefix(cidr); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("networkPolicy", "allowedEgressCIDRs").Index(i), cidr, err.Error()))
+			}
+		}
+		for i, cidr := range policy.DeniedEgressCIDRs {
+			if _, err := netip.ParsePrefix(cidr); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Child("networkPolicy", "deniedEgressCIDRs").Index(i), cidr, err.Error()))
+			}
+		}
+	}
+
+	// Two outputs on the same env var or file would override each other or fail the pod
+	seen := map[string]bool{}
+	for i, output := range builder.FlagOutputs(&ctfv1alpha1.Challenge{Spec: ctfv1alpha1.ChallengeSpec{Scenario: *scenario}}) {
+		target := output.Type + ":" + output.Name + output.Path
+		if output.Type != "none" && seen[target] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("flagOutputs").Index(i), output))
+		}
+		seen[target] = true
+	}
+
+	if scenario.Replicas != nil && *scenario.Replicas > 1 && scenario.Persistence != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("replicas"), *scenario.Replicas,
+			"a persistent challenge runs a single replica, its ReadWriteOnce volume can't be shared"))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+var _ = Describe("Challenge Webhook", func() {
+	var (
+		challenge *ctfv1alpha1.Challenge
+		validator ChallengeCustomValidator
+	)
+
+	BeforeEach(func() {
+		challenge = &ctfv1alpha1.Challenge{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-challenge", Namespace: "default"},
+			Spec: ctfv1alpha1.ChallengeSpec{
+				ID: "test",
+				Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+					Image:      "nginx:latest",
+					Port:       80,
+					ExposeType: "NodePort",
+				},
+			},
+		}
+		validator = ChallengeCustomValidator{}
+	})
+
+	Context("When creating or updating a Challenge", func() {
+		It("Should admit a consistent spec", func() {
+			challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+			challenge.Spec.Scenario.NetworkPolicy = &ctfv1alpha1.NetworkPolicySpec{Enabled: true}
+			_, err := validator.ValidateCreate(context.Background(), challenge)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny an attack box on a challenge without a Service", func() {
+			challenge.Spec.Scenario.ExposeType = "None"
+			challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+
+			_, err := validator.ValidateCreate(context.Background(), challenge)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.scenario.attackBox.enabled"))
+		})
+
+		It("Should deny a UDP challenge behind an Ingress", func() {
+			challenge.Spec.Scenario.Protocol = "UDP"
+			challenge.Spec.Scenario.ExposeType = "Ingress"
+			challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{Enabled: true}
+
+			_, err := validator.ValidateCreate(context.Background(), challenge)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.scenario.protocol"))
+		})
+
+		It("Should deny two flag outputs writing the same file", func() {
+			challenge.Spec.Scenario.FlagOutputs = []ctfv1alpha1.FlagOutputSpec{
+				{Type: "env"},
+				{Type: "file", Path: "/flag"},
+				{Type: "file"},
+			}
+
+			_, err := validator.ValidateCreate(context.Background(), challenge)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.scenario.flagOutputs[2]"))
+		})
+
+		It("Should admit a network policy without an attack box on update", func() {
+			oldChallenge := challenge.DeepCopy()
+			challenge.Spec.Scenario.NetworkPolicy = &ctfv1alpha1.NetworkPolicySpec{Enabled: true}
+
+			_, err := validator.ValidateUpdate(context.Background(), oldChallenge, challenge)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny several replicas of a persistent challenge", func() {
+			replicas := int32(3)
+			challenge.Spec.Scenario.Replicas = &replicas
+			challenge.Spec.Scenario.Persistence = &ctfv1alpha1.PersistenceSpec{MountPath: "/data"}
+
+			_, err := validator.ValidateCreate(context.Background(), challenge)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.scenario.replicas"))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var challengeinstancelog = logf.Log.WithName("challengeinstance-resource")
+
+// SetupChallengeInstanceWebhookWithManager registers the webhook for ChallengeInstance in the manager.
+func SetupChallengeInstanceWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&ctfv1alpha1.ChallengeInstance{}).
+		WithValidator(&ChallengeInstanceCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-ctf-ctf-io-v1alpha1-challengeinstance,mutating=false,failurePolicy=fail,sideEffects=None,groups=ctf.ctf.io,resources=challengeinstances,verbs=update,versions=v1alpha1,name=vchallengeinstance-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ChallengeInstanceCustomValidator rejects changes to the instance spec fields its resources are derived from
+type ChallengeInstanceCustomValidator struct{}
+
+var _ webhook.CustomValidator = &ChallengeInstanceCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type ChallengeInstance.
+func (v *ChallengeInstanceCustomValidator) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type ChallengeInstance.
+func (v *ChallengeInstanceCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldInstance, ok := oldObj.(*ctfv1alpha1.ChallengeInstance)
+	if !ok {
+		return nil, fmt.Errorf("expected a ChallengeInstance object for the oldObj but got %T", oldObj)
+	}
+	instance, ok := newObj.(*ctfv1alpha1.ChallengeInstance)
+	if !ok {
+		return nil, fmt.Errorf("expected a ChallengeInstance object for the newObj but got %T", newObj)
+	}
+	challengeinstancelog.Info("Validation for ChallengeInstance upon update", "name", instance.GetName())
+
+	allErrs := validateInstanceSpecUpdate(&instance.Spec, &oldInstance.Spec, field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil, nil
+	}
+	return nil, apierrors.NewInvalid(
+		schema.GroupKind{Group: ctfv1alpha1.GroupVersion.Group, Kind: "ChallengeInstance"},
+		instance.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type ChallengeInstance.
+func (v *ChallengeInstanceCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateInstanceSpecUpdate returns an error per changed immutable field
+// Only until may change, for renewals; the other fields name or shape resources the instance already owns
+func validateInstanceSpecUpdate(spec, oldSpec *ctfv1alpha1.ChallengeInstanceSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(spec.ChallengeID, oldSpec.ChallengeID, path.Child("challengeId"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(spec.SourceID, oldSpec.SourceID, path.Child("sourceId"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(spec.ChallengeName, oldSpec.ChallengeName, path.Child("challengeName"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(spec.Additional, oldSpec.Additional, path.Child("additional"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(spec.Hostname, oldSpec.Hostname, path.Child("hostname"))...)
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(spec.Since, oldSpec.Since, path.Child("since"))...)
+	return allErrs
+}
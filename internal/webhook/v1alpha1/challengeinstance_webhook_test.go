@@ -0,0 +1,77 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+var _ = Describe("ChallengeInstance Webhook", func() {
+	var (
+		oldInstance *ctfv1alpha1.ChallengeInstance
+		instance    *ctfv1alpha1.ChallengeInstance
+		validator   ChallengeInstanceCustomValidator
+	)
+
+	BeforeEach(func() {
+		oldInstance = &ctfv1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "default"},
+			Spec: ctfv1alpha1.ChallengeInstanceSpec{
+				ChallengeID:   "101",
+				SourceID:      "alice",
+				ChallengeName: "101",
+				Since:         metav1.NewTime(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)),
+			},
+		}
+		instance = oldInstance.DeepCopy()
+		validator = ChallengeInstanceCustomValidator{}
+	})
+
+	Context("When updating a ChallengeInstance", func() {
+		It("Should admit a renewal changing until", func() {
+			until := metav1.NewTime(time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC))
+			instance.Spec.Until = &until
+
+			_, err := validator.ValidateUpdate(context.Background(), oldInstance, instance)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny a change of source", func() {
+			instance.Spec.SourceID = "mallory"
+
+			_, err := validator.ValidateUpdate(context.Background(), oldInstance, instance)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.sourceId"))
+		})
+
+		It("Should report every changed immutable field", func() {
+			instance.Spec.ChallengeID = "102"
+			instance.Spec.ChallengeName = "102"
+
+			_, err := validator.ValidateUpdate(context.Background(), oldInstance, instance)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.challengeId"))
+			Expect(err.Error()).To(ContainSubstring("spec.challengeName"))
+		})
+	})
+})
@@ -0,0 +1,98 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Identity is the authenticated API caller
+type Identity struct {
+	Name  string
+	Admin bool // Admins see moderation metadata, e.g. who created an instance
+}
+
+// identityKey is the request context key of the caller Identity
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the caller identity
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller identity set by Authenticate, if any
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// apiToken grants its bearer an identity
+type apiToken struct {
+	token    string
+	identity Identity
+}
+
+// getAPITokens returns the API tokens from env, as comma-separated name=token pairs
+// Identities named in API_ADMINS are admins
+func getAPITokens() []apiToken {
+	v := os.Getenv("API_TOKENS")
+	if v == "" {
+		return nil
+	}
+	admins := strings.Split(os.Getenv("API_ADMINS"), ",")
+	for i := range admins {
+		admins[i] = strings.TrimSpace(admins[i])
+	}
+	var tokens []apiToken
+	for _, pair := range strings.Split(v, ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || token == "" {
+			slog.Warn("Invalid API_TOKENS entry, ignoring", "entry", name)
+			continue
+		}
+		tokens = append(tokens, apiToken{token: token, identity: Identity{Name: name, Admin: slices.Contains(admins, name)}})
+	}
+	return tokens
+}
+
+// Authenticate resolves the bearer token of each request into the caller Identity
+// Without API_TOKENS every request stays anonymous; with them, a missing or unknown token is rejected
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.apiTokens) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for _, t := range h.apiTokens {
+				if subtle.ConstantTimeCompare([]byte(bearer), []byte(t.token)) == 1 {
+					next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), t.identity)))
+					return
+				}
+			}
+		}
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		h.writeError(w, r, http.StatusUnauthorized, "Unauthorized", "a valid bearer token is required")
+	})
+}
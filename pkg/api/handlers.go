@@ -19,40 +19,57 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 	"github.com/leo/chall-operator/pkg/builder"
+	"github.com/leo/chall-operator/pkg/flaggen"
+	"github.com/leo/chall-operator/pkg/metrics"
 )
 
-// sanitizeName converts a string to be DNS-safe for Kubernetes resource names
-// Example: "alice@ctf.local" -> "alice-at-ctf-local"
-func sanitizeName(s string) string {
-	result := strings.ReplaceAll(s, "@", "-at-")
-	result = strings.ReplaceAll(result, ".", "-")
-	result = strings.ToLower(result)
-	// Truncate to 63 chars (K8s name limit)
-	if len(result) > 63 {
-		result = result[:63]
-	}
-	return result
-}
-
 // Handler handles HTTP requests for the CTFd-compatible API
 type Handler struct {
-	client    client.Client
-	namespace string
+	client                client.Client
+	namespace             string
+	flagLimiter           *rateLimiter
+	createLimiter         *tokenBucketLimiter // Throttles instance creation per source
+	listWrapperKey        string
+	maxInstancesPerSource int      // 0 = unlimited
+	annotatedKeys         []string // Additional keys copied into instance annotations
+	readyPollAttempts     int
+	readyPollInterval     time.Duration
+	readyBackoffInitial   time.Duration // First readiness poll delay, doubled up to readyBackoffMax
+	readyBackoffMax       time.Duration
+	deletePropagation     metav1.DeletionPropagation // Empty = API server default
+	leaderNamespace       string                     // Namespace of the operator leader election Lease
+	connectionFallback    *template.Template         // Connection info of Ready instances without any, nil = none
+	connectionGrace       time.Duration              // How long a Ready instance may lack connection info before the fallback
+	gitOpsMode            bool                       // Challenges come from kubectl/ArgoCD; false lets CreateChallenge create them
+	apiTokens             []apiToken                 // Bearer tokens checked by Authenticate, none = anonymous access
 }
 
 // NewHandler creates a new API handler
@@ -62,9 +79,183 @@ func NewHandler(c client.Client) *Handler {
 		namespace = "ctf-instances"
 	}
 	return &Handler{
-		client:    c,
-		namespace: namespace,
+		client:                c,
+		namespace:             namespace,
+		flagLimiter:           newRateLimiter(getFlagRateLimit(), time.Minute),
+		createLimiter:         newTokenBucketLimiter(getCreateRateLimit()/60, getCreateRateBurst()),
+		listWrapperKey:        getListWrapperKey(),
+		maxInstancesPerSource: getMaxInstancesPerSource(),
+		annotatedKeys:         getAnnotatedAdditionalKeys(),
+		readyPollAttempts:     getReadyPollAttempts(),
+		readyPollInterval:     getReadyPollInterval(),
+		readyBackoffInitial:   getReadyBackoffInitial(),
+		readyBackoffMax:       getReadyBackoffMax(),
+		deletePropagation:     builder.DeletePropagationPolicy(),
+		leaderNamespace:       getLeaderElectionNamespace(),
+		connectionFallback:    getConnectionInfoFallback(),
+		connectionGrace:       getConnectionInfoFallbackGrace(),
+		gitOpsMode:            getGitOpsMode(),
+		apiTokens:             getAPITokens(),
+	}
+}
+
+// getGitOpsMode returns whether Challenges are managed outside the gateway from env or fallback
+func getGitOpsMode() bool {
+	if v := os.Getenv("GITOPS_MODE"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			return enabled
+		}
+		slog.Warn("Invalid GITOPS_MODE, using default", "value", v)
+	}
+	return true
+}
+
+// getReadyPollAttempts returns how many times CreateInstance polls for readiness from env or fallback
+func getReadyPollAttempts() int {
+	if v := os.Getenv("READY_POLL_ATTEMPTS"); v != "" {
+		if attempts, err := strconv.Atoi(v); err == nil && attempts > 0 {
+			return attempts
+		}
+		slog.Warn("Invalid READY_POLL_ATTEMPTS, using default", "value", v)
+	}
+	return 60
+}
+
+// getReadyPollInterval returns the delay between readiness polls from env or fallback (e.g. "500ms", "2s")
+func getReadyPollInterval() time.Duration {
+	if v := os.Getenv("READY_POLL_INTERVAL"); v != "" {
+		if interval, err := time.ParseDuration(v); err == nil && interval > 0 {
+			return interval
+		}
+		slog.Warn("Invalid READY_POLL_INTERVAL, using default", "value", v)
+	}
+	return time.Second
+}
+
+// getReadyBackoffInitial returns the first readiness poll delay from env or fallback
+func getReadyBackoffInitial() time.Duration {
+	if v := os.Getenv("READY_POLL_BACKOFF_INITIAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("Invalid READY_POLL_BACKOFF_INITIAL, using default", "value", v)
+	}
+	return 100 * time.Millisecond
+}
+
+// getReadyBackoffMax returns the cap on the readiness poll delay from env or fallback
+func getReadyBackoffMax() time.Duration {
+	if v := os.Getenv("READY_POLL_BACKOFF_MAX"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		slog.Warn("Invalid READY_POLL_BACKOFF_MAX, using default", "value", v)
+	}
+	return 5 * time.Second
+}
+
+// instanceDeleteOptions returns the delete options for instances, with the configured propagation policy
+func (h *Handler) instanceDeleteOptions() []client.DeleteOption {
+	return builder.DeleteOptions(h.deletePropagation)
+}
+
+// getAnnotatedAdditionalKeys returns the Additional keys stored as ctf.io/<key> instance annotations
+// ANNOTATED_ADDITIONAL_KEYS is a comma-separated list, e.g. "team_name,round"
+func getAnnotatedAdditionalKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("ANNOTATED_ADDITIONAL_KEYS"), ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if errs := validation.IsQualifiedName("ctf.io/" + key); len(errs) > 0 {
+			slog.Warn("Ignoring invalid ANNOTATED_ADDITIONAL_KEYS entry", "key", key, "error", strings.Join(errs, "; "))
+			continue
+		}
+		keys = append(keys, key)
 	}
+	return keys
+}
+
+// getMaxInstancesPerSource returns the per-source instance quota from env (default 0 = unlimited)
+func getMaxInstancesPerSource() int {
+	if v := os.Getenv("MAX_INSTANCES_PER_SOURCE"); v != "" {
+		if limit, err := strconv.Atoi(v); err == nil {
+			return limit
+		}
+		slog.Warn("Invalid MAX_INSTANCES_PER_SOURCE, quota disabled", "value", v)
+	}
+	return 0
+}
+
+// getListWrapperKey returns the key wrapping each item of streaming list responses from env or fallback
+// Setting LIST_WRAPPER_KEY to an empty string streams bare objects
+func getListWrapperKey() string {
+	if key, ok := os.LookupEnv("LIST_WRAPPER_KEY"); ok {
+		return key
+	}
+	return "result"
+}
+
+// getConnectionInfoFallback returns the connection info template shown when none can be computed from env
+// CONNECTION_INFO_FALLBACK takes .ChallengeID, .SourceID and .InstanceName, e.g. "Contact an organizer ({{.InstanceName}})"
+func getConnectionInfoFallback() *template.Template {
+	v := os.Getenv("CONNECTION_INFO_FALLBACK")
+	if v == "" {
+		return nil
+	}
+	tmpl, err := template.New("connection-info").Parse(v)
+	if err != nil {
+		slog.Warn("Invalid CONNECTION_INFO_FALLBACK template, fallback disabled", "value", v, "error", err)
+		return nil
+	}
+	return tmpl
+}
+
+// getConnectionInfoFallbackGrace returns how long after creation a Ready instance may lack connection info from env or fallback
+func getConnectionInfoFallbackGrace() time.Duration {
+	if v := os.Getenv("CONNECTION_INFO_FALLBACK_GRACE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		slog.Warn("Invalid CONNECTION_INFO_FALLBACK_GRACE, using default", "value", v)
+	}
+	return 2 * time.Minute
+}
+
+// getFlagRateLimit returns the allowed flag submissions per minute from env or fallback
+// Set FLAG_RATELIMIT=0 to disable flag submission throttling
+func getFlagRateLimit() int {
+	if v := os.Getenv("FLAG_RATELIMIT"); v != "" {
+		if limit, err := strconv.Atoi(v); err == nil {
+			return limit
+		}
+		slog.Warn("Invalid FLAG_RATELIMIT, using default", "value", v)
+	}
+	return 10
+}
+
+// getCreateRateLimit returns the allowed instance creations per minute and per source from env or fallback
+// Set CREATE_RATELIMIT=0 to disable creation throttling
+func getCreateRateLimit() float64 {
+	if v := os.Getenv("CREATE_RATELIMIT"); v != "" {
+		if limit, err := strconv.ParseFloat(v, 64); err == nil {
+			return limit
+		}
+		slog.Warn("Invalid CREATE_RATELIMIT, using default", "value", v)
+	}
+	return 30
+}
+
+// getCreateRateBurst returns how many creations a source may issue back to back from env or fallback
+func getCreateRateBurst() int {
+	if v := os.Getenv("CREATE_RATELIMIT_BURST"); v != "" {
+		if burst, err := strconv.Atoi(v); err == nil && burst > 0 {
+			return burst
+		}
+		slog.Warn("Invalid CREATE_RATELIMIT_BURST, using default", "value", v)
+	}
+	return 10
 }
 
 // CreateInstanceRequest represents the request body for creating an instance
@@ -95,13 +286,32 @@ func (r *CreateInstanceRequest) GetSourceID() string {
 
 // InstanceResponse represents the response for instance operations
 type InstanceResponse struct {
-	ChallengeID    string   `json:"challenge_id" example:"101"`
-	SourceID       string   `json:"source_id" example:"user@example.com"`
-	ConnectionInfo string   `json:"connectionInfo" example:"http://ctf.instance.user.101.devleo.local"`
-	Flags          []string `json:"flags,omitempty" example:"FLAG{test}"`
-	Flag           string   `json:"flag,omitempty" example:"FLAG{test}"` // Deprecated but kept for compatibility
-	Since          string   `json:"since" example:"2024-01-15T10:30:00Z"`
-	Until          string   `json:"until,omitempty" example:"2024-01-15T12:30:00Z"`
+	ChallengeID            string        `json:"challenge_id" example:"101"`
+	SourceID               string        `json:"source_id" example:"user@example.com"`
+	Phase                  string        `json:"phase,omitempty" example:"Running"`
+	ConnectionInfo         string        `json:"connectionInfo" example:"http://ctf.instance.user.101.devleo.local"`
+	ChallengeURL           string        `json:"challenge_url,omitempty" example:"http://ctf.instance.user.101.devleo.local"`
+	TerminalURL            string        `json:"terminal_url,omitempty" example:"http://ctf.instance.user.101.devleo.local/terminal"`
+	ConnectionInstructions string        `json:"connection_instructions,omitempty" example:"SSH as user ctf, the password is in /flag"`
+	Flags                  []string      `json:"flags,omitempty" example:"FLAG{test}"`
+	Flag                   string        `json:"flag,omitempty" example:"FLAG{test}"` // Deprecated but kept for compatibility
+	Since                  string        `json:"since" example:"2024-01-15T10:30:00Z"`
+	Until                  string        `json:"until,omitempty" example:"2024-01-15T12:30:00Z"`
+	RemainingSeconds       int64         `json:"remaining_seconds,omitempty" example:"540"`           // Seconds until Until, 0 once passed
+	Expired                bool          `json:"expired,omitempty" example:"false"`                   // Until passed, waiting for the janitor
+	CreatedBy              string        `json:"created_by,omitempty" example:"ctfd"`                 // Admins only: identity or client IP of the creator
+	CreatedAt              string        `json:"created_at,omitempty" example:"2026-01-01T12:00:00Z"` // Admins only
+	Draining               bool          `json:"draining,omitempty" example:"false"`
+	Warning                string        `json:"warning,omitempty" example:"Instance expires at 2024-01-15T12:30:00Z, save your work"`
+	Ports                  []PortMapping `json:"ports,omitempty"`
+}
+
+// PortMapping describes how a port of the instance Service is exposed
+type PortMapping struct {
+	Name     string `json:"name" example:"challenge"`
+	Port     int32  `json:"port" example:"8080"`
+	NodePort int32  `json:"nodePort,omitempty" example:"30080"`
+	Protocol string `json:"protocol" example:"TCP"`
 }
 
 // ErrorResponse represents an error response
@@ -118,44 +328,241 @@ type ErrorResponse struct {
 // @Produce json
 // @Param body body CreateInstanceRequest true "Instance creation request"
 // @Success 201 {object} InstanceResponse
+// @Header 201 {string} Location "URL of the created instance"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
 // @Router /instance [post]
 func (h *Handler) CreateInstance(w http.ResponseWriter, r *http.Request) {
 	var req CreateInstanceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		// Garbage bodies are throttled too, per client IP
+		if h.throttleCreate(w, r, "", "") {
+			return
+		}
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
+	h.createInstance(w, r, req)
+}
+
+// throttleCreate takes an instance creation token for the source, or for the client IP when the request has no source
+// It reports whether the bucket was empty, in which case a 429 with Retry-After has been written
+func (h *Handler) throttleCreate(w http.ResponseWriter, r *http.Request, challengeID, sourceID string) bool {
+	key := builder.SanitizeForLabel(sourceID)
+	if sourceID == "" {
+		// ':' never appears in a sanitized source, so IP keys can't collide with sources
+		key = "ip:" + clientIP(r)
+	}
+
+	ok, retryAfter := h.createLimiter.Reserve(key)
+	if ok {
+		return false
+	}
+	slog.Warn("Source is creating instances too fast", "challenge_id", challengeID, "source_id", sourceID, "client_ip", clientIP(r))
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	h.writeError(w, r, http.StatusTooManyRequests, "Too many instance creations", "Rate limit exceeded, try again later")
+	return true
+}
+
+// Annotations tracing an instance back to the API request that created it
+const (
+	RequestIDAnnotation = "ctf.io/request-id"
+	UserAgentAnnotation = "ctf.io/user-agent"
+	CreatedByAnnotation = "ctf.io/created-by"
+)
+
+// maxUserAgentLength truncates oversized User-Agent headers before they are stored
+const maxUserAgentLength = 256
+
+// requestAnnotations returns the request ID (set by chi's RequestID middleware), the client
+// user agent and the caller of r, for support tickets to be traced to the instance; empty values are left out
+// The caller is the authenticated identity, or the client IP for anonymous requests
+func requestAnnotations(r *http.Request) map[string]string {
+	annotations := map[string]string{CreatedByAnnotation: clientIP(r)}
+	if identity, ok := IdentityFromContext(r.Context()); ok {
+		annotations[CreatedByAnnotation] = identity.Name
+	}
+	if requestID := middleware.GetReqID(r.Context()); requestID != "" {
+		annotations[RequestIDAnnotation] = requestID
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		annotations[UserAgentAnnotation] = userAgent
+	}
+	return annotations
+}
 
+// clientIP returns the IP of the request's remote address, or the address itself when it has no port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// createInstance creates the instance described by req, or returns the existing one
+// Shared by POST /instance and GET /instance/{challengeId}/{sourceId}?create=true
+func (h *Handler) createInstance(w http.ResponseWriter, r *http.Request, req CreateInstanceRequest) {
 	// Get IDs from either format (snake_case or camelCase)
 	challengeID := req.GetChallengeID()
 	sourceID := req.GetSourceID()
 
-	if challengeID == "" || sourceID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing required fields", "challenge_id/challengeId and source_id/sourceId are required")
+	// Throttle creations per source, independently of the running instance quota
+	if h.throttleCreate(w, r, challengeID, sourceID) {
 		return
 	}
 
 	ctx := context.Background()
+	instance, challenge, existed, cerr := h.provisionInstance(ctx, req, requestAnnotations(r))
+	if cerr != nil {
+		h.writeError(w, r, cerr.status, cerr.err, cerr.message)
+		return
+	}
+	instanceName := instance.Name
+	location := instanceLocation(challengeID, sourceID, req.Additional[teamIDKey])
+	if existed {
+		// Instance already exists, return it
+		w.Header().Set("Location", location)
+		h.writeInstanceResponse(w, r, instance)
+		return
+	}
+
+	// Wait for instance to be ready (poll status)
+	var readyInstance *ctfv1alpha1.ChallengeInstance
+	for _, delay := range pollBackoffSchedule(h.readyBackoffInitial, h.readyBackoffMax, h.readyPollTimeout(challenge)) {
+		time.Sleep(delay)
+
+		instance := &ctfv1alpha1.ChallengeInstance{}
+		if err := h.client.Get(ctx, types.NamespacedName{
+			Name:      instanceName,
+			Namespace: h.namespace,
+		}, instance); err != nil {
+			continue
+		}
+
+		if instance.Status.Ready {
+			readyInstance = instance
+			slog.Info("Instance is ready", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
+			break
+		}
+
+		// Check for failure
+		if instance.Status.Phase == "Failed" {
+			h.writeError(w, r, http.StatusInternalServerError, "Instance failed to start", "Challenge deployment failed")
+			return
+		}
+	}
+
+	if readyInstance == nil {
+		// Timeout waiting for ready, but return what we have
+		instance := &ctfv1alpha1.ChallengeInstance{}
+		if err := h.client.Get(ctx, types.NamespacedName{
+			Name:      instanceName,
+			Namespace: h.namespace,
+		}, instance); err != nil {
+			h.writeError(w, r, http.StatusInternalServerError, "Failed to get instance status", err.Error())
+			return
+		}
+		readyInstance = instance
+		slog.Warn("Instance not ready after timeout, returning current state", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
+	}
+
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+	h.writeInstanceResponse(w, r, readyInstance)
+}
+
+// createError is a failed instance creation, mapped to an HTTP error response
+type createError struct {
+	status  int
+	err     string
+	message string
+}
+
+// provisionInstance validates req and creates its ChallengeInstance, without waiting for readiness
+// origin annotations (see requestAnnotations) are stamped on the created instance.
+// It returns the existing instance with existed set when the source already has one for the challenge,
+// otherwise the created instance and its Challenge (empty when it couldn't be read)
+func (h *Handler) provisionInstance(ctx context.Context, req CreateInstanceRequest, origin map[string]string) (*ctfv1alpha1.ChallengeInstance, *ctfv1alpha1.Challenge, bool, *createError) {
+	challengeID := req.GetChallengeID()
+	sourceID := req.GetSourceID()
+	if challengeID == "" || sourceID == "" {
+		return nil, nil, false, &createError{http.StatusBadRequest, "Missing required fields", "challenge_id/challengeId and source_id/sourceId are required"}
+	}
+
+	// Optional custom hostname, restricted to a single label under the base domain
+	var hostname string
+	if requested := req.Additional["hostname"]; requested != "" {
+		var err error
+		if hostname, err = builder.CustomHostname(requested); err != nil {
+			return nil, nil, false, &createError{http.StatusBadRequest, "Invalid hostname", err.Error()}
+		}
+	}
+
+	// Shared challenges key the instance on the team instead of the requesting member
+	shared, err := h.sharedChallenge(ctx, challengeID)
+	if err != nil {
+		slog.Error("Failed to look up challenge", "challenge_id", challengeID, "error", err)
+		return nil, nil, false, &createError{http.StatusServiceUnavailable, "Failed to look up challenge", err.Error()}
+	}
+	teamID := req.Additional[teamIDKey]
+	sourceID = sharedKey(shared, sourceID, teamID)
+	// The common instance of a shared challenge belongs to no source, so no source quota applies to it
+	commonInstance := shared && teamID == ""
 
 	// Generate instance name from challenge and source IDs (sanitized for K8s)
-	// Prefix with "chal-" to ensure DNS-1035 compliance (must start with letter)
-	sanitizedSourceID := sanitizeName(sourceID)
-	instanceName := fmt.Sprintf("chal-%s-%s", challengeID, sanitizedSourceID)
+	sanitizedSourceID := builder.SanitizeForLabel(sourceID)
+	instanceName := instanceName(challengeID, sourceID)
 
 	// Check if instance already exists
 	existingInstance := &ctfv1alpha1.ChallengeInstance{}
-	err := h.client.Get(ctx, types.NamespacedName{
+	err = h.client.Get(ctx, types.NamespacedName{
 		Name:      instanceName,
 		Namespace: h.namespace,
 	}, existingInstance)
 
 	if err == nil {
-		// Instance already exists, return it
-		log.Printf("Instance %s already exists, returning existing", instanceName)
-		h.writeInstanceResponse(w, existingInstance)
-		return
+		slog.Info("Instance already exists, returning existing", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
+		return existingInstance, nil, true, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		// Can't tell whether the instance exists; creating now could duplicate or mask the error
+		slog.Error("Failed to look up instance", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName, "error", err)
+		return nil, nil, false, &createError{http.StatusServiceUnavailable, "Failed to look up instance", err.Error()}
+	}
+
+	// Reject custom hostnames already claimed by another instance
+	if hostname != "" {
+		instanceList := &ctfv1alpha1.ChallengeInstanceList{}
+		if err := h.client.List(ctx, instanceList, client.InNamespace(h.namespace)); err != nil {
+			return nil, nil, false, &createError{http.StatusInternalServerError, "Failed to list instances", err.Error()}
+		}
+		for _, other := range instanceList.Items {
+			if other.Spec.Hostname == hostname {
+				return nil, nil, false, &createError{http.StatusConflict, "Hostname already in use", fmt.Sprintf("hostname %s is used by another instance", hostname)}
+			}
+		}
+	}
+
+	// Enforce the per-source instance quota
+	if h.maxInstancesPerSource > 0 && !commonInstance {
+		sourceInstances := &ctfv1alpha1.ChallengeInstanceList{}
+		if err := h.client.List(ctx, sourceInstances, client.InNamespace(h.namespace), client.MatchingLabels{
+			"ctf.io/source": sanitizedSourceID,
+		}); err != nil {
+			return nil, nil, false, &createError{http.StatusInternalServerError, "Failed to list instances", err.Error()}
+		}
+		if count := len(sourceInstances.Items); count >= h.maxInstancesPerSource {
+			slog.Warn("Source reached instance quota", "challenge_id", challengeID, "source_id", sourceID, "count", count, "max", h.maxInstancesPerSource)
+			return nil, nil, false, &createError{http.StatusTooManyRequests, "Instance quota exceeded",
+				fmt.Sprintf("source has %d running instances, limit is %d", count, h.maxInstancesPerSource)}
+		}
 	}
 
 	// Get timeout from challenge (default 600 seconds)
@@ -165,6 +572,10 @@ func (h *Handler) CreateInstance(w http.ResponseWriter, r *http.Request) {
 		Name:      challengeID,
 		Namespace: h.namespace,
 	}, challenge); err == nil {
+		if builder.ChallengeDisabled(challenge) {
+			slog.Warn("Refusing instance of disabled challenge", "challenge_id", challengeID, "source_id", sourceID)
+			return nil, nil, false, &createError{http.StatusForbidden, "Challenge disabled", fmt.Sprintf("challenge %s is disabled by the organizers", challengeID)}
+		}
 		if challenge.Spec.Timeout > 0 {
 			timeout = challenge.Spec.Timeout
 		}
@@ -174,6 +585,14 @@ func (h *Handler) CreateInstance(w http.ResponseWriter, r *http.Request) {
 	now := metav1.Now()
 	until := metav1.NewTime(time.Now().Add(time.Duration(timeout) * time.Second))
 
+	annotations := h.additionalAnnotations(req.Additional)
+	if len(origin) > 0 {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		maps.Copy(annotations, origin)
+	}
+
 	instance := &ctfv1alpha1.ChallengeInstance{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      instanceName,
@@ -182,67 +601,361 @@ func (h *Handler) CreateInstance(w http.ResponseWriter, r *http.Request) {
 				"ctf.io/challenge": challengeID,
 				"ctf.io/source":    sanitizedSourceID,
 			},
+			Annotations: annotations,
 		},
 		Spec: ctfv1alpha1.ChallengeInstanceSpec{
 			ChallengeID:   challengeID,
 			SourceID:      sourceID,
 			ChallengeName: challengeID, // Assume Challenge name = challengeID
 			Additional:    req.Additional,
+			Hostname:      hostname,
 			Since:         now,
 			Until:         &until,
 		},
 	}
 
 	if err := h.client.Create(ctx, instance); err != nil {
-		log.Printf("Failed to create instance %s: %v", instanceName, err)
-		h.writeError(w, http.StatusInternalServerError, "Failed to create instance", err.Error())
+		slog.Error("Failed to create instance", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName, "error", err)
+		if apierrors.IsAlreadyExists(err) {
+			// A concurrent request created the same instance between our Get and Create
+			return nil, nil, false, &createError{http.StatusConflict, "Instance already exists", err.Error()}
+		}
+		return nil, nil, false, &createError{http.StatusInternalServerError, "Failed to create instance", err.Error()}
+	}
+
+	metrics.InstancesCreated.WithLabelValues(challengeID).Inc()
+	slog.Info("Created instance", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
+	return instance, challenge, false, nil
+}
+
+// bulkCreateWorkers bounds the concurrent creations of a bulk request
+const bulkCreateWorkers = 8
+
+// maxBulkCreateItems caps the items of a bulk request
+const maxBulkCreateItems = 500
+
+// BulkCreateResult is the outcome of one item of a bulk creation, in request order
+type BulkCreateResult struct {
+	ChallengeID string `json:"challenge_id" example:"101"`
+	SourceID    string `json:"source_id" example:"team-1"`
+	Instance    string `json:"instance,omitempty" example:"chal-101-team-1"`
+	// Status is 201 for a created instance, 200 for an existing one, the error status otherwise
+	Status  int    `json:"status" example:"201"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// BulkCreateInstance godoc
+// @Summary Create challenge instances in bulk
+// @Description Create the ChallengeInstances of many challenge/source pairs, e.g. to pre-warm a team's instances,
+// @Description without waiting for them to be ready; each item reports its own status
+// @Tags instances
+// @Accept json
+// @Produce json
+// @Param body body []CreateInstanceRequest true "Instances to create"
+// @Success 202 {array} BulkCreateResult
+// @Failure 400 {object} ErrorResponse
+// @Router /instance/bulk [post]
+func (h *Handler) BulkCreateInstance(w http.ResponseWriter, r *http.Request) {
+	var reqs []CreateInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxBulkCreateItems {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid bulk size", fmt.Sprintf("expected 1 to %d instances, got %d", maxBulkCreateItems, len(reqs)))
 		return
 	}
 
-	log.Printf("Created instance %s, waiting for ready state", instanceName)
+	results := h.createInstances(context.Background(), reqs, requestAnnotations(r))
 
-	// Wait for instance to be ready (poll status)
-	var readyInstance *ctfv1alpha1.ChallengeInstance
-	for i := 0; i < 60; i++ { // 60 seconds timeout
-		time.Sleep(1 * time.Second)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := newJSONEncoder(w, r).Encode(results); err != nil {
+		slog.Error("handlers: encode bulk results", "error", err)
+	}
+}
 
-		instance := &ctfv1alpha1.ChallengeInstance{}
-		if err := h.client.Get(ctx, types.NamespacedName{
-			Name:      instanceName,
-			Namespace: h.namespace,
-		}, instance); err != nil {
+// BatchCreateRequest creates the instances of one challenge for many sources
+type BatchCreateRequest struct {
+	ChallengeID string   `json:"challenge_id" example:"101"`
+	SourceIDs   []string `json:"source_ids" example:"alice,bob"`
+}
+
+// Batch creation outcomes
+const (
+	BatchStatusCreated = "created"
+	BatchStatusExists  = "exists"
+	BatchStatusError   = "error"
+)
+
+// BatchCreateResult is the outcome of the instance of one source, in request order
+type BatchCreateResult struct {
+	SourceID string `json:"source_id" example:"alice"`
+	Instance string `json:"instance,omitempty" example:"chal-101-alice"`
+	// Status is created, exists or error
+	Status  string `json:"status" example:"created"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// BatchCreateInstance godoc
+// @Summary Create a challenge's instances for many sources
+// @Description Create the ChallengeInstances of one challenge for a list of sources, e.g. a whole team,
+// @Description without waiting for them to be ready; a failing source does not abort the others
+// @Tags instances
+// @Accept json
+// @Produce json
+// @Param body body BatchCreateRequest true "Challenge and sources"
+// @Success 202 {array} BatchCreateResult
+// @Failure 400 {object} ErrorResponse
+// @Router /instance/batch [post]
+func (h *Handler) BatchCreateInstance(w http.ResponseWriter, r *http.Request) {
+	var req BatchCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.ChallengeID == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Missing required fields", "challenge_id is required")
+		return
+	}
+	if len(req.SourceIDs) == 0 || len(req.SourceIDs) > maxBulkCreateItems {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid batch size", fmt.Sprintf("expected 1 to %d sources, got %d", maxBulkCreateItems, len(req.SourceIDs)))
+		return
+	}
+
+	reqs := make([]CreateInstanceRequest, len(req.SourceIDs))
+	for i, sourceID := range req.SourceIDs {
+		reqs[i] = CreateInstanceRequest{ChallengeID: req.ChallengeID, SourceID: sourceID}
+	}
+
+	results := make([]BatchCreateResult, len(reqs))
+	for i, created := range h.createInstances(context.Background(), reqs, requestAnnotations(r)) {
+		result := BatchCreateResult{SourceID: created.SourceID, Instance: created.Instance, Error: created.Error, Message: created.Message}
+		switch created.Status {
+		case http.StatusCreated:
+			result.Status = BatchStatusCreated
+		case http.StatusOK:
+			result.Status = BatchStatusExists
+		default:
+			result.Status = BatchStatusError
+		}
+		results[i] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := newJSONEncoder(w, r).Encode(results); err != nil {
+		slog.Error("handlers: encode batch results", "error", err)
+	}
+}
+
+// InstanceStatusResult is the status of one queried instance, in request order
+type InstanceStatusResult struct {
+	ChallengeID string `json:"challenge_id" example:"101"`
+	SourceID    string `json:"source_id" example:"team-1"`
+	// Status is 200 with the instance, 404 when it doesn't exist, 400 for an incomplete item
+	Status   int               `json:"status" example:"200"`
+	Instance *InstanceResponse `json:"instance,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// BatchInstanceStatus godoc
+// @Summary Get the status of many instances
+// @Description Get the status of many challenge/source pairs in one call, e.g. for a scoreboard,
+// @Description instead of one GET per instance; each item reports its own status
+// @Tags instances
+// @Accept json
+// @Produce json
+// @Param body body []CreateInstanceRequest true "Instances to query"
+// @Success 200 {array} InstanceStatusResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /instance/status/batch [post]
+func (h *Handler) BatchInstanceStatus(w http.ResponseWriter, r *http.Request) {
+	var reqs []CreateInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxBulkCreateItems {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid batch size", fmt.Sprintf("expected 1 to %d instances, got %d", maxBulkCreateItems, len(reqs)))
+		return
+	}
+
+	// Each challenge is looked up once: its shared flag, then its instances and Services by label
+	lookups := map[string]*statusLookup{}
+	results := make([]InstanceStatusResult, len(reqs))
+	for i := range reqs {
+		result := InstanceStatusResult{ChallengeID: reqs[i].GetChallengeID(), SourceID: reqs[i].GetSourceID()}
+		if result.ChallengeID == "" || result.SourceID == "" {
+			result.Status, result.Error = http.StatusBadRequest, "challenge_id and source_id are required"
+			results[i] = result
+			continue
+		}
+		lookup, ok := lookups[result.ChallengeID]
+		if !ok {
+			lookup = h.lookupChallengeInstances(r.Context(), result.ChallengeID)
+			lookups[result.ChallengeID] = lookup
+		}
+		if lookup.err != nil {
+			// A failing lookup only fails the items of that challenge
+			result.Status, result.Error = lookupErrorStatus(lookup.err), lookup.err.Error()
+			results[i] = result
 			continue
 		}
+		key := sharedKey(lookup.shared, result.SourceID, reqs[i].Additional[teamIDKey])
+		if instance, found := lookup.instances[instanceName(result.ChallengeID, key)]; found {
+			resp := h.instanceResponse(instance, lookup.ports[serviceKey(instance)])
+			result.Status, result.Instance = http.StatusOK, &resp
+		} else {
+			result.Status, result.Error = http.StatusNotFound, "Instance not found"
+		}
+		results[i] = result
+	}
 
-		if instance.Status.Ready {
-			readyInstance = instance
-			log.Printf("Instance %s is ready", instanceName)
-			break
+	w.Header().Set("Content-Type", "application/json")
+	if err := newJSONEncoder(w, r).Encode(results); err != nil {
+		slog.Error("handlers: encode status results", "error", err)
+	}
+}
+
+// statusLookup holds what a status batch reads once per challenge
+type statusLookup struct {
+	shared    bool
+	instances map[string]*ctfv1alpha1.ChallengeInstance
+	ports     map[types.NamespacedName][]PortMapping
+	err       error
+}
+
+// lookupChallengeInstances reads the shared flag, instances and Service ports of a challenge,
+// selecting on the ctf.io/challenge label rather than listing the whole namespace
+func (h *Handler) lookupChallengeInstances(ctx context.Context, challengeID string) *statusLookup {
+	lookup := &statusLookup{}
+	if lookup.shared, lookup.err = h.sharedChallenge(ctx, challengeID); lookup.err != nil {
+		return lookup
+	}
+
+	instanceList := &ctfv1alpha1.ChallengeInstanceList{}
+	if lookup.err = h.client.List(ctx, instanceList, client.InNamespace(h.namespace),
+		client.MatchingLabels{"ctf.io/challenge": challengeID}); lookup.err != nil {
+		return lookup
+	}
+	lookup.instances = make(map[string]*ctfv1alpha1.ChallengeInstance, len(instanceList.Items))
+	for i := range instanceList.Items {
+		lookup.instances[instanceList.Items[i].Name] = &instanceList.Items[i]
+	}
+	if len(lookup.instances) > 0 {
+		lookup.ports = h.listServicePorts(ctx, challengeID)
+	}
+	return lookup
+}
+
+// lookupErrorStatus maps a lookup error to the HTTP status writeGetError would answer
+func lookupErrorStatus(err error) int {
+	switch {
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apiUnavailable(err):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// createInstances provisions the requested instances with a bounded worker pool, without waiting
+// for readiness; results follow the request order and a failing item does not stop the others
+func (h *Handler) createInstances(ctx context.Context, reqs []CreateInstanceRequest, origin map[string]string) []BulkCreateResult {
+	groups := quotaGroups(reqs)
+	results := make([]BulkCreateResult, len(reqs))
+	items := make(chan []int)
+	var wg sync.WaitGroup
+	for n := 0; n < min(bulkCreateWorkers, len(groups)); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range items {
+				for _, i := range group {
+					results[i] = h.createItem(ctx, reqs[i], origin)
+				}
+			}
+		}()
+	}
+	for _, group := range groups {
+		items <- group
+	}
+	close(items)
+	wg.Wait()
+	return results
+}
+
+// quotaGroups splits bulk items into groups run in order on a single worker, so they can't race a quota check
+// Items sharing a source or a team land in the same group, as shared challenges count instances against the team
+func quotaGroups(reqs []CreateInstanceRequest) [][]int {
+	var groups [][]int
+	groupOf := map[string]int{}
+	for i, req := range reqs {
+		keys := []string{"source:" + builder.SanitizeForLabel(req.GetSourceID())}
+		if teamID := req.Additional[teamIDKey]; teamID != "" {
+			keys = append(keys, "team:"+builder.SanitizeForLabel(teamID))
 		}
 
-		// Check for failure
-		if instance.Status.Phase == "Failed" {
-			h.writeError(w, http.StatusInternalServerError, "Instance failed to start", "Challenge deployment failed")
-			return
+		g := len(groups)
+		groups = append(groups, []int{i})
+		for _, key := range keys {
+			other, ok := groupOf[key]
+			if !ok || other == g {
+				continue
+			}
+			// Merge the group already holding this key into the item's group
+			groups[g] = append(groups[g], groups[other]...)
+			groups[other] = nil
+			for k, owner := range groupOf {
+				if owner == other {
+					groupOf[k] = g
+				}
+			}
+		}
+		for _, key := range keys {
+			groupOf[key] = g
+		}
+	}
+
+	merged := groups[:0]
+	for _, group := range groups {
+		if len(group) > 0 {
+			slices.Sort(group)
+			merged = append(merged, group)
 		}
 	}
+	return merged
+}
 
-	if readyInstance == nil {
-		// Timeout waiting for ready, but return what we have
-		instance := &ctfv1alpha1.ChallengeInstance{}
-		if err := h.client.Get(ctx, types.NamespacedName{
-			Name:      instanceName,
-			Namespace: h.namespace,
-		}, instance); err != nil {
-			h.writeError(w, http.StatusInternalServerError, "Failed to get instance status", err.Error())
-			return
+// createItem creates the instance of one bulk item, after taking a creation token of its source
+func (h *Handler) createItem(ctx context.Context, req CreateInstanceRequest, origin map[string]string) BulkCreateResult {
+	result := BulkCreateResult{ChallengeID: req.GetChallengeID(), SourceID: req.GetSourceID(), Status: http.StatusCreated}
+	if result.SourceID != "" {
+		if ok, retryAfter := h.createLimiter.Reserve(builder.SanitizeForLabel(result.SourceID)); !ok {
+			slog.Warn("Source is creating instances too fast", "challenge_id", result.ChallengeID, "source_id", result.SourceID)
+			result.Status, result.Error = http.StatusTooManyRequests, "Too many instance creations"
+			result.Message = fmt.Sprintf("Rate limit exceeded, retry in %ds", int(math.Ceil(retryAfter.Seconds())))
+			return result
 		}
-		readyInstance = instance
-		log.Printf("Instance %s not ready after timeout, returning current state", instanceName)
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	h.writeInstanceResponse(w, readyInstance)
+	instance, _, existed, cerr := h.provisionInstance(ctx, req, origin)
+	switch {
+	case cerr != nil:
+		result.Status, result.Error, result.Message = cerr.status, cerr.err, cerr.message
+	case existed:
+		result.Status = http.StatusOK
+	}
+	if instance != nil {
+		result.Instance = instance.Name
+	}
+	return result
 }
 
 // GetInstance godoc
@@ -252,8 +965,11 @@ func (h *Handler) CreateInstance(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param challengeId path string true "Challenge ID"
 // @Param sourceId path string true "Source ID (user/team identifier)"
+// @Param create query bool false "Create the instance if it doesn't exist, like POST /instance"
 // @Success 200 {object} InstanceResponse
+// @Success 201 {object} InstanceResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /instance/{challengeId}/{sourceId} [get]
 func (h *Handler) GetInstance(w http.ResponseWriter, r *http.Request) {
@@ -261,22 +977,41 @@ func (h *Handler) GetInstance(w http.ResponseWriter, r *http.Request) {
 	sourceID := chi.URLParam(r, "sourceId")
 
 	if challengeID == "" || sourceID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
 		return
 	}
 
-	instanceName := fmt.Sprintf("chal-%s-%s", challengeID, sanitizeName(sourceID))
+	instanceName, err := h.resolveInstanceName(r, challengeID, sourceID)
+	if err != nil {
+		h.writeGetError(w, r, "Challenge not found", err)
+		return
+	}
 
 	instance := &ctfv1alpha1.ChallengeInstance{}
 	if err := h.client.Get(context.Background(), types.NamespacedName{
 		Name:      instanceName,
 		Namespace: h.namespace,
 	}, instance); err != nil {
-		h.writeError(w, http.StatusNotFound, "Instance not found", err.Error())
+		// Integrations limited to GET can claim an instance with ?create=true
+		if apierrors.IsNotFound(err) && wantsCreate(r) {
+			req := CreateInstanceRequest{ChallengeID: challengeID, SourceID: sourceID}
+			if teamID := r.URL.Query().Get(teamIDKey); teamID != "" {
+				req.Additional = map[string]string{teamIDKey: teamID}
+			}
+			h.createInstance(w, r, req)
+			return
+		}
+		h.writeGetError(w, r, "Instance not found", err)
 		return
 	}
 
-	h.writeInstanceResponse(w, instance)
+	h.writeInstanceResponse(w, r, instance)
+}
+
+// wantsCreate reports whether a GET asked to create the missing instance via ?create=true
+func wantsCreate(r *http.Request) bool {
+	create, err := strconv.ParseBool(r.URL.Query().Get("create"))
+	return err == nil && create
 }
 
 // DeleteInstance godoc
@@ -295,11 +1030,15 @@ func (h *Handler) DeleteInstance(w http.ResponseWriter, r *http.Request) {
 	sourceID := chi.URLParam(r, "sourceId")
 
 	if challengeID == "" || sourceID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
 		return
 	}
 
-	instanceName := fmt.Sprintf("chal-%s-%s", challengeID, sanitizeName(sourceID))
+	instanceName, err := h.resolveInstanceName(r, challengeID, sourceID)
+	if err != nil {
+		h.writeGetError(w, r, "Challenge not found", err)
+		return
+	}
 
 	instance := &ctfv1alpha1.ChallengeInstance{}
 	ctx := context.Background()
@@ -308,25 +1047,25 @@ func (h *Handler) DeleteInstance(w http.ResponseWriter, r *http.Request) {
 		Name:      instanceName,
 		Namespace: h.namespace,
 	}, instance); err != nil {
-		h.writeError(w, http.StatusNotFound, "Instance not found", err.Error())
+		h.writeGetError(w, r, "Instance not found", err)
 		return
 	}
 
-	if err := h.client.Delete(ctx, instance); err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to delete instance", err.Error())
+	if err := h.client.Delete(ctx, instance, h.instanceDeleteOptions()...); err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to delete instance", err.Error())
 		return
 	}
 
-	log.Printf("Deleted instance %s", instanceName)
+	slog.Info("Deleted instance", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
 
 	// Return success response for CTFd compatibility
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+	if err := newJSONEncoder(w, r).Encode(map[string]interface{}{
 		"success": true,
 		"message": "Instance deleted successfully",
 	}); err != nil {
-		log.Printf("handlers: encode response: %v", err)
+		slog.Error("handlers: encode response", "error", err)
 	}
 }
 
@@ -337,53 +1076,191 @@ func (h *Handler) DeleteInstance(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param source_id query string false "Filter by source ID"
 // @Param sourceId query string false "Filter by source ID (camelCase)"
+// @Param limit query int false "Maximum number of instances per page"
+// @Param continue query string false "Continue token of the previous page"
 // @Success 200 {array} InstanceResponse
+// @Header 200 {string} X-Continue-Token "Token of the next page, absent on the last one"
+// @Failure 400 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /instance [get]
 func (h *Handler) ListInstances(w http.ResponseWriter, r *http.Request) {
-	// Support both snake_case and camelCase query params
-	sourceID := r.URL.Query().Get("source_id")
-	if sourceID == "" {
-		sourceID = r.URL.Query().Get("sourceId")
+	sourceID := querySourceID(r)
+
+	pageOpts, err := paginationOptions(r)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid pagination", err.Error())
+		return
 	}
 
 	instanceList := &ctfv1alpha1.ChallengeInstanceList{}
-	listOpts := []client.ListOption{
+	listOpts := append([]client.ListOption{
 		client.InNamespace(h.namespace),
-	}
+	}, pageOpts...)
 
 	if sourceID != "" {
-		listOpts = append(listOpts, client.MatchingLabels{
-			"ctf.io/source": sanitizeName(sourceID),
-		})
+		listOpts = append(listOpts, sourceSelector(sourceID))
 	}
 
 	if err := h.client.List(context.Background(), instanceList, listOpts...); err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to list instances", err.Error())
+		h.writeListError(w, r, "Failed to list instances", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setContinueToken(w, instanceList.Continue)
 
 	// Return instances in streaming format (one {"result": {...}} per line)
-	// This matches the format expected by the CTFd plugin
+	// This matches the format expected by the CTFd plugin, the key is set by LIST_WRAPPER_KEY
+	servicePorts := h.listServicePorts(r.Context(), "")
 	for _, instance := range instanceList.Items {
-		response := h.buildInstanceResponse(&instance)
-		result := map[string]interface{}{
-			"result": response,
-		}
-		data, err := json.Marshal(result)
+		response := h.instanceResponse(&instance, servicePorts[serviceKey(&instance)])
+		data, err := json.Marshal(h.wrapListItem(response))
 		if err != nil {
-			log.Printf("handlers: marshal response: %v", err)
+			slog.Error("handlers: marshal response", "error", err)
+			continue
+		}
+		// Stop streaming once the client is gone, the remaining lines would fail too
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			slog.Error("handlers: write data", "error", err)
+			return
+		}
+	}
+}
+
+// querySourceID returns the source_id (or sourceId) query param
+func querySourceID(r *http.Request) string {
+	// Support both snake_case and camelCase query params
+	if sourceID := r.URL.Query().Get("source_id"); sourceID != "" {
+		return sourceID
+	}
+	return r.URL.Query().Get("sourceId")
+}
+
+// sourceSelector selects the instances of a source by their ctf.io/source label
+func sourceSelector(sourceID string) client.MatchingLabels {
+	return client.MatchingLabels{"ctf.io/source": builder.SanitizeForLabel(sourceID)}
+}
+
+// DeleteSourceInstances godoc
+// @Summary Delete all instances of a source
+// @Description Delete every ChallengeInstance of a source, e.g. a disqualified team
+// @Tags instances
+// @Produce json
+// @Param source_id query string true "Source ID whose instances are deleted"
+// @Param sourceId query string false "Source ID (camelCase)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /instance [delete]
+func (h *Handler) DeleteSourceInstances(w http.ResponseWriter, r *http.Request) {
+	sourceID := querySourceID(r)
+	// Without a source the selector would match every instance
+	if sourceID == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Missing required fields", "source_id is required")
+		return
+	}
+
+	ctx := context.Background()
+	instanceList := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := h.client.List(ctx, instanceList, client.InNamespace(h.namespace), sourceSelector(sourceID)); err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to list instances", err.Error())
+		return
+	}
+
+	deleted := 0
+	var failed []string
+	for i := range instanceList.Items {
+		instance := &instanceList.Items[i]
+		// Distinct source IDs may sanitize to the same label, only reap the exact source
+		if instance.Spec.SourceID != sourceID {
 			continue
 		}
-		if _, err := w.Write(data); err != nil {
-			log.Printf("handlers: write data: %v", err)
+		if err := h.client.Delete(ctx, instance, h.instanceDeleteOptions()...); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			slog.Error("Failed to delete instance", "challenge_id", instance.Spec.ChallengeID, "source_id", sourceID, "instance", instance.Name, "error", err)
+			failed = append(failed, instance.Name)
+			continue
 		}
-		if _, err := w.Write([]byte("\n")); err != nil {
-			log.Printf("handlers: write newline: %v", err)
+		deleted++
+	}
+
+	slog.Info("Deleted source instances", "source_id", sourceID, "deleted", deleted, "failed", len(failed))
+	if len(failed) > 0 {
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to delete instances",
+			fmt.Sprintf("deleted %d, failed: %s", deleted, strings.Join(failed, ", ")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := newJSONEncoder(w, r).Encode(map[string]interface{}{
+		"success": true,
+		"deleted": deleted,
+	}); err != nil {
+		slog.Error("handlers: encode response", "error", err)
+	}
+}
+
+// continueTokenHeader carries the continue token of the next list page
+const continueTokenHeader = "X-Continue-Token"
+
+// paginationOptions maps the ?limit= and ?continue= query params to list options
+func paginationOptions(r *http.Request) ([]client.ListOption, error) {
+	var opts []client.ListOption
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("limit must be a positive integer, got %q", v)
 		}
+		opts = append(opts, client.Limit(limit))
+	}
+	if token := r.URL.Query().Get("continue"); token != "" {
+		opts = append(opts, client.Continue(token))
+	}
+	return opts, nil
+}
+
+// setContinueToken advertises the next page, if any, before the streamed body
+func setContinueToken(w http.ResponseWriter, token string) {
+	if token != "" {
+		w.Header().Set(continueTokenHeader, token)
+	}
+}
+
+// writeListError reports a failed list, as 410 Gone when the continue token expired so clients restart from the first page
+func (h *Handler) writeListError(w http.ResponseWriter, r *http.Request, errStr string, err error) {
+	if apierrors.IsResourceExpired(err) {
+		h.writeError(w, r, http.StatusGone, "Continue token expired", err.Error())
+		return
+	}
+	h.writeError(w, r, http.StatusInternalServerError, errStr, err.Error())
+}
+
+// writeGetError reports a failed Get: 404 only when the object doesn't exist, 503 when the API server
+// can't be reached so clients retry instead of recreating it, 500 otherwise
+func (h *Handler) writeGetError(w http.ResponseWriter, r *http.Request, notFound string, err error) {
+	switch {
+	case apierrors.IsNotFound(err):
+		h.writeError(w, r, http.StatusNotFound, notFound, err.Error())
+	case apiUnavailable(err):
+		h.writeError(w, r, http.StatusServiceUnavailable, "Kubernetes API unavailable", err.Error())
+	default:
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to get resource", err.Error())
+	}
+}
+
+// apiUnavailable reports whether err means the API server couldn't be reached or didn't answer in time
+func apiUnavailable(err error) bool {
+	if apierrors.IsServiceUnavailable(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) {
+		return true
 	}
+	// Transport failures (refused, reset, DNS, timeouts) surface as net.Error, e.g. *url.Error
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
 }
 
 // ValidateFlagRequest represents the request body for flag validation
@@ -398,22 +1275,32 @@ func (h *Handler) ValidateFlag(w http.ResponseWriter, r *http.Request) {
 	sourceID := chi.URLParam(r, "sourceId")
 
 	if challengeID == "" || sourceID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
+		return
+	}
+
+	// Throttle submissions per (challenge, source) to prevent flag brute-forcing
+	if !h.flagLimiter.Allow(challengeID + "/" + builder.SanitizeForLabel(sourceID)) {
+		h.writeError(w, r, http.StatusTooManyRequests, "Too many flag submissions", "Rate limit exceeded, try again later")
 		return
 	}
 
 	var req ValidateFlagRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
 
 	if req.Flag == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing flag", "flag is required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing flag", "flag is required")
 		return
 	}
 
-	instanceName := fmt.Sprintf("chal-%s-%s", challengeID, sanitizeName(sourceID))
+	instanceName, err := h.resolveInstanceName(r, challengeID, sourceID)
+	if err != nil {
+		h.writeGetError(w, r, "Challenge not found", err)
+		return
+	}
 	ctx := context.Background()
 
 	instance := &ctfv1alpha1.ChallengeInstance{}
@@ -421,12 +1308,15 @@ func (h *Handler) ValidateFlag(w http.ResponseWriter, r *http.Request) {
 		Name:      instanceName,
 		Namespace: h.namespace,
 	}, instance); err != nil {
-		h.writeError(w, http.StatusNotFound, "Instance not found", err.Error())
+		h.writeGetError(w, r, "Instance not found", err)
 		return
 	}
 
-	// Check if the flag is correct
+	// Check if the flag is correct, against the salted hashes when the operator only stores those
 	flagValid := false
+	if len(instance.Status.FlagHashes) > 0 {
+		flagValid = flaggen.MatchesHash(req.Flag, instance.Status.FlagSalt, instance.Status.FlagHashes)
+	}
 	for _, correctFlag := range instance.Status.Flags {
 		if req.Flag == correctFlag {
 			flagValid = true
@@ -435,26 +1325,36 @@ func (h *Handler) ValidateFlag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !flagValid {
-		h.writeError(w, http.StatusForbidden, "Invalid flag", "The submitted flag is incorrect")
+		metrics.FlagValidations.WithLabelValues(challengeID, "incorrect").Inc()
+		h.writeError(w, r, http.StatusForbidden, "Invalid flag", "The submitted flag is incorrect")
 		return
 	}
 
-	// Mark the instance for deletion by setting FlagValidated = true
+	// Mark the instance solved; unless its challenge disables destroyOnFlag, the operator deletes it
 	instance.Status.FlagValidated = true
 	if err := h.client.Status().Update(ctx, instance); err != nil {
-		log.Printf("Failed to mark instance %s as validated: %v", instanceName, err)
-		h.writeError(w, http.StatusInternalServerError, "Failed to validate flag", err.Error())
+		slog.Error("Failed to mark instance as validated", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName, "error", err)
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to validate flag", err.Error())
 		return
 	}
 
-	log.Printf("Flag validated for instance %s, marked for deletion", instanceName)
+	metrics.FlagValidations.WithLabelValues(challengeID, "correct").Inc()
+
+	message := "Flag correct! Instance will be cleaned up."
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: instance.Spec.ChallengeName, Namespace: h.namespace}, challenge); err == nil && !builder.DestroyOnFlag(challenge) {
+		message = "Flag correct! Instance stays available."
+		slog.Info("Flag validated, instance kept running", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
+	} else {
+		slog.Info("Flag validated, instance marked for deletion", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+	if err := newJSONEncoder(w, r).Encode(map[string]interface{}{
 		"valid":   true,
-		"message": "Flag correct! Instance will be cleaned up.",
+		"message": message,
 	}); err != nil {
-		log.Printf("handlers: encode responses: %v", err)
+		slog.Error("handlers: encode responses", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 	}
 }
@@ -466,11 +1366,15 @@ func (h *Handler) RenewInstance(w http.ResponseWriter, r *http.Request) {
 	sourceID := chi.URLParam(r, "sourceId")
 
 	if challengeID == "" || sourceID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
 		return
 	}
 
-	instanceName := fmt.Sprintf("chal-%s-%s", challengeID, sanitizeName(sourceID))
+	instanceName, err := h.resolveInstanceName(r, challengeID, sourceID)
+	if err != nil {
+		h.writeGetError(w, r, "Challenge not found", err)
+		return
+	}
 	ctx := context.Background()
 
 	instance := &ctfv1alpha1.ChallengeInstance{}
@@ -478,7 +1382,7 @@ func (h *Handler) RenewInstance(w http.ResponseWriter, r *http.Request) {
 		Name:      instanceName,
 		Namespace: h.namespace,
 	}, instance); err != nil {
-		h.writeError(w, http.StatusNotFound, "Instance not found", err.Error())
+		h.writeGetError(w, r, "Instance not found", err)
 		return
 	}
 
@@ -494,82 +1398,256 @@ func (h *Handler) RenewInstance(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Extend expiration
+	// Extend expiration in a single write; a concurrent update (finalizer, labels) is read again rather than failing the renew
+	// The Until change re-triggers the reconcile, which refreshes what derives from it (instance info, draining)
 	newUntil := metav1.NewTime(time.Now().Add(time.Duration(timeout) * time.Second))
-	instance.Spec.Until = &newUntil
-
-	if err := h.client.Update(ctx, instance); err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to renew instance", err.Error())
+	stale := false
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if stale {
+			if err := h.client.Get(ctx, client.ObjectKeyFromObject(instance), instance); err != nil {
+				return err
+			}
+		}
+		stale = true
+		instance.Spec.Until = &newUntil
+		return h.client.Update(ctx, instance)
+	}); err != nil {
+		if apierrors.IsNotFound(err) {
+			h.writeGetError(w, r, "Instance not found", err)
+			return
+		}
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to renew instance", err.Error())
 		return
 	}
 
-	log.Printf("Instance %s renewed until %s", instanceName, newUntil.Format(time.RFC3339))
-	h.writeInstanceResponse(w, instance)
+	slog.Info("Instance renewed", "challenge_id", challengeID, "source_id", sourceID, "instance", instanceName, "until", newUntil.Format(time.RFC3339))
+	h.writeInstanceResponse(w, r, instance)
 }
 
 // Health handles GET /health
+// The response names the leading operator replica when its leader election Lease is live
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]string{"status": "ok"}
+	if leader := h.controllerLeader(r.Context()); leader != "" {
+		resp["leader"] = leader
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{"status": "ok"}); err != nil {
-		log.Printf("handlers: encode responses: %v", err)
+	if err := newJSONEncoder(w, r).Encode(resp); err != nil {
+		slog.Error("handlers: encode responses", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
 // writeError writes an error response
-func (h *Handler) writeError(w http.ResponseWriter, status int, errStr, message string) {
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, status int, errStr, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(ErrorResponse{
+	if err := newJSONEncoder(w, r).Encode(ErrorResponse{
 		Error:   errStr,
 		Message: message,
 	}); err != nil {
-		log.Printf("handlers: encode responses: %v", err)
+		slog.Error("handlers: encode responses", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
 // writeInstanceResponse writes an instance response
-func (h *Handler) writeInstanceResponse(w http.ResponseWriter, instance *ctfv1alpha1.ChallengeInstance) {
+func (h *Handler) writeInstanceResponse(w http.ResponseWriter, r *http.Request, instance *ctfv1alpha1.ChallengeInstance) {
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(h.buildInstanceResponse(instance)); err != nil {
-		log.Printf("handlers: encode responses: %v", err)
+	resp := h.buildInstanceResponse(instance)
+	// Who created the instance is moderation data, only shown to admins
+	if identity, ok := IdentityFromContext(r.Context()); ok && identity.Admin {
+		resp.CreatedBy = instance.Annotations[CreatedByAnnotation]
+		if !instance.CreationTimestamp.IsZero() {
+			resp.CreatedAt = instance.CreationTimestamp.Format(time.RFC3339)
+		}
+	}
+	if err := newJSONEncoder(w, r).Encode(resp); err != nil {
+		slog.Error("handlers: encode responses", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// wantsPrettyJSON reports whether the client asked for indented output via ?pretty=true
+func wantsPrettyJSON(r *http.Request) bool {
+	pretty, err := strconv.ParseBool(r.URL.Query().Get("pretty"))
+	return err == nil && pretty
+}
+
+// newJSONEncoder returns a JSON encoder for w, indenting output when the request asks for it
+// Streaming endpoints (one {"result": {...}} per line) keep using compact encoding
+func newJSONEncoder(w http.ResponseWriter, r *http.Request) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if wantsPrettyJSON(r) {
+		enc.SetIndent("", "  ")
+	}
+	return enc
+}
+
+// instanceLocation returns the GET route clients poll for an instance's status
+// The team_id query parameter is kept so members of a shared challenge get back to the team instance
+func instanceLocation(challengeID, sourceID, teamID string) string {
+	location := "/api/v1/instance/" + url.PathEscape(challengeID) + "/" + url.PathEscape(sourceID)
+	if teamID != "" {
+		location += "?" + teamIDKey + "=" + url.QueryEscape(teamID)
+	}
+	return location
+}
+
+// teamIDKey is the additional field, or query parameter, naming the team of a shared challenge instance
+const teamIDKey = "team_id"
+
+// sharedInstanceKey keys the instance of a shared challenge requested without a team_id
+const sharedInstanceKey = "shared"
+
+// instanceName returns the ChallengeInstance name of a challenge and instance key, sanitized for K8s
+// Prefixed with "chal-" to ensure DNS-1035 compliance (must start with letter)
+func instanceName(challengeID, key string) string {
+	return fmt.Sprintf("chal-%s-%s", challengeID, builder.SanitizeForLabel(key))
+}
+
+// instanceKey returns what the instances of a challenge are keyed on: the source itself,
+// or for shared challenges the team (teamID) or, without one, the challenge alone
+func (h *Handler) instanceKey(ctx context.Context, challengeID, sourceID, teamID string) (string, error) {
+	shared, err := h.sharedChallenge(ctx, challengeID)
+	if err != nil {
+		return "", err
+	}
+	return sharedKey(shared, sourceID, teamID), nil
+}
+
+// sharedChallenge reports whether the challenge shares its instances; a missing challenge doesn't
+func (h *Handler) sharedChallenge(ctx context.Context, challengeID string) (bool, error) {
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: challengeID, Namespace: h.namespace}, challenge); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return challenge.Spec.Shared, nil
+}
+
+// sharedKey returns the instance key of a source, see instanceKey
+func sharedKey(shared bool, sourceID, teamID string) string {
+	switch {
+	case !shared:
+		return sourceID
+	case teamID != "":
+		return teamID
+	default:
+		return sharedInstanceKey
+	}
+}
+
+// resolveInstanceName returns the name of the instance a source reaches through the instance routes,
+// the team instance for shared challenges (see instanceKey), with the team given by ?team_id=
+func (h *Handler) resolveInstanceName(r *http.Request, challengeID, sourceID string) (string, error) {
+	key, err := h.instanceKey(r.Context(), challengeID, sourceID, r.URL.Query().Get(teamIDKey))
+	if err != nil {
+		return "", err
+	}
+	return instanceName(challengeID, key), nil
+}
+
+// readyPollTimeout returns how long CreateInstance waits for readiness
+// READY_POLL_ATTEMPTS x READY_POLL_INTERVAL, extended to the challenge startup timeout when longer
+func (h *Handler) readyPollTimeout(challenge *ctfv1alpha1.Challenge) time.Duration {
+	timeout := time.Duration(h.readyPollAttempts) * h.readyPollInterval
+	if startup := time.Duration(challenge.Spec.Scenario.StartupTimeoutSeconds) * time.Second; startup > timeout {
+		timeout = startup
+	}
+	return timeout
+}
+
+// pollBackoffSchedule returns the delays before each readiness poll: initial, doubled up to max,
+// with the last delay trimmed so the total never exceeds timeout
+func pollBackoffSchedule(initial, max, timeout time.Duration) []time.Duration {
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if max < initial {
+		max = initial
+	}
+
+	var schedule []time.Duration
+	delay := initial
+	for elapsed := time.Duration(0); elapsed < timeout; {
+		if delay > timeout-elapsed {
+			delay = timeout - elapsed
+		}
+		schedule = append(schedule, delay)
+		elapsed += delay
+		if delay *= 2; delay > max {
+			delay = max
+		}
+	}
+	return schedule
+}
+
+// additionalAnnotations maps the configured Additional keys (team name, round, ...) to ctf.io/ annotations
+func (h *Handler) additionalAnnotations(additional map[string]string) map[string]string {
+	annotations := map[string]string{}
+	for _, key := range h.annotatedKeys {
+		if value, ok := additional[key]; ok {
+			annotations["ctf.io/"+key] = value
+		}
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
+
+// wrapListItem wraps an item of a streaming list response with the configured key
+// Returns the item unchanged when no wrapper key is configured
+func (h *Handler) wrapListItem(item interface{}) interface{} {
+	if h.listWrapperKey == "" {
+		return item
+	}
+	return map[string]interface{}{
+		h.listWrapperKey: item,
+	}
+}
+
 // buildInstanceResponse creates an InstanceResponse from a ChallengeInstance
 func (h *Handler) buildInstanceResponse(instance *ctfv1alpha1.ChallengeInstance) InstanceResponse {
+	return h.instanceResponse(instance, h.instancePorts(instance))
+}
+
+// instanceResponse creates an InstanceResponse from a ChallengeInstance and its Service port mappings
+func (h *Handler) instanceResponse(instance *ctfv1alpha1.ChallengeInstance, ports []PortMapping) InstanceResponse {
 	resp := InstanceResponse{
 		ChallengeID:    instance.Spec.ChallengeID,
 		SourceID:       instance.Spec.SourceID,
+		Phase:          instance.Status.Phase,
 		ConnectionInfo: instance.Status.ConnectionInfo,
 		Flags:          instance.Status.Flags,
 		Since:          instance.Spec.Since.Format(time.RFC3339),
 	}
 
-	// Calculate connectionInfo if not already set by controller
-	if resp.ConnectionInfo == "" {
-		// Get Challenge to check for Ingress config
-		challenge := &ctfv1alpha1.Challenge{}
-		if err := h.client.Get(context.Background(), types.NamespacedName{
-			Name:      instance.Spec.ChallengeID,
-			Namespace: h.namespace,
-		}, challenge); err == nil {
-			// Generate hostname using builder
-			hostname := builder.GetIngressHostname(instance, challenge)
-			if hostname != "" {
-				if challenge.Spec.Scenario.AttackBox != nil && challenge.Spec.Scenario.AttackBox.Enabled {
-					resp.ConnectionInfo = fmt.Sprintf("Challenge: http://%s\nTerminal: http://%s/terminal", hostname, hostname)
-				} else {
-					resp.ConnectionInfo = fmt.Sprintf("http://%s", hostname)
-				}
-			}
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := h.client.Get(context.Background(), types.NamespacedName{
+		Name:      instance.Spec.ChallengeID,
+		Namespace: h.namespace,
+	}, challenge); err == nil {
+		resp.ConnectionInstructions = challenge.Spec.ConnectionInstructions
+
+		// Calculate connectionInfo if not already set by controller
+		if resp.ConnectionInfo == "" {
+			resp.ConnectionInfo = builder.IngressConnectionInfo(instance, challenge)
 		}
 	}
+	if resp.ConnectionInfo == "" {
+		resp.ConnectionInfo = h.fallbackConnectionInfo(instance)
+	}
+
+	resp.ChallengeURL, resp.TerminalURL = splitConnectionInfo(resp.ConnectionInfo)
+	resp.Ports = ports
 
 	// Set deprecated Flag field for backwards compatibility
 	if len(instance.Status.Flags) > 0 {
@@ -578,12 +1656,120 @@ func (h *Handler) buildInstanceResponse(instance *ctfv1alpha1.ChallengeInstance)
 
 	if instance.Spec.Until != nil {
 		resp.Until = instance.Spec.Until.Format(time.RFC3339)
+		remaining := time.Until(instance.Spec.Until.Time)
+		resp.RemainingSeconds = int64(max(remaining, 0) / time.Second)
+		resp.Expired = remaining <= 0
+	}
+
+	if instance.Status.Draining {
+		resp.Draining = true
+		resp.Warning = fmt.Sprintf("Instance expires at %s, save your work", resp.Until)
 	}
 
 	return resp
 }
 
-// FlexibleInt64 can unmarshal from both string and int
+// fallbackConnectionInfo renders the configured fallback for a Ready instance still without connection info
+// once the grace period has passed, so users get a hint rather than a blank field; "" otherwise
+func (h *Handler) fallbackConnectionInfo(instance *ctfv1alpha1.ChallengeInstance) string {
+	if h.connectionFallback == nil || !instance.Status.Ready || time.Since(instance.Spec.Since.Time) < h.connectionGrace {
+		return ""
+	}
+
+	var buf strings.Builder
+	if err := h.connectionFallback.Execute(&buf, map[string]string{
+		"ChallengeID":  instance.Spec.ChallengeID,
+		"SourceID":     instance.Spec.SourceID,
+		"InstanceName": instance.Name,
+	}); err != nil {
+		slog.Error("Failed to render connection info fallback", "instance", instance.Name, "error", err)
+		return ""
+	}
+	return buf.String()
+}
+
+// instancePorts returns the port mappings of the instance Service, nil until it exists
+func (h *Handler) instancePorts(instance *ctfv1alpha1.ChallengeInstance) []PortMapping {
+	if instance.Status.ServiceName == "" {
+		return nil
+	}
+
+	service := &corev1.Service{}
+	if err := h.client.Get(context.Background(), serviceKey(instance), service); err != nil {
+		if !apierrors.IsNotFound(err) {
+			slog.Error("Failed to get service", "challenge_id", instance.Spec.ChallengeID, "source_id", instance.Spec.SourceID, "instance", instance.Name, "service", instance.Status.ServiceName, "error", err)
+		}
+		return nil
+	}
+	return portMappings(service)
+}
+
+// listServicePorts returns the port mappings of the operator Services, keyed by Service, restricted
+// to one challenge unless challengeID is empty
+// A single List backs the instance lists instead of one Get per instance; on error ports are left out
+func (h *Handler) listServicePorts(ctx context.Context, challengeID string) map[types.NamespacedName][]PortMapping {
+	selector := client.MatchingLabels{"app.kubernetes.io/managed-by": "chall-operator"}
+	if challengeID != "" {
+		selector["ctf.io/challenge"] = challengeID
+	}
+	opts := []client.ListOption{selector}
+	if !builder.NamespaceIsolation() {
+		opts = append(opts, client.InNamespace(h.namespace))
+	}
+
+	services := &corev1.ServiceList{}
+	if err := h.client.List(ctx, services, opts...); err != nil {
+		slog.Error("Failed to list services", "error", err)
+		return nil
+	}
+	ports := make(map[types.NamespacedName][]PortMapping, len(services.Items))
+	for i := range services.Items {
+		ports[client.ObjectKeyFromObject(&services.Items[i])] = portMappings(&services.Items[i])
+	}
+	return ports
+}
+
+// serviceKey returns the key of the instance Service
+func serviceKey(instance *ctfv1alpha1.ChallengeInstance) types.NamespacedName {
+	return types.NamespacedName{Name: instance.Status.ServiceName, Namespace: builder.TargetNamespace(instance)}
+}
+
+// portMappings converts the ports of a Service
+func portMappings(service *corev1.Service) []PortMapping {
+	ports := make([]PortMapping, 0, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, PortMapping{
+			Name:     port.Name,
+			Port:     port.Port,
+			NodePort: port.NodePort,
+			Protocol: string(port.Protocol),
+		})
+	}
+	return ports
+}
+
+// splitConnectionInfo extracts the challenge and terminal URLs from the connection info
+// It understands both "http://host" and the "Challenge: ...\nTerminal: ..." attackbox format;
+// non-HTTP connection info (e.g. "nc host port") yields empty URLs
+func splitConnectionInfo(connectionInfo string) (challengeURL, terminalURL string) {
+	for _, line := range strings.Split(connectionInfo, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Challenge: "):
+			challengeURL = strings.TrimPrefix(line, "Challenge: ")
+		case strings.HasPrefix(line, "Terminal: "):
+			terminalURL = strings.TrimPrefix(line, "Terminal: ")
+		case strings.HasPrefix(line, "http://"), strings.HasPrefix(line, "https://"):
+			if challengeURL == "" {
+				challengeURL = line
+			}
+		}
+	}
+	return challengeURL, terminalURL
+}
+
+// FlexibleInt64 is a number of seconds that can unmarshal from an int, a numeric string
+// or a Go duration string ("90s", "10m", "1h30m")
 type FlexibleInt64 int64
 
 func (f *FlexibleInt64) UnmarshalJSON(data []byte) error {
@@ -598,16 +1784,20 @@ func (f *FlexibleInt64) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &s); err != nil {
 		return err
 	}
-	// Strip common duration suffixes (s, m, h, etc.)
-	s = strings.TrimSuffix(s, "s")
-	s = strings.TrimSuffix(s, "m")
-	s = strings.TrimSuffix(s, "h")
-	// Parse string to int
-	i, err := strconv.ParseInt(s, 10, 64)
+	s = strings.TrimSpace(s)
+	// Bare numbers are already seconds
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*f = FlexibleInt64(i)
+		return nil
+	}
+	d, err := time.ParseDuration(s)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid duration %q: expected seconds or a duration like 90s, 10m or 1h30m", s)
+	}
+	if d < 0 || d%time.Second != 0 {
+		return fmt.Errorf("invalid duration %q: must be a non-negative whole number of seconds", s)
 	}
-	*f = FlexibleInt64(i)
+	*f = FlexibleInt64(d / time.Second)
 	return nil
 }
 
@@ -618,8 +1808,8 @@ type CreateChallengeRequest struct {
 	Scenario string        `json:"scenario"` // Image reference (e.g. registry.local:5000/chal1:latest)
 	Timeout  FlexibleInt64 `json:"timeout"`
 	// Additional fields from CTFd
-	DestroyOnFlag bool `json:"destroy_on_flag"`
-	Shared        bool `json:"shared"`
+	DestroyOnFlag *bool `json:"destroy_on_flag"` // Unset keeps the Challenge default, destroy
+	Shared        bool  `json:"shared"`
 }
 
 // ChallengeResponse represents the response for challenge operations
@@ -633,17 +1823,23 @@ type ChallengeResponse struct {
 // In GitOps mode: just verifies the Challenge CRD exists (doesn't create it)
 // The Challenge should be created manually via kubectl/ArgoCD
 // Uses the "scenario" field as the Challenge ID (ignores CTFd auto-incremented ID)
+// With GITOPS_MODE=false the Challenge is created from the request instead, see createChallenge
 func (h *Handler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
 	var req CreateChallengeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if !h.gitOpsMode {
+		h.createChallenge(w, r, req)
 		return
 	}
 
 	// Use scenario as the Challenge ID (GitOps: scenario = Challenge CRD name)
 	challengeID := req.Scenario
 	if challengeID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing required field", "scenario is required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing required field", "scenario is required")
 		return
 	}
 
@@ -656,17 +1852,81 @@ func (h *Handler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		Namespace: h.namespace,
 	}, existingChallenge)
 
+	if err != nil && !apierrors.IsNotFound(err) {
+		h.writeGetError(w, r, "Challenge not found", err)
+		return
+	}
 	if err != nil {
 		// Challenge doesn't exist - in GitOps mode, this is an error
-		log.Printf("Challenge %s not found (GitOps mode: create it manually with kubectl). CTFd ID: %s", challengeID, req.ID)
-		h.writeError(w, http.StatusNotFound, "Challenge not found", fmt.Sprintf("Challenge %s must be created manually via kubectl/ArgoCD before creating it in CTFd", challengeID))
+		slog.Warn("Challenge not found (GitOps mode: create it manually with kubectl)", "challenge_id", challengeID, "ctfd_id", req.ID)
+		h.writeError(w, r, http.StatusNotFound, "Challenge not found", fmt.Sprintf("Challenge %s must be created manually via kubectl/ArgoCD before creating it in CTFd", challengeID))
 		return
 	}
 
 	// Challenge exists, return it
-	log.Printf("Challenge %s found (GitOps mode). CTFd ID: %s", challengeID, req.ID)
+	slog.Info("Challenge found (GitOps mode)", "challenge_id", challengeID, "ctfd_id", req.ID)
 	w.WriteHeader(http.StatusOK)
-	h.writeChallengeResponse(w, existingChallenge)
+	h.writeChallengeResponse(w, r, existingChallenge)
+}
+
+// Defaults of Challenges created by the gateway outside GitOps mode
+const (
+	defaultChallengePort       int32 = 80
+	defaultChallengeExposeType       = "NodePort"
+)
+
+// createChallenge creates the Challenge described by req, for quick local testing without GitOps
+// The CTFd id names the Challenge (instances reference it) and scenario is the image; an existing
+// Challenge is returned unchanged with 200
+func (h *Handler) createChallenge(w http.ResponseWriter, r *http.Request, req CreateChallengeRequest) {
+	if req.ID == "" || req.Scenario == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Missing required field", "id and scenario (the image) are required")
+		return
+	}
+	// The name ends up in instance names (chal-<id>-<source>), so it must be a DNS label
+	if errs := validation.IsDNS1123Label(req.ID); len(errs) > 0 {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid challenge id", strings.Join(errs, "; "))
+		return
+	}
+
+	ctx := context.Background()
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.ID,
+			Namespace: h.namespace,
+		},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:            req.ID,
+			Timeout:       int64(req.Timeout),
+			DestroyOnFlag: req.DestroyOnFlag,
+			Shared:        req.Shared,
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:      req.Scenario,
+				Port:       defaultChallengePort,
+				ExposeType: defaultChallengeExposeType,
+			},
+		},
+	}
+
+	if err := h.client.Create(ctx, challenge); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			existing := &ctfv1alpha1.Challenge{}
+			if err := h.client.Get(ctx, types.NamespacedName{Name: req.ID, Namespace: h.namespace}, existing); err != nil {
+				h.writeGetError(w, r, "Challenge not found", err)
+				return
+			}
+			h.writeChallengeResponse(w, r, existing)
+			return
+		}
+		slog.Error("Failed to create challenge", "challenge_id", req.ID, "error", err)
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to create challenge", err.Error())
+		return
+	}
+
+	slog.Info("Created challenge", "challenge_id", req.ID, "image", req.Scenario)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.writeChallengeResponse(w, r, challenge)
 }
 
 // GetChallenge handles GET /api/v1/challenge/{challengeId}
@@ -674,7 +1934,7 @@ func (h *Handler) GetChallenge(w http.ResponseWriter, r *http.Request) {
 	challengeID := chi.URLParam(r, "challengeId")
 
 	if challengeID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameter", "challengeId is required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameter", "challengeId is required")
 		return
 	}
 
@@ -683,11 +1943,11 @@ func (h *Handler) GetChallenge(w http.ResponseWriter, r *http.Request) {
 		Name:      challengeID,
 		Namespace: h.namespace,
 	}, challenge); err != nil {
-		h.writeError(w, http.StatusNotFound, "Challenge not found", err.Error())
+		h.writeGetError(w, r, "Challenge not found", err)
 		return
 	}
 
-	h.writeChallengeResponse(w, challenge)
+	h.writeChallengeResponse(w, r, challenge)
 }
 
 // UpdateChallenge handles PATCH /api/v1/challenge/{challengeId}
@@ -695,13 +1955,13 @@ func (h *Handler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 	challengeID := chi.URLParam(r, "challengeId")
 
 	if challengeID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameter", "challengeId is required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameter", "challengeId is required")
 		return
 	}
 
 	var req CreateChallengeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		h.writeError(w, r, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
 
@@ -712,7 +1972,7 @@ func (h *Handler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 		Name:      challengeID,
 		Namespace: h.namespace,
 	}, challenge); err != nil {
-		h.writeError(w, http.StatusNotFound, "Challenge not found", err.Error())
+		h.writeGetError(w, r, "Challenge not found", err)
 		return
 	}
 
@@ -723,14 +1983,17 @@ func (h *Handler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 	if req.Timeout > 0 {
 		challenge.Spec.Timeout = int64(req.Timeout)
 	}
+	if req.DestroyOnFlag != nil {
+		challenge.Spec.DestroyOnFlag = req.DestroyOnFlag
+	}
 
 	if err := h.client.Update(ctx, challenge); err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to update challenge", err.Error())
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to update challenge", err.Error())
 		return
 	}
 
-	log.Printf("Updated challenge %s", challengeID)
-	h.writeChallengeResponse(w, challenge)
+	slog.Info("Updated challenge", "challenge_id", challengeID)
+	h.writeChallengeResponse(w, r, challenge)
 }
 
 // DeleteChallenge handles DELETE /api/v1/challenge/{challengeId}
@@ -738,7 +2001,7 @@ func (h *Handler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 	challengeID := chi.URLParam(r, "challengeId")
 
 	if challengeID == "" {
-		h.writeError(w, http.StatusBadRequest, "Missing path parameter", "challengeId is required")
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameter", "challengeId is required")
 		return
 	}
 
@@ -749,7 +2012,7 @@ func (h *Handler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 		Name:      challengeID,
 		Namespace: h.namespace,
 	}, challenge); err != nil {
-		h.writeError(w, http.StatusNotFound, "Challenge not found", err.Error())
+		h.writeGetError(w, r, "Challenge not found", err)
 		return
 	}
 
@@ -759,56 +2022,63 @@ func (h *Handler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 		"ctf.io/challenge": challengeID,
 	}); err == nil {
 		for _, instance := range instanceList.Items {
-			if err := h.client.Delete(ctx, &instance); err != nil {
-				log.Printf("Failed to delete instance %s: %v", instance.Name, err)
+			if err := h.client.Delete(ctx, &instance, h.instanceDeleteOptions()...); err != nil {
+				slog.Error("Failed to delete instance", "challenge_id", challengeID, "source_id", instance.Spec.SourceID, "instance", instance.Name, "error", err)
 			}
 		}
 	}
 
 	if err := h.client.Delete(ctx, challenge); err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to delete challenge", err.Error())
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to delete challenge", err.Error())
 		return
 	}
 
-	log.Printf("Deleted challenge %s and its instances", challengeID)
+	slog.Info("Deleted challenge and its instances", "challenge_id", challengeID)
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]string{"status": "deleted"}); err != nil {
-		log.Printf("handlers: encode response: %v", err)
+	if err := newJSONEncoder(w, r).Encode(map[string]string{"status": "deleted"}); err != nil {
+		slog.Error("handlers: encode response", "error", err)
 	}
 }
 
 // ListChallenges handles GET /api/v1/challenge
+// Like ListInstances, it pages with ?limit= and ?continue=, returning the next token in X-Continue-Token
 func (h *Handler) ListChallenges(w http.ResponseWriter, r *http.Request) {
+	pageOpts, err := paginationOptions(r)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "Invalid pagination", err.Error())
+		return
+	}
+
 	challengeList := &ctfv1alpha1.ChallengeList{}
-	if err := h.client.List(context.Background(), challengeList, client.InNamespace(h.namespace)); err != nil {
-		h.writeError(w, http.StatusInternalServerError, "Failed to list challenges", err.Error())
+	listOpts := append([]client.ListOption{client.InNamespace(h.namespace)}, pageOpts...)
+	if err := h.client.List(context.Background(), challengeList, listOpts...); err != nil {
+		h.writeListError(w, r, "Failed to list challenges", err)
 		return
 	}
 
 	// Stream response like chall-manager does
 	w.Header().Set("Content-Type", "application/json")
+	setContinueToken(w, challengeList.Continue)
 	for _, challenge := range challengeList.Items {
-		resp := map[string]interface{}{
-			"result": ChallengeResponse{
-				ID:       challenge.Spec.ID,
-				Scenario: challenge.Spec.Scenario.Image,
-				Timeout:  challenge.Spec.Timeout,
-			},
-		}
+		resp := h.wrapListItem(ChallengeResponse{
+			ID:       challenge.Spec.ID,
+			Scenario: challenge.Spec.Scenario.Image,
+			Timeout:  challenge.Spec.Timeout,
+		})
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			log.Printf("handlers: encode challenge: %v", err)
+			slog.Error("handlers: encode challenge", "error", err)
 		}
 	}
 }
 
 // writeChallengeResponse writes a challenge response
-func (h *Handler) writeChallengeResponse(w http.ResponseWriter, challenge *ctfv1alpha1.Challenge) {
+func (h *Handler) writeChallengeResponse(w http.ResponseWriter, r *http.Request, challenge *ctfv1alpha1.Challenge) {
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(ChallengeResponse{
+	if err := newJSONEncoder(w, r).Encode(ChallengeResponse{
 		ID:       challenge.Spec.ID,
 		Scenario: challenge.Spec.Scenario.Image,
 		Timeout:  challenge.Spec.Timeout,
 	}); err != nil {
-		log.Printf("handlers: encode challenge response: %v", err)
+		slog.Error("handlers: encode challenge response", "error", err)
 	}
 }
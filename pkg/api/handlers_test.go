@@ -0,0 +1,1781 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+	"github.com/leo/chall-operator/pkg/builder"
+	"github.com/leo/chall-operator/pkg/flaggen"
+)
+
+// newTestHandler creates a Handler backed by a fake client seeded with objs
+func newTestHandler(t *testing.T, objs ...client.Object) *Handler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := ctfv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := coordinationv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&ctfv1alpha1.ChallengeInstance{}).
+		Build()
+
+	h := NewHandler(c)
+	h.namespace = "ctf-instances"
+	h.readyPollInterval = 10 * time.Millisecond
+	h.readyBackoffInitial = 10 * time.Millisecond
+	h.readyBackoffMax = 10 * time.Millisecond
+	return h
+}
+
+// newReadyTestHandler is like newTestHandler but reports every fetched instance as Ready,
+// so CreateInstance returns after its first readiness poll
+func newReadyTestHandler(t *testing.T, objs ...client.Object) *Handler {
+	t.Helper()
+
+	h := newTestHandler(t, objs...)
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			if instance, ok := obj.(*ctfv1alpha1.ChallengeInstance); ok {
+				instance.Status.Ready = true
+			}
+			return nil
+		},
+	})
+	return h
+}
+
+// withURLParams attaches chi route parameters to a request for direct handler calls
+func withURLParams(req *http.Request, params map[string]string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestWriteError_Compact(t *testing.T) {
+	h := &Handler{}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/instance/101/user", nil)
+	rec := httptest.NewRecorder()
+
+	h.writeError(rec, req, http.StatusNotFound, "Instance not found", "missing")
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+
+	expected := `{"error":"Instance not found","message":"missing"}` + "\n"
+	if rec.Body.String() != expected {
+		t.Errorf("Expected compact body %q, got %q", expected, rec.Body.String())
+	}
+}
+
+func TestWriteError_Pretty(t *testing.T) {
+	h := &Handler{}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/instance/101/user?pretty=true", nil)
+	rec := httptest.NewRecorder()
+
+	h.writeError(rec, req, http.StatusNotFound, "Instance not found", "missing")
+
+	expected := "{\n  \"error\": \"Instance not found\",\n  \"message\": \"missing\"\n}\n"
+	if rec.Body.String() != expected {
+		t.Errorf("Expected indented body %q, got %q", expected, rec.Body.String())
+	}
+}
+
+func TestWantsPrettyJSON(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{"", false},
+		{"?pretty=true", true},
+		{"?pretty=1", true},
+		{"?pretty=false", false},
+		{"?pretty=yes", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/health"+tt.query, nil)
+		if got := wantsPrettyJSON(req); got != tt.expected {
+			t.Errorf("wantsPrettyJSON(%q) = %v, expected %v", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestHealth_Pretty(t *testing.T) {
+	h := &Handler{}
+	req := httptest.NewRequest(http.MethodGet, "/health?pretty=true", nil)
+	rec := httptest.NewRecorder()
+
+	h.Health(rec, req)
+
+	if !strings.Contains(rec.Body.String(), "\n  \"status\": \"ok\"\n") {
+		t.Errorf("Expected indented health response, got %q", rec.Body.String())
+	}
+}
+
+func TestHealth_ReportsLeader(t *testing.T) {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: leaderElectionID, Namespace: "chall-operator-system"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("controller-manager-7d9f_1234"),
+			LeaseDurationSeconds: ptr.To(int32(15)),
+			RenewTime:            &metav1.MicroTime{Time: time.Now()},
+		},
+	}
+	h := newTestHandler(t, lease)
+	h.leaderNamespace = "chall-operator-system"
+
+	rec := httptest.NewRecorder()
+	h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var resp map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["leader"] != "controller-manager-7d9f_1234" {
+		t.Errorf("Expected leader controller-manager-7d9f_1234, got %q", resp["leader"])
+	}
+
+	// A lease that wasn't renewed in time has no live leader
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().Add(-time.Minute)}
+	if err := h.client.Update(context.Background(), lease); err != nil {
+		t.Fatalf("Failed to update lease: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if strings.Contains(rec.Body.String(), "leader") {
+		t.Errorf("Expected no leader for an expired lease, got %s", rec.Body.String())
+	}
+}
+
+func TestCreateInstance_RejectsHostnameOutsideBaseDomain(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+
+	h := &Handler{}
+	body := `{"challenge_id":"101","source_id":"alice","additional":{"hostname":"evil.example.com"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.CreateInstance(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "Invalid hostname") {
+		t.Errorf("Expected invalid hostname error, got %s", rec.Body.String())
+	}
+}
+
+func TestListChallenges_WrapperKey(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "101", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "101",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:alpine", Port: 80},
+			Timeout:  600,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		wrapperKey string
+		expectKey  string
+	}{
+		{"default", "result", "result"},
+		{"data", "data", "data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, challenge)
+			h.listWrapperKey = tt.wrapperKey
+
+			rec := httptest.NewRecorder()
+			h.ListChallenges(rec, httptest.NewRequest(http.MethodGet, "/api/v1/challenge", nil))
+
+			var line map[string]ChallengeResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &line); err != nil {
+				t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+			}
+			if line[tt.expectKey].ID != "101" {
+				t.Errorf("Expected challenge under key %q, got %s", tt.expectKey, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestListInstances_BareObjects(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{ConnectionInfo: "nc localhost 30000"},
+	}
+
+	h := newTestHandler(t, instance)
+	h.listWrapperKey = ""
+
+	rec := httptest.NewRecorder()
+	h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance", nil))
+
+	var resp InstanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	if resp.ChallengeID != "101" || resp.ConnectionInfo != "nc localhost 30000" {
+		t.Errorf("Expected bare instance object, got %s", rec.Body.String())
+	}
+}
+
+func TestListInstances_StreamsOneLinePerInstance(t *testing.T) {
+	var objs []client.Object
+	for challengeID, source := range map[string]string{"101": "alice", "102": "alice", "103": "bob"} {
+		objs = append(objs, &ctfv1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "chal-" + challengeID + "-" + source,
+				Namespace: "ctf-instances",
+				Labels:    map[string]string{"ctf.io/source": source},
+			},
+			Spec: ctfv1alpha1.ChallengeInstanceSpec{
+				ChallengeID:   challengeID,
+				SourceID:      source,
+				ChallengeName: challengeID,
+				Since:         metav1.Now(),
+			},
+		})
+	}
+
+	h := newTestHandler(t, objs...)
+
+	rec := httptest.NewRecorder()
+	h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance?source_id=alice", nil))
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", contentType)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 streamed lines for alice, got %d: %q", len(lines), rec.Body.String())
+	}
+	for _, line := range lines {
+		var item map[string]InstanceResponse
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			t.Fatalf("Failed to decode line %q: %v", line, err)
+		}
+		if resp := item[h.listWrapperKey]; resp.SourceID != "alice" {
+			t.Errorf("Expected only alice's instances, got source %q", resp.SourceID)
+		}
+	}
+}
+
+func TestBulkCreateInstance(t *testing.T) {
+	existing := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-team-2", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "team-2",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+	}
+	h := newTestHandler(t, existing)
+
+	body := `[
+		{"challenge_id":"101","source_id":"team-1"},
+		{"challenge_id":"102","source_id":"team-1"},
+		{"challenge_id":"101","source_id":"team-2"},
+		{"challenge_id":"103"}
+	]`
+	rec := httptest.NewRecorder()
+	h.BulkCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/bulk", strings.NewReader(body)))
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var results []BulkCreateResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+	expected := []struct {
+		instance string
+		status   int
+	}{
+		{"chal-101-team-1", http.StatusCreated},
+		{"chal-102-team-1", http.StatusCreated},
+		{"chal-101-team-2", http.StatusOK},
+		{"", http.StatusBadRequest},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, want := range expected {
+		if results[i].Instance != want.instance || results[i].Status != want.status {
+			t.Errorf("Expected item %d to be %s with status %d, got %+v", i, want.instance, want.status, results[i])
+		}
+	}
+
+	// Created without waiting for readiness
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-102-team-1", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Errorf("Expected instance chal-102-team-1 to be created: %v", err)
+	}
+
+	for _, body := range []string{`[]`, `{"challenge_id":"101"}`} {
+		rec = httptest.NewRecorder()
+		h.BulkCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/bulk", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for body %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestBulkCreateInstance_PerSourceLimits(t *testing.T) {
+	h := newTestHandler(t)
+	h.createLimiter = newTokenBucketLimiter(1.0/60, 1)
+
+	body := `[
+		{"challenge_id":"101","source_id":"team-1"},
+		{"challenge_id":"102","source_id":"team-1"},
+		{"challenge_id":"101","source_id":"team-2"}
+	]`
+	rec := httptest.NewRecorder()
+	h.BulkCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/bulk", strings.NewReader(body)))
+
+	var results []BulkCreateResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+	// Each source has its own token: team-1's second item is throttled, team-2 is not
+	expected := []int{http.StatusCreated, http.StatusTooManyRequests, http.StatusCreated}
+	for i, want := range expected {
+		if results[i].Status != want {
+			t.Errorf("Expected item %d to have status %d, got %+v", i, want, results[i])
+		}
+	}
+
+	h = newTestHandler(t)
+	h.createLimiter = nil
+	h.maxInstancesPerSource = 2
+	items := make([]string, 6)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"challenge_id":"%d","source_id":"team-3"}`, 200+i)
+	}
+	rec = httptest.NewRecorder()
+	h.BulkCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/bulk", strings.NewReader("["+strings.Join(items, ",")+"]")))
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+
+	// Items of one source don't race the quota: exactly the first two are created
+	instances := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := h.client.List(context.Background(), instances); err != nil {
+		t.Fatalf("Failed to list instances: %v", err)
+	}
+	if len(instances.Items) != 2 {
+		t.Errorf("Expected the quota of 2 instances, got %d", len(instances.Items))
+	}
+	for i, result := range results {
+		want := http.StatusTooManyRequests
+		if i < 2 {
+			want = http.StatusCreated
+		}
+		if result.Status != want {
+			t.Errorf("Expected item %d to have status %d, got %+v", i, want, result)
+		}
+	}
+}
+
+func TestBatchCreateInstance(t *testing.T) {
+	existing := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-bob", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "bob",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+	}
+	h := newTestHandler(t, existing)
+
+	body := `{"challenge_id":"101","source_ids":["alice","bob",""]}`
+	rec := httptest.NewRecorder()
+	h.BatchCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/batch", strings.NewReader(body)))
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var results []BatchCreateResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+	expected := []BatchCreateResult{
+		{SourceID: "alice", Instance: "chal-101-alice", Status: BatchStatusCreated},
+		{SourceID: "bob", Instance: "chal-101-bob", Status: BatchStatusExists},
+		{SourceID: "", Status: BatchStatusError},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, want := range expected {
+		got := results[i]
+		if got.SourceID != want.SourceID || got.Instance != want.Instance || got.Status != want.Status {
+			t.Errorf("Expected item %d to be %+v, got %+v", i, want, got)
+		}
+	}
+	if results[2].Error == "" {
+		t.Error("Expected the failing source to report its error")
+	}
+
+	// Labels and naming come from the single-instance path
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-101-alice", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Fatalf("Expected instance chal-101-alice to be created: %v", err)
+	}
+	if instance.Labels["ctf.io/source"] != "alice" {
+		t.Errorf("Expected source label alice, got %v", instance.Labels)
+	}
+
+	for _, body := range []string{`{"source_ids":["alice"]}`, `{"challenge_id":"101","source_ids":[]}`, `[]`} {
+		rec = httptest.NewRecorder()
+		h.BatchCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/batch", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for body %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestBatchCreateInstance_ThrottlesPerSource(t *testing.T) {
+	h := newTestHandler(t)
+	h.createLimiter = newTokenBucketLimiter(1.0/60, 1)
+
+	batch := func(body string) []BatchCreateResult {
+		rec := httptest.NewRecorder()
+		h.BatchCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/batch", strings.NewReader(body)))
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var results []BatchCreateResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+		}
+		return results
+	}
+
+	if results := batch(`{"challenge_id":"101","source_ids":["alice","bob"]}`); results[0].Status != BatchStatusCreated || results[1].Status != BatchStatusCreated {
+		t.Errorf("Expected both sources created with their own token, got %+v", results)
+	}
+
+	results := batch(`{"challenge_id":"102","source_ids":["alice","carol"]}`)
+	if results[0].Status != BatchStatusError || results[0].Error != "Too many instance creations" {
+		t.Errorf("Expected alice to be throttled, got %+v", results[0])
+	}
+	if results[1].Status != BatchStatusCreated {
+		t.Errorf("Expected carol to be created, got %+v", results[1])
+	}
+}
+
+func TestBatchInstanceStatus(t *testing.T) {
+	running := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "chal-101-bob",
+			Namespace: "ctf-instances",
+			Labels:    map[string]string{"ctf.io/challenge": "101"},
+		},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "bob",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{Phase: "Running", ConnectionInfo: "nc 192.0.2.10 31000"},
+	}
+	h := newTestHandler(t, running)
+
+	body := `[{"challenge_id":"101","source_id":"bob"},{"challenge_id":"101","source_id":"alice"},{"challenge_id":"101"}]`
+	rec := httptest.NewRecorder()
+	h.BatchInstanceStatus(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/status/batch", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var results []InstanceStatusResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Status != http.StatusOK || results[0].Instance == nil {
+		t.Fatalf("Expected bob's instance to be found, got %+v", results[0])
+	}
+	if results[0].Instance.Phase != "Running" || results[0].Instance.ConnectionInfo != "nc 192.0.2.10 31000" {
+		t.Errorf("Expected bob's instance status, got %+v", *results[0].Instance)
+	}
+	if results[1].SourceID != "alice" || results[1].Status != http.StatusNotFound || results[1].Instance != nil {
+		t.Errorf("Expected alice's instance to be not found, got %+v", results[1])
+	}
+	if results[2].Status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an item without source, got %+v", results[2])
+	}
+
+	for _, body := range []string{`[]`, `{"challenge_id":"101"}`} {
+		rec = httptest.NewRecorder()
+		h.BatchInstanceStatus(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/status/batch", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for body %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestBatchInstanceStatus_ChallengeLookupError(t *testing.T) {
+	running := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "chal-101-bob",
+			Namespace: "ctf-instances",
+			Labels:    map[string]string{"ctf.io/challenge": "101"},
+		},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "101", SourceID: "bob", ChallengeName: "101", Since: metav1.Now()},
+	}
+	h := newTestHandler(t, running)
+
+	// The lookup of challenge 102 fails; every List must select a single challenge
+	var unfiltered int
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*ctfv1alpha1.Challenge); ok && key.Name == "102" {
+				return errors.New("etcd exploded")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			listOpts := &client.ListOptions{}
+			listOpts.ApplyOptions(opts)
+			if listOpts.LabelSelector == nil || listOpts.LabelSelector.Empty() {
+				unfiltered++
+			}
+			return c.List(ctx, list, opts...)
+		},
+	})
+
+	body := `[{"challenge_id":"101","source_id":"bob"},{"challenge_id":"102","source_id":"bob"},{"challenge_id":"101","source_id":"alice"}]`
+	rec := httptest.NewRecorder()
+	h.BatchInstanceStatus(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/status/batch", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var results []InstanceStatusResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Status != http.StatusOK || results[0].Instance == nil {
+		t.Errorf("Expected bob's instance of 101 to be found, got %+v", results[0])
+	}
+	if results[1].Status != http.StatusInternalServerError || results[1].Error == "" {
+		t.Errorf("Expected status 500 for the failing challenge, got %+v", results[1])
+	}
+	if results[2].Status != http.StatusNotFound {
+		t.Errorf("Expected alice's instance to be not found, got %+v", results[2])
+	}
+	if unfiltered != 0 {
+		t.Errorf("Expected every List to select a challenge, got %d unfiltered", unfiltered)
+	}
+}
+
+func TestListInstances_Pagination(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+	}
+
+	// The fake client ignores limit/continue: record them and hand out a next page token
+	var listOpts client.ListOptions
+	h := newTestHandler(t, instance)
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			listOpts.ApplyOptions(opts)
+			if listOpts.Continue == "expired" {
+				return apierrors.NewResourceExpired("continue token expired")
+			}
+			if err := c.List(ctx, list, opts...); err != nil {
+				return err
+			}
+			list.SetContinue("page-2")
+			return nil
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance?limit=50&continue=page-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if listOpts.Limit != 50 || listOpts.Continue != "page-1" {
+		t.Errorf("Expected limit 50 and continue page-1, got %d %q", listOpts.Limit, listOpts.Continue)
+	}
+	if token := rec.Header().Get("X-Continue-Token"); token != "page-2" {
+		t.Errorf("Expected X-Continue-Token page-2, got %q", token)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ListChallenges(rec, httptest.NewRequest(http.MethodGet, "/api/v1/challenge?limit=10", nil))
+	if listOpts.Limit != 10 || rec.Header().Get("X-Continue-Token") != "page-2" {
+		t.Errorf("Expected challenges to be paged too, got limit %d and token %q", listOpts.Limit, rec.Header().Get("X-Continue-Token"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance?continue=expired", nil))
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected status 410 for an expired continue token, got %d", rec.Code)
+	}
+
+	for _, limit := range []string{"0", "-1", "many"} {
+		rec = httptest.NewRecorder()
+		h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance?limit="+limit, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for limit %q, got %d", limit, rec.Code)
+		}
+	}
+}
+
+func TestListInstances_StreamsSeparateURLs(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{
+			ConnectionInfo: "Challenge: http://ctf.alice.101.devleo.local\nTerminal: http://ctf.alice.101.devleo.local/terminal",
+		},
+	}
+
+	h := newTestHandler(t, instance)
+
+	rec := httptest.NewRecorder()
+	h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance", nil))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 streamed line, got %d: %q", len(lines), rec.Body.String())
+	}
+
+	var item map[string]InstanceResponse
+	if err := json.Unmarshal([]byte(lines[0]), &item); err != nil {
+		t.Fatalf("Failed to decode line %q: %v", lines[0], err)
+	}
+	resp := item[h.listWrapperKey]
+	if resp.ChallengeURL != "http://ctf.alice.101.devleo.local" {
+		t.Errorf("Expected challenge_url http://ctf.alice.101.devleo.local, got %q", resp.ChallengeURL)
+	}
+	if resp.TerminalURL != "http://ctf.alice.101.devleo.local/terminal" {
+		t.Errorf("Expected terminal_url http://ctf.alice.101.devleo.local/terminal, got %q", resp.TerminalURL)
+	}
+	if resp.ConnectionInfo != instance.Status.ConnectionInfo {
+		t.Errorf("Expected connectionInfo to be kept, got %q", resp.ConnectionInfo)
+	}
+}
+
+func TestGetInstance_ConnectionInstructions(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "101", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:                     "101",
+			Scenario:               ctfv1alpha1.ChallengeScenarioSpec{Image: "ssh-chall:latest", Port: 22},
+			ConnectionInstructions: "SSH as user ctf, the password is in /flag",
+		},
+	}
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{ConnectionInfo: "ssh ctf@10.0.0.1 -p 30022"},
+	}
+
+	h := newTestHandler(t, challenge, instance)
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/v1/instance/101/alice", nil),
+		map[string]string{"challengeId": "101", "sourceId": "alice"})
+	h.GetInstance(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp InstanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.ConnectionInstructions != challenge.Spec.ConnectionInstructions {
+		t.Errorf("Expected connection_instructions %q, got %q", challenge.Spec.ConnectionInstructions, resp.ConnectionInstructions)
+	}
+	if resp.ConnectionInfo != "ssh ctf@10.0.0.1 -p 30022" {
+		t.Errorf("Expected connectionInfo to be kept, got %q", resp.ConnectionInfo)
+	}
+}
+
+func TestGetInstance_PublicBaseURL(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+	t.Setenv("PUBLIC_BASE_URL", "https://ctf.example.com")
+
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "101", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "101",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:     "web-chall:latest",
+				Port:      80,
+				Ingress:   &ctfv1alpha1.IngressSpec{Enabled: true, HostTemplate: "{{.InstanceName}}.devleo.local"},
+				AttackBox: &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+			},
+		},
+	}
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+	}
+
+	h := newTestHandler(t, challenge, instance)
+	resp := h.buildInstanceResponse(instance)
+
+	if resp.ChallengeURL != "https://chal-101-alice.ctf.example.com" {
+		t.Errorf("Expected public challenge URL, got %q", resp.ChallengeURL)
+	}
+	if resp.TerminalURL != "https://chal-101-alice.ctf.example.com/terminal" {
+		t.Errorf("Expected public terminal URL, got %q", resp.TerminalURL)
+	}
+}
+
+func TestBuildInstanceResponse_RemainingTime(t *testing.T) {
+	until := metav1.NewTime(time.Now().Add(10 * time.Minute))
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+			Until:         &until,
+		},
+	}
+	h := newTestHandler(t, instance)
+
+	resp := h.buildInstanceResponse(instance)
+	if resp.RemainingSeconds < 595 || resp.RemainingSeconds > 600 {
+		t.Errorf("Expected about 600 remaining seconds, got %d", resp.RemainingSeconds)
+	}
+	if resp.Expired {
+		t.Error("Expected a live instance not to be expired")
+	}
+
+	// Expired but not yet reaped: clamped at zero
+	until = metav1.NewTime(time.Now().Add(-time.Minute))
+	resp = h.buildInstanceResponse(instance)
+	if resp.RemainingSeconds != 0 || !resp.Expired {
+		t.Errorf("Expected 0 remaining seconds and expired, got %d and %v", resp.RemainingSeconds, resp.Expired)
+	}
+}
+
+func TestGetInstance_ConnectionInfoFallback(t *testing.T) {
+	t.Setenv("CONNECTION_INFO_FALLBACK", "Contact an organizer about {{.InstanceName}}")
+	t.Setenv("CONNECTION_INFO_FALLBACK_GRACE", "1m")
+
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{Phase: "Running", Ready: true},
+	}
+
+	h := newTestHandler(t, instance)
+	if resp := h.buildInstanceResponse(instance); resp.ConnectionInfo != "" {
+		t.Errorf("Expected no fallback within the grace period, got %q", resp.ConnectionInfo)
+	}
+
+	instance.Spec.Since = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	if resp := h.buildInstanceResponse(instance); resp.ConnectionInfo != "Contact an organizer about chal-101-alice" {
+		t.Errorf("Expected the fallback after the grace period, got %q", resp.ConnectionInfo)
+	}
+
+	instance.Status.ConnectionInfo = "nc 192.0.2.10 31337"
+	if resp := h.buildInstanceResponse(instance); resp.ConnectionInfo != "nc 192.0.2.10 31337" {
+		t.Errorf("Expected computed connection info to win over the fallback, got %q", resp.ConnectionInfo)
+	}
+}
+
+func TestGetInstance_PortMappings(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{ServiceName: "chal-101-alice-service"},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice-service", Namespace: "ctf-instances"},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 8080, NodePort: 30080, Protocol: corev1.ProtocolTCP},
+				{Name: "dns", Port: 53, NodePort: 30053, Protocol: corev1.ProtocolUDP},
+			},
+		},
+	}
+
+	h := newTestHandler(t, instance, service)
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/v1/instance/101/alice", nil),
+		map[string]string{"challengeId": "101", "sourceId": "alice"})
+	h.GetInstance(rec, req)
+
+	var resp InstanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := []PortMapping{
+		{Name: "http", Port: 8080, NodePort: 30080, Protocol: "TCP"},
+		{Name: "dns", Port: 53, NodePort: 30053, Protocol: "UDP"},
+	}
+	if len(resp.Ports) != len(expected) {
+		t.Fatalf("Expected %d port mappings, got %+v", len(expected), resp.Ports)
+	}
+	for i, port := range expected {
+		if resp.Ports[i] != port {
+			t.Errorf("Expected port mapping %+v, got %+v", port, resp.Ports[i])
+		}
+	}
+}
+
+func TestListInstances_PortMappingsWithoutServiceGets(t *testing.T) {
+	var objs []client.Object
+	for _, source := range []string{"alice", "bob"} {
+		name := "chal-101-" + source
+		objs = append(objs,
+			&ctfv1alpha1.ChallengeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ctf-instances"},
+				Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "101", SourceID: source, ChallengeName: "101", Since: metav1.Now()},
+				Status:     ctfv1alpha1.ChallengeInstanceStatus{ServiceName: name + "-service"},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name + "-service",
+					Namespace: "ctf-instances",
+					Labels:    map[string]string{"app.kubernetes.io/managed-by": "chall-operator"},
+				},
+				Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 8080, Protocol: corev1.ProtocolTCP}}},
+			},
+		)
+	}
+	h := newTestHandler(t, objs...)
+
+	serviceGets, serviceLists := 0, 0
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.Service); ok {
+				serviceGets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*corev1.ServiceList); ok {
+				serviceLists++
+			}
+			return c.List(ctx, list, opts...)
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	h.ListInstances(rec, httptest.NewRequest(http.MethodGet, "/api/v1/instance", nil))
+
+	if serviceGets != 0 || serviceLists != 1 {
+		t.Errorf("Expected a single Service List and no Get, got %d lists and %d gets", serviceLists, serviceGets)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 instances, got %q", rec.Body.String())
+	}
+	for _, line := range lines {
+		var wrapped map[string]InstanceResponse
+		if err := json.Unmarshal([]byte(line), &wrapped); err != nil {
+			t.Fatalf("Failed to decode %q: %v", line, err)
+		}
+		for _, resp := range wrapped {
+			if len(resp.Ports) != 1 || resp.Ports[0].Port != 8080 {
+				t.Errorf("Expected the http port of %s, got %+v", resp.SourceID, resp.Ports)
+			}
+		}
+	}
+}
+
+func TestGetListWrapperKey(t *testing.T) {
+	t.Setenv("LIST_WRAPPER_KEY", "data")
+	if key := getListWrapperKey(); key != "data" {
+		t.Errorf("Expected wrapper key data, got %q", key)
+	}
+
+	t.Setenv("LIST_WRAPPER_KEY", "")
+	if key := getListWrapperKey(); key != "" {
+		t.Errorf("Expected empty wrapper key, got %q", key)
+	}
+}
+
+func TestCreateInstance_SourceQuota(t *testing.T) {
+	var objs []client.Object
+	for _, challengeID := range []string{"101", "102"} {
+		objs = append(objs, &ctfv1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "chal-" + challengeID + "-alice",
+				Namespace: "ctf-instances",
+				Labels: map[string]string{
+					"ctf.io/challenge": challengeID,
+					"ctf.io/source":    "alice",
+				},
+			},
+			Spec: ctfv1alpha1.ChallengeInstanceSpec{
+				ChallengeID:   challengeID,
+				SourceID:      "alice",
+				ChallengeName: challengeID,
+				Since:         metav1.Now(),
+			},
+		})
+	}
+
+	h := newTestHandler(t, objs...)
+	h.maxInstancesPerSource = 2
+
+	body := `{"challenge_id":"103","source_id":"alice"}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "2 running instances, limit is 2") {
+		t.Errorf("Expected count and limit in error message, got %s", rec.Body.String())
+	}
+}
+
+func TestCreateInstance_DisabledChallenge(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "101",
+			Namespace:   "ctf-instances",
+			Annotations: map[string]string{builder.DisabledAnnotation: "true"},
+		},
+		Spec: ctfv1alpha1.ChallengeSpec{ID: "101"},
+	}
+	h := newTestHandler(t, challenge)
+
+	body := `{"challenge_id":"101","source_id":"alice"}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-101-alice", Namespace: "ctf-instances"}, &ctfv1alpha1.ChallengeInstance{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Expected no instance to be created, got %v", err)
+	}
+}
+
+func TestCreateInstance_AdditionalAnnotations(t *testing.T) {
+	h := newReadyTestHandler(t)
+	h.annotatedKeys = []string{"team_name", "round"}
+
+	body := `{"challenge_id":"101","source_id":"alice","additional":{"team_name":"Root Squad","round":"2","notes":"x"}}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-101-alice", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Fatalf("Failed to get created instance: %v", err)
+	}
+
+	if instance.Annotations["ctf.io/team_name"] != "Root Squad" {
+		t.Errorf("Expected team_name annotation, got %v", instance.Annotations)
+	}
+	if instance.Annotations["ctf.io/round"] != "2" {
+		t.Errorf("Expected round annotation, got %v", instance.Annotations)
+	}
+	if _, ok := instance.Annotations["ctf.io/notes"]; ok {
+		t.Errorf("Expected unlisted key notes not to be annotated, got %v", instance.Annotations)
+	}
+}
+
+func TestCreateInstance_RequestAnnotations(t *testing.T) {
+	h := newReadyTestHandler(t)
+
+	body := `{"challenge_id":"101","source_id":"alice"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body))
+	req.Header.Set("User-Agent", "ctfd-plugin/1.4")
+	req.Header.Set(middleware.RequestIDHeader, "support-1234")
+	rec := httptest.NewRecorder()
+	middleware.RequestID(http.HandlerFunc(h.CreateInstance)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-101-alice", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Fatalf("Failed to get created instance: %v", err)
+	}
+	if instance.Annotations[RequestIDAnnotation] != "support-1234" {
+		t.Errorf("Expected request ID annotation support-1234, got %v", instance.Annotations)
+	}
+	if instance.Annotations[UserAgentAnnotation] != "ctfd-plugin/1.4" {
+		t.Errorf("Expected user agent annotation ctfd-plugin/1.4, got %v", instance.Annotations)
+	}
+}
+
+func TestGetInstance_CreatedByForAdmins(t *testing.T) {
+	t.Setenv("API_TOKENS", "ctfd=ctfd-token,ops=ops-token")
+	// Spaces around admin names are ignored, like in API_TOKENS
+	t.Setenv("API_ADMINS", "ci, ops")
+	h := newReadyTestHandler(t)
+	params := map[string]string{"challengeId": "101", "sourceId": "alice"}
+
+	serve := func(handler http.HandlerFunc, req *http.Request, token string) *httptest.ResponseRecorder {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.Authenticate(handler).ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"challenge_id":"101","source_id":"alice"}`
+	if rec := serve(h.CreateInstance, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)), "ctfd-token"); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-101-alice", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Fatalf("Failed to get created instance: %v", err)
+	}
+	if instance.Annotations[CreatedByAnnotation] != "ctfd" {
+		t.Errorf("Expected created-by annotation ctfd, got %v", instance.Annotations)
+	}
+
+	get := func(token string) (*httptest.ResponseRecorder, InstanceResponse) {
+		rec := serve(h.GetInstance, withURLParams(httptest.NewRequest(http.MethodGet, "/api/v1/instance/101/alice", nil), params), token)
+		var resp InstanceResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return rec, resp
+	}
+
+	if rec, resp := get("ops-token"); rec.Code != http.StatusOK || resp.CreatedBy != "ctfd" {
+		t.Errorf("Expected admins to see created_by ctfd, got %d %q", rec.Code, resp.CreatedBy)
+	}
+	if rec, resp := get("ctfd-token"); rec.Code != http.StatusOK || resp.CreatedBy != "" {
+		t.Errorf("Expected created_by hidden from non-admins, got %d %q", rec.Code, resp.CreatedBy)
+	}
+	for _, token := range []string{"", "wrong-token"} {
+		if rec, _ := get(token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for token %q, got %d", token, rec.Code)
+		}
+	}
+}
+
+func TestRenewInstance_RetriesOnConflict(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+			Until:         &metav1.Time{Time: time.Now().Add(time.Minute)},
+		},
+	}
+	h := newTestHandler(t, instance)
+
+	// The controller updates the instance between the handler's Get and Update
+	updates := 0
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updates++
+			if updates == 1 {
+				concurrent := &ctfv1alpha1.ChallengeInstance{}
+				if err := c.Get(ctx, client.ObjectKeyFromObject(obj), concurrent); err != nil {
+					return err
+				}
+				concurrent.Labels = map[string]string{"concurrent": "true"}
+				if err := c.Update(ctx, concurrent); err != nil {
+					return err
+				}
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	params := map[string]string{"challengeId": "101", "sourceId": "alice"}
+	h.RenewInstance(rec, withURLParams(httptest.NewRequest(http.MethodPost, "/api/v1/instance/101/alice/renew", nil), params))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if updates != 2 {
+		t.Errorf("Expected the conflicting update to be retried once, got %d updates", updates)
+	}
+
+	renewed := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKeyFromObject(instance), renewed); err != nil {
+		t.Fatalf("Failed to get renewed instance: %v", err)
+	}
+	if time.Until(renewed.Spec.Until.Time) < 9*time.Minute {
+		t.Errorf("Expected Until extended by the default 600s timeout, got %s", renewed.Spec.Until)
+	}
+	if renewed.Labels["concurrent"] != "true" {
+		t.Errorf("Expected the concurrent update to be kept, got labels %v", renewed.Labels)
+	}
+}
+
+func TestCreateInstance_SharedChallengeQuota(t *testing.T) {
+	var objs []client.Object
+	for _, id := range []string{"koth-1", "koth-2", "koth-3"} {
+		objs = append(objs, &ctfv1alpha1.Challenge{
+			ObjectMeta: metav1.ObjectMeta{Name: id, Namespace: "ctf-instances"},
+			Spec: ctfv1alpha1.ChallengeSpec{
+				ID:       id,
+				Shared:   true,
+				Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "koth:v1", Port: 80},
+			},
+		})
+	}
+	h := newTestHandler(t, objs...)
+	h.createLimiter = nil
+	h.maxInstancesPerSource = 1
+
+	body := `[
+		{"challenge_id":"koth-1","source_id":"alice"},
+		{"challenge_id":"koth-2","source_id":"bob"},
+		{"challenge_id":"koth-3","source_id":"carol"},
+		{"challenge_id":"koth-1","source_id":"dave","additional":{"team_id":"red"}},
+		{"challenge_id":"koth-2","source_id":"erin","additional":{"team_id":"red"}}
+	]`
+	rec := httptest.NewRecorder()
+	h.BulkCreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance/bulk", strings.NewReader(body)))
+
+	var results []BulkCreateResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode results %q: %v", rec.Body.String(), err)
+	}
+	// Common instances of shared challenges count against no source; team instances count against the team
+	expected := []int{http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusTooManyRequests}
+	for i, want := range expected {
+		if results[i].Status != want {
+			t.Errorf("Expected item %d to have status %d, got %+v", i, want, results[i])
+		}
+	}
+}
+
+func TestCreateInstance_SharedChallenge(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "koth", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "koth",
+			Shared:   true,
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "koth:v1", Port: 80},
+		},
+	}
+	h := newReadyTestHandler(t, challenge)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+		return rec
+	}
+
+	// Members of a team resolve to the team instance
+	rec := create(`{"challenge_id":"koth","source_id":"alice","additional":{"team_id":"red"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if location := rec.Header().Get("Location"); location != "/api/v1/instance/koth/alice?team_id=red" {
+		t.Errorf("Expected Location to keep the team, got %q", location)
+	}
+	if rec := create(`{"challenge_id":"koth","source_id":"bob","additional":{"team_id":"red"}}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected bob to get the existing team instance with 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-koth-red", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Fatalf("Expected team instance chal-koth-red: %v", err)
+	}
+	if instance.Spec.SourceID != "red" {
+		t.Errorf("Expected the team to own the instance, got source %q", instance.Spec.SourceID)
+	}
+
+	// Without a team the whole challenge shares one instance
+	if rec := create(`{"challenge_id":"koth","source_id":"carol"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-koth-shared", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Errorf("Expected challenge-wide instance chal-koth-shared: %v", err)
+	}
+
+	// GetInstance and DeleteInstance resolve the same name
+	params := map[string]string{"challengeId": "koth", "sourceId": "bob"}
+	getRec := httptest.NewRecorder()
+	h.GetInstance(getRec, withURLParams(httptest.NewRequest(http.MethodGet, "/api/v1/instance/koth/bob?team_id=red", nil), params))
+	var resp InstanceResponse
+	if err := json.Unmarshal(getRec.Body.Bytes(), &resp); err != nil || getRec.Code != http.StatusOK || resp.SourceID != "red" {
+		t.Errorf("Expected GET to return the team instance, got %d: %s", getRec.Code, getRec.Body.String())
+	}
+	delRec := httptest.NewRecorder()
+	h.DeleteInstance(delRec, withURLParams(httptest.NewRequest(http.MethodDelete, "/api/v1/instance/koth/bob?team_id=red", nil), params))
+	if delRec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on delete, got %d: %s", delRec.Code, delRec.Body.String())
+	}
+	err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-koth-red", Namespace: "ctf-instances"}, instance)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the team instance to be deleted, got %v", err)
+	}
+}
+
+func TestGetInstance_CreateIfMissing(t *testing.T) {
+	h := newReadyTestHandler(t)
+	params := map[string]string{"challengeId": "101", "sourceId": "alice"}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.GetInstance(rec, withURLParams(httptest.NewRequest(http.MethodGet, target, nil), params))
+		return rec
+	}
+
+	// Without the flag, GET stays read-only
+	if rec := get("/api/v1/instance/101/alice"); rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 without create, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := get("/api/v1/instance/101/alice?create=true")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 on create-on-get, got %d: %s", rec.Code, rec.Body.String())
+	}
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "chal-101-alice", Namespace: "ctf-instances"}, instance); err != nil {
+		t.Fatalf("Failed to get created instance: %v", err)
+	}
+
+	// Claiming again returns the same instance
+	rec = get("/api/v1/instance/101/alice?create=true")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for an existing instance, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp InstanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.ChallengeID != "101" || resp.SourceID != "alice" {
+		t.Errorf("Expected instance 101/alice, got %s/%s", resp.ChallengeID, resp.SourceID)
+	}
+}
+
+func TestGetAnnotatedAdditionalKeys(t *testing.T) {
+	t.Setenv("ANNOTATED_ADDITIONAL_KEYS", "team_name, round,,bad key")
+
+	keys := getAnnotatedAdditionalKeys()
+	if len(keys) != 2 || keys[0] != "team_name" || keys[1] != "round" {
+		t.Errorf("Expected [team_name round], got %v", keys)
+	}
+}
+
+func TestCreateInstance_StartupTimeoutHint(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "slow", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "slow",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:                 "slow:latest",
+				Port:                  1337,
+				StartupTimeoutSeconds: 1,
+			},
+		},
+	}
+	h := newTestHandler(t, challenge)
+	h.readyPollAttempts = 2
+
+	// The instance only becomes ready after more polls than the global budget allows
+	polls := 0
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := c.Get(ctx, key, obj, opts...); err != nil {
+				return err
+			}
+			if instance, ok := obj.(*ctfv1alpha1.ChallengeInstance); ok {
+				if polls++; polls >= 5 {
+					instance.Status.Ready = true
+					instance.Status.ConnectionInfo = "nc slow 1337"
+				}
+			}
+			return nil
+		},
+	})
+
+	body := `{"challenge_id":"slow","source_id":"alice"}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp InstanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.ConnectionInfo != "nc slow 1337" {
+		t.Errorf("Expected ready connection info, got %q", resp.ConnectionInfo)
+	}
+}
+
+func TestReadyPollTimeout(t *testing.T) {
+	h := &Handler{readyPollAttempts: 60, readyPollInterval: time.Second}
+
+	tests := []struct {
+		startup int32
+		want    time.Duration
+	}{
+		{0, 60 * time.Second},
+		{30, 60 * time.Second},
+		{120, 120 * time.Second},
+	}
+
+	for _, tt := range tests {
+		challenge := &ctfv1alpha1.Challenge{}
+		challenge.Spec.Scenario.StartupTimeoutSeconds = tt.startup
+		if got := h.readyPollTimeout(challenge); got != tt.want {
+			t.Errorf("Expected %s timeout for startup %ds, got %s", tt.want, tt.startup, got)
+		}
+	}
+}
+
+func TestPollBackoffSchedule(t *testing.T) {
+	ms := time.Millisecond
+	schedule := pollBackoffSchedule(100*ms, 1000*ms, 3000*ms)
+
+	// 100+200+400+800 = 1500ms, then capped at 1s, last delay trimmed to the timeout
+	expected := []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, 1000 * ms, 500 * ms}
+	if len(schedule) != len(expected) {
+		t.Fatalf("Expected schedule %v, got %v", expected, schedule)
+	}
+	for i := range expected {
+		if schedule[i] != expected[i] {
+			t.Errorf("Expected delay %d to be %s, got %s", i, expected[i], schedule[i])
+		}
+	}
+
+	// Equal initial and max delays give the flat schedule
+	flat := pollBackoffSchedule(time.Second, time.Second, 60*time.Second)
+	if len(flat) != 60 {
+		t.Errorf("Expected 60 flat polls, got %d", len(flat))
+	}
+}
+
+func TestCreateInstance_LocationHeader(t *testing.T) {
+	h := newReadyTestHandler(t)
+
+	body := `{"challenge_id":"101","source_id":"alice@ctf.local"}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	location := rec.Header().Get("Location")
+	if location != "/api/v1/instance/101/alice@ctf.local" {
+		t.Fatalf("Expected Location /api/v1/instance/101/alice@ctf.local, got %q", location)
+	}
+
+	// The Location must resolve to the GetInstance route
+	router := chi.NewRouter()
+	router.Get("/api/v1/instance/{challengeId}/{sourceId}", h.GetInstance)
+	getRec := httptest.NewRecorder()
+	router.ServeHTTP(getRec, httptest.NewRequest(http.MethodGet, location, nil))
+
+	if getRec.Code != http.StatusOK {
+		t.Errorf("Expected GET %s to return 200, got %d: %s", location, getRec.Code, getRec.Body.String())
+	}
+}
+
+func TestCreateInstance_LookupErrorUnavailable(t *testing.T) {
+	h := newTestHandler(t)
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*ctfv1alpha1.ChallengeInstance); ok {
+				return apierrors.NewServiceUnavailable("etcd leader changed")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			t.Errorf("Expected no Create after a failed lookup")
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+
+	body := `{"challenge_id":"web","source_id":"alice"}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreateInstance_CreateRaceConflict(t *testing.T) {
+	h := newTestHandler(t)
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			return apierrors.NewAlreadyExists(schema.GroupResource{Group: "ctf.ctf.io", Resource: "challengeinstances"}, obj.GetName())
+		},
+	})
+
+	body := `{"challenge_id":"web","source_id":"alice"}`
+	rec := httptest.NewRecorder()
+	h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestValidateFlag_HashedFlags(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-web-alice", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "web", SourceID: "alice"},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{
+			FlagSalt:   "pepper",
+			FlagHashes: []string{flaggen.HashFlag("FLAG{hashed}", "pepper")},
+		},
+	}
+	h := newTestHandler(t, instance)
+
+	params := map[string]string{"challengeId": "web", "sourceId": "alice"}
+	submit := func(flag string) int {
+		body := `{"flag":"` + flag + `"}`
+		req := withURLParams(httptest.NewRequest(http.MethodPost, "/api/v1/instance/web/alice/validate", strings.NewReader(body)), params)
+		rec := httptest.NewRecorder()
+		h.ValidateFlag(rec, req)
+		return rec.Code
+	}
+
+	if code := submit("FLAG{wrong}"); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a wrong flag, got %d", code)
+	}
+	if code := submit("FLAG{hashed}"); code != http.StatusOK {
+		t.Errorf("Expected status 200 for the hashed flag, got %d", code)
+	}
+}
+
+func TestDeleteInstance_PropagationPolicy(t *testing.T) {
+	t.Setenv("DELETE_PROPAGATION_POLICY", "Foreground")
+
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-web-alice", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "web", SourceID: "alice"},
+	}
+	h := newTestHandler(t, instance)
+
+	var policy *metav1.DeletionPropagation
+	h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleteOpts := &client.DeleteOptions{}
+			deleteOpts.ApplyOptions(opts)
+			policy = deleteOpts.PropagationPolicy
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	req := withURLParams(httptest.NewRequest(http.MethodDelete, "/api/v1/instance/web/alice", nil),
+		map[string]string{"challengeId": "web", "sourceId": "alice"})
+	rec := httptest.NewRecorder()
+	h.DeleteInstance(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if policy == nil || *policy != metav1.DeletePropagationForeground {
+		t.Errorf("Expected Foreground propagation policy, got %v", policy)
+	}
+}
+
+func TestDeleteSourceInstances(t *testing.T) {
+	sourceInstance := func(name, challengeID, sourceID, label string) *ctfv1alpha1.ChallengeInstance {
+		return &ctfv1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ctf-instances",
+				Labels:    map[string]string{"ctf.io/source": label},
+			},
+			Spec: ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: challengeID, SourceID: sourceID},
+		}
+	}
+	h := newTestHandler(t,
+		sourceInstance("chal-web-alice", "web", "alice", "alice"),
+		sourceInstance("chal-pwn-alice", "pwn", "alice", "alice"),
+		sourceInstance("chal-web-bob", "web", "bob", "bob"),
+		// Another source sharing alice's sanitized label
+		sourceInstance("chal-web-alice-2", "web", "Alice", "alice"),
+	)
+
+	// An empty source must not select every instance
+	rec := httptest.NewRecorder()
+	h.DeleteSourceInstances(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/instance?source_id=", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without source_id, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.DeleteSourceInstances(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/instance?source_id=alice", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Deleted != 2 {
+		t.Errorf("Expected 2 deleted instances, got %d", body.Deleted)
+	}
+
+	remaining := &ctfv1alpha1.ChallengeInstanceList{}
+	if err := h.client.List(context.Background(), remaining); err != nil {
+		t.Fatalf("Failed to list instances: %v", err)
+	}
+	names := map[string]bool{}
+	for _, instance := range remaining.Items {
+		names[instance.Name] = true
+	}
+	if len(names) != 2 || !names["chal-web-bob"] || !names["chal-web-alice-2"] {
+		t.Errorf("Expected only chal-web-bob and chal-web-alice-2 to remain, got %v", names)
+	}
+}
+
+func TestGetErrors_StatusByErrorType(t *testing.T) {
+	gr := schema.GroupResource{Group: "ctf.ctf.io", Resource: "challengeinstances"}
+	errs := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"not found", apierrors.NewNotFound(gr, "chal-101-alice"), http.StatusNotFound},
+		{"API server unavailable", apierrors.NewServiceUnavailable("etcd leader changed"), http.StatusServiceUnavailable},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, http.StatusServiceUnavailable},
+		{"forbidden", apierrors.NewForbidden(gr, "chal-101-alice", errors.New("rbac")), http.StatusInternalServerError},
+	}
+	params := map[string]string{"challengeId": "101", "sourceId": "alice"}
+	handlers := []struct {
+		name   string
+		call   func(h *Handler) http.HandlerFunc
+		method string
+		body   string
+	}{
+		{"GetInstance", func(h *Handler) http.HandlerFunc { return h.GetInstance }, http.MethodGet, ""},
+		{"DeleteInstance", func(h *Handler) http.HandlerFunc { return h.DeleteInstance }, http.MethodDelete, ""},
+		{"ValidateFlag", func(h *Handler) http.HandlerFunc { return h.ValidateFlag }, http.MethodPost, `{"flag":"FLAG{x}"}`},
+		{"RenewInstance", func(h *Handler) http.HandlerFunc { return h.RenewInstance }, http.MethodPost, ""},
+		{"GetChallenge", func(h *Handler) http.HandlerFunc { return h.GetChallenge }, http.MethodGet, ""},
+	}
+
+	for _, tc := range errs {
+		for _, handler := range handlers {
+			h := newTestHandler(t)
+			h.client = interceptor.NewClient(h.client.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					return tc.err
+				},
+			})
+
+			req := withURLParams(httptest.NewRequest(handler.method, "/api/v1/instance/101/alice", strings.NewReader(handler.body)), params)
+			rec := httptest.NewRecorder()
+			handler.call(h)(rec, req)
+
+			if rec.Code != tc.expected {
+				t.Errorf("%s with %s: expected status %d, got %d: %s", handler.name, tc.name, tc.expected, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestCreateChallenge_NonGitOps(t *testing.T) {
+	create := func(h *Handler, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.CreateChallenge(rec, httptest.NewRequest(http.MethodPost, "/api/v1/challenge", strings.NewReader(body)))
+		return rec
+	}
+	body := `{"id":"web1","scenario":"nginx:alpine","timeout":"10m","destroy_on_flag":false}`
+
+	// GitOps mode (default) never creates the Challenge
+	if rec := create(newTestHandler(t), body); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 in GitOps mode, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	t.Setenv("GITOPS_MODE", "false")
+	h := newTestHandler(t)
+
+	rec := create(h, body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp ChallengeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.ID != "web1" || resp.Scenario != "nginx:alpine" || resp.Timeout != 600 {
+		t.Errorf("Expected web1/nginx:alpine/600, got %+v", resp)
+	}
+
+	challenge := &ctfv1alpha1.Challenge{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Name: "web1", Namespace: "ctf-instances"}, challenge); err != nil {
+		t.Fatalf("Expected Challenge web1 to be created: %v", err)
+	}
+	if challenge.Spec.Scenario.Port != 80 || challenge.Spec.Scenario.ExposeType != "NodePort" {
+		t.Errorf("Expected default port 80 and NodePort, got %d and %s", challenge.Spec.Scenario.Port, challenge.Spec.Scenario.ExposeType)
+	}
+	if challenge.Spec.DestroyOnFlag == nil || *challenge.Spec.DestroyOnFlag {
+		t.Errorf("Expected destroy_on_flag false to be persisted, got %v", challenge.Spec.DestroyOnFlag)
+	}
+
+	// Creating again returns the existing Challenge
+	if rec := create(h, body); rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for an existing challenge, got %d", rec.Code)
+	}
+
+	for _, invalid := range []string{`{"scenario":"nginx:alpine"}`, `{"id":"Web_1","scenario":"nginx:alpine"}`, `{"id":"web2"}`} {
+		if rec := create(h, invalid); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", invalid, rec.Code)
+		}
+	}
+}
+
+func TestFlexibleInt64_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`600`, 600},
+		{`"600"`, 600},
+		{`"45s"`, 45},
+		{`"10m"`, 600},
+		{`"5h"`, 18000},
+		{`"1h30m"`, 5400},
+		{`"2m30s"`, 150},
+		{`"0s"`, 0},
+	}
+
+	for _, tt := range tests {
+		var f FlexibleInt64
+		if err := json.Unmarshal([]byte(tt.input), &f); err != nil {
+			t.Errorf("Unmarshal(%s) returned error: %v", tt.input, err)
+			continue
+		}
+		if int64(f) != tt.expected {
+			t.Errorf("Unmarshal(%s) = %d, expected %d", tt.input, f, tt.expected)
+		}
+	}
+}
+
+func TestFlexibleInt64_UnmarshalJSONRejected(t *testing.T) {
+	// Sub-second and malformed values used to be silently truncated by suffix stripping
+	for _, input := range []string{`"5ms"`, `"1500ms"`, `"5hm"`, `"ten"`, `"-1m"`, `""`, `true`} {
+		var f FlexibleInt64
+		if err := json.Unmarshal([]byte(input), &f); err == nil {
+			t.Errorf("Expected Unmarshal(%s) to fail, got %d", input, f)
+		}
+	}
+}
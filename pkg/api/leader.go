@@ -0,0 +1,58 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"os"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// leaderElectionID is the Lease held by the leading operator replica, matching the manager LeaderElectionID
+const leaderElectionID = "ce13542a.ctf.io"
+
+// getLeaderElectionNamespace returns the namespace of the operator leader election Lease from env or fallback
+func getLeaderElectionNamespace() string {
+	if ns := os.Getenv("LEADER_ELECTION_NAMESPACE"); ns != "" {
+		return ns
+	}
+	return "chall-operator-system"
+}
+
+// controllerLeader returns the identity of the operator replica currently holding the leader Lease
+// Empty when the Lease can't be read, has no holder or wasn't renewed within its duration
+func (h *Handler) controllerLeader(ctx context.Context) string {
+	if h.client == nil || h.leaderNamespace == "" {
+		return ""
+	}
+
+	lease := &coordinationv1.Lease{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: leaderElectionID, Namespace: h.leaderNamespace}, lease); err != nil {
+		return ""
+	}
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return ""
+	}
+	if time.Since(spec.RenewTime.Time) > time.Duration(*spec.LeaseDurationSeconds)*time.Second {
+		return ""
+	}
+	return *spec.HolderIdentity
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is an in-memory fixed-window limiter keyed by an arbitrary string
+// State is per gateway replica and is lost on restart, which is acceptable for throttling
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	now     func() time.Time
+	windows map[string]*rateWindow
+}
+
+// rateWindow tracks the attempts made for a key in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// newRateLimiter creates a limiter allowing limit attempts per window for each key
+// A limit <= 0 disables limiting
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Allow records an attempt for key and reports whether it is within the limit
+func (l *rateLimiter) Allow(key string) bool {
+	if l == nil || l.limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		// Drop expired windows so the map doesn't grow with every key ever seen
+		for k, old := range l.windows {
+			if now.Sub(old.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.windows[key] = &rateWindow{start: now, count: 1}
+		return true
+	}
+
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// tokenBucketLimiter is an in-memory token bucket limiter keyed by an arbitrary string
+// Each key holds up to burst tokens, refilled continuously at rate tokens per second
+type tokenBucketLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	now     func() time.Time
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket tracks the tokens left for a key at the last refill
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucketLimiter creates a limiter refilling rate tokens per second up to burst for each key
+// A rate <= 0 disables limiting
+func newTokenBucketLimiter(rate float64, burst int) *tokenBucketLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucketLimiter{
+		rate:    rate,
+		burst:   burst,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Reserve takes a token for key and reports whether one was available
+// When it wasn't, it also returns how long until the next token is refilled
+func (l *tokenBucketLimiter) Reserve(key string) (bool, time.Duration) {
+	if l == nil || l.rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		// Drop buckets that refilled completely so the map doesn't grow with every key ever seen
+		for k, old := range l.buckets {
+			if old.tokens+now.Sub(old.last).Seconds()*l.rate >= float64(l.burst) {
+				delete(l.buckets, k)
+			}
+		}
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(3, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow("chall-1/alice") {
+			t.Fatalf("Expected attempt %d to be allowed", i+1)
+		}
+	}
+	if l.Allow("chall-1/alice") {
+		t.Error("Expected 4th attempt within the window to be rejected")
+	}
+
+	// Other keys have their own budget
+	if !l.Allow("chall-1/bob") {
+		t.Error("Expected a different source to be allowed")
+	}
+
+	// A new window resets the budget
+	now = now.Add(time.Minute)
+	if !l.Allow("chall-1/alice") {
+		t.Error("Expected attempt in a new window to be allowed")
+	}
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	l := newRateLimiter(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		if !l.Allow("key") {
+			t.Fatal("Expected disabled limiter to allow every attempt")
+		}
+	}
+}
+
+func TestValidateFlag_RateLimited(t *testing.T) {
+	h := &Handler{flagLimiter: newRateLimiter(1, time.Minute)}
+	h.flagLimiter.Allow("101/alice")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/instance/101/alice/validate", nil)
+	req = withURLParams(req, map[string]string{"challengeId": "101", "sourceId": "alice"})
+	rec := httptest.NewRecorder()
+
+	h.ValidateFlag(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", rec.Code)
+	}
+}
+
+func TestTokenBucketLimiter_Reserve(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newTokenBucketLimiter(0.5, 2)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Reserve("alice"); !ok {
+			t.Fatalf("Expected attempt %d within the burst to be allowed", i+1)
+		}
+	}
+	ok, retryAfter := l.Reserve("alice")
+	if ok {
+		t.Fatal("Expected attempt beyond the burst to be rejected")
+	}
+	if retryAfter != 2*time.Second {
+		t.Errorf("Expected retry after 2s, got %s", retryAfter)
+	}
+
+	// Other keys have their own bucket
+	if ok, _ := l.Reserve("bob"); !ok {
+		t.Error("Expected a different source to be allowed")
+	}
+
+	// Tokens refill at the configured rate
+	now = now.Add(2 * time.Second)
+	if ok, _ := l.Reserve("alice"); !ok {
+		t.Error("Expected attempt after a refill to be allowed")
+	}
+}
+
+func TestCreateInstance_RateLimited(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+
+	h := &Handler{createLimiter: newTokenBucketLimiter(1.0/60, 2)}
+	// The invalid hostname makes allowed requests fail fast, after the rate limit check
+	body := `{"challenge_id":"101","source_id":"alice","additional":{"hostname":"evil.example.com"}}`
+
+	var codes []int
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		rec = httptest.NewRecorder()
+		h.CreateInstance(rec, httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body)))
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] == http.StatusTooManyRequests || codes[1] == http.StatusTooManyRequests {
+		t.Fatalf("Expected the first 2 creates within the burst to pass the limiter, got %v", codes)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 once the burst is spent, got %d", codes[2])
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", retryAfter)
+	}
+}
+
+func TestCreateInstance_RateLimitedByClientIP(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := &Handler{createLimiter: newTokenBucketLimiter(1.0/60, 1)}
+	h.createLimiter.now = func() time.Time { return now }
+
+	post := func(body, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/instance", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.CreateInstance(rec, req)
+		return rec
+	}
+
+	// Without a source, requests are keyed by client IP, whatever the port
+	if rec := post(`{"challenge_id":"101"}`, "198.51.100.7:40000"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 within the burst, got %d", rec.Code)
+	}
+	rec := post(`not json`, "198.51.100.7:40001")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 once the client IP spent its burst, got %d", rec.Code)
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", retryAfter)
+	}
+
+	// Other clients have their own bucket
+	if rec := post(`{"challenge_id":"101"}`, "203.0.113.9:40000"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected another client IP to pass the limiter, got %d", rec.Code)
+	}
+
+	// The bucket refills with the fake clock
+	now = now.Add(time.Minute)
+	if rec := post(`{"challenge_id":"101"}`, "198.51.100.7:40002"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected the client IP to pass the limiter after a refill, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// SchemaField describes one field of the Challenge spec for form rendering
+type SchemaField struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Required bool          `json:"required"`
+	Fields   []SchemaField `json:"fields,omitempty"`
+}
+
+// ChallengeSchemaResponse is the simplified field list of the Challenge spec
+type ChallengeSchemaResponse struct {
+	Kind   string        `json:"kind"`
+	Fields []SchemaField `json:"fields"`
+}
+
+// challengeSchema is derived from the Go types, so it follows the CRD as fields are added
+var challengeSchema = ChallengeSchemaResponse{
+	Kind:   "Challenge",
+	Fields: schemaFields(reflect.TypeOf(ctfv1alpha1.ChallengeSpec{})),
+}
+
+// GetChallengeSchema handles GET /api/v1/challenge/schema
+func (h *Handler) GetChallengeSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := newJSONEncoder(w, r).Encode(challengeSchema); err != nil {
+		slog.Error("handlers: encode challenge schema", "error", err)
+	}
+}
+
+// schemaFields lists the JSON fields of a struct type
+// Nested fields are only expanded for operator types; Kubernetes types are reported as opaque objects
+func schemaFields(t reflect.Type) []SchemaField {
+	fields := []SchemaField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "" || tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		field := SchemaField{
+			Name:     name,
+			Required: !strings.Contains(opts, "omitempty"),
+		}
+		field.Type, field.Fields = schemaType(f.Type)
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// schemaType maps a Go type to a JSON schema type name and, for operator structs, its fields
+func schemaType(t reflect.Type) (string, []SchemaField) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer", nil
+	case reflect.Slice:
+		_, items := schemaType(t.Elem())
+		return "array", items
+	case reflect.Map:
+		return "object", nil
+	case reflect.Struct:
+		if t.PkgPath() == reflect.TypeOf(ctfv1alpha1.ChallengeSpec{}).PkgPath() {
+			return "object", schemaFields(t)
+		}
+		// resource.Quantity serializes as a string ("1Gi")
+		if t.Name() == "Quantity" {
+			return "string", nil
+		}
+		return "object", nil
+	}
+	return t.Kind().String(), nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func findSchemaField(fields []SchemaField, name string) *SchemaField {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func TestGetChallengeSchema_ScenarioFields(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.GetChallengeSchema(rec, httptest.NewRequest(http.MethodGet, "/api/v1/challenge/schema", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	var schema ChallengeSchemaResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+
+	scenario := findSchemaField(schema.Fields, "scenario")
+	if scenario == nil {
+		t.Fatalf("Expected a scenario field, got %+v", schema.Fields)
+	}
+	if !scenario.Required || scenario.Type != "object" {
+		t.Errorf("Expected scenario to be a required object, got %+v", scenario)
+	}
+
+	expected := map[string]string{
+		"image":        "string",
+		"port":         "integer",
+		"exposeType":   "string",
+		"flagTemplate": "string",
+		"attackBox":    "object",
+		"volumes":      "array",
+	}
+	for name, typ := range expected {
+		field := findSchemaField(scenario.Fields, name)
+		if field == nil {
+			t.Errorf("Expected scenario field %s", name)
+			continue
+		}
+		if field.Type != typ {
+			t.Errorf("Expected %s to be %s, got %s", name, typ, field.Type)
+		}
+	}
+
+	if image := findSchemaField(scenario.Fields, "image"); image != nil && !image.Required {
+		t.Errorf("Expected image to be required")
+	}
+	if attackBox := findSchemaField(scenario.Fields, "attackBox"); attackBox != nil && findSchemaField(attackBox.Fields, "enabled") == nil {
+		t.Errorf("Expected attackBox to list its nested fields, got %+v", attackBox.Fields)
+	}
+}
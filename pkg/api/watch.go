@@ -0,0 +1,162 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// Server-sent event names of the instance watch stream
+const (
+	WatchEventStatus  = "status"
+	WatchEventDeleted = "deleted"
+)
+
+// watchHeartbeatInterval keeps idle watch streams open through proxies
+const watchHeartbeatInterval = 15 * time.Second
+
+// WatchInstance godoc
+// @Summary Stream the status of a challenge instance
+// @Description Server-sent events pushing the instance (same body as GET) on every change, instead of polling.
+// @Description The stream ends after a "status" event for a ready or failed instance, or a "deleted" event
+// @Tags instances
+// @Produce text/event-stream
+// @Param challengeId path string true "Challenge ID"
+// @Param sourceId path string true "Source ID (user/team identifier)"
+// @Success 200 {object} InstanceResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /instance/{challengeId}/{sourceId}/watch [get]
+func (h *Handler) WatchInstance(w http.ResponseWriter, r *http.Request) {
+	challengeID := chi.URLParam(r, "challengeId")
+	sourceID := chi.URLParam(r, "sourceId")
+
+	if challengeID == "" || sourceID == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Missing path parameters", "challengeId and sourceId are required")
+		return
+	}
+
+	watcher, ok := h.client.(client.WithWatch)
+	if !ok {
+		h.writeError(w, r, http.StatusNotImplemented, "Watch not supported", "the gateway client cannot watch instances")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, r, http.StatusInternalServerError, "Streaming not supported", "the response writer cannot flush")
+		return
+	}
+
+	instanceName, err := h.resolveInstanceName(r, challengeID, sourceID)
+	if err != nil {
+		h.writeGetError(w, r, "Challenge not found", err)
+		return
+	}
+
+	// Watch before reading the current state so no change falls in between
+	events, err := watcher.Watch(r.Context(), &ctfv1alpha1.ChallengeInstanceList{},
+		client.InNamespace(h.namespace),
+		client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", instanceName)})
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to watch instance", err.Error())
+		return
+	}
+	defer events.Stop()
+
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(r.Context(), types.NamespacedName{Name: instanceName, Namespace: h.namespace}, instance); err != nil {
+		h.writeGetError(w, r, "Instance not found", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx would otherwise buffer the stream
+	w.WriteHeader(http.StatusOK)
+
+	if !h.sendInstanceEvent(w, flusher, instance) || watchDone(instance) {
+		return
+	}
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events.ResultChan():
+			if !ok {
+				// The API server closed the watch; clients reconnect and get the current state first
+				return
+			}
+			changed, isInstance := event.Object.(*ctfv1alpha1.ChallengeInstance)
+			if !isInstance || changed.Name != instanceName {
+				continue
+			}
+			switch event.Type {
+			case watch.Deleted:
+				writeSSE(w, flusher, WatchEventDeleted, map[string]string{"challenge_id": challengeID, "source_id": sourceID})
+				return
+			case watch.Added, watch.Modified:
+				if !h.sendInstanceEvent(w, flusher, changed) || watchDone(changed) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// watchDone reports whether an instance reached a state ending its watch stream
+func watchDone(instance *ctfv1alpha1.ChallengeInstance) bool {
+	return instance.Status.Ready || instance.Status.Phase == "Failed"
+}
+
+// sendInstanceEvent writes the instance as a status event, false once the client is gone
+func (h *Handler) sendInstanceEvent(w http.ResponseWriter, flusher http.Flusher, instance *ctfv1alpha1.ChallengeInstance) bool {
+	return writeSSE(w, flusher, WatchEventStatus, h.buildInstanceResponse(instance))
+}
+
+// writeSSE writes one server-sent event with a JSON payload, false once the client is gone
+func writeSSE(w http.ResponseWriter, flusher http.Flusher, event string, payload interface{}) bool {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("handlers: encode watch event", "event", event, "error", err)
+		return false
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return false
+	}
+	flusher.Flush()
+	return true
+}
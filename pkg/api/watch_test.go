@@ -0,0 +1,130 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// readSSE reads the next server-sent event, skipping keepalive comments
+func readSSE(t *testing.T, reader *bufio.Reader) (event string, data string) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected an event, got %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && event != "":
+			return event, data
+		}
+	}
+}
+
+func TestWatchInstance(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID:   "101",
+			SourceID:      "alice",
+			ChallengeName: "101",
+			Since:         metav1.Now(),
+		},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{Phase: "Pending"},
+	}
+	h := newTestHandler(t, instance)
+
+	router := chi.NewRouter()
+	router.Get("/api/v1/instance/{challengeId}/{sourceId}/watch", h.WatchInstance)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/instance/101/alice/watch")
+	if err != nil {
+		t.Fatalf("Failed to open the watch stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected a 200 event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	event, data := readSSE(t, reader)
+	var status InstanceResponse
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		t.Fatalf("Failed to decode event data %q: %v", data, err)
+	}
+	if event != WatchEventStatus || status.Phase != "Pending" {
+		t.Errorf("Expected the current Pending status first, got %s %+v", event, status)
+	}
+
+	// The controller marks the instance Running
+	current := &ctfv1alpha1.ChallengeInstance{}
+	if err := h.client.Get(context.Background(), client.ObjectKeyFromObject(instance), current); err != nil {
+		t.Fatalf("Failed to get instance: %v", err)
+	}
+	current.Status.Phase = "Running"
+	current.Status.Ready = true
+	current.Status.ConnectionInfo = "nc 10.0.0.1 31337"
+	if err := h.client.Status().Update(context.Background(), current); err != nil {
+		t.Fatalf("Failed to update instance status: %v", err)
+	}
+
+	event, data = readSSE(t, reader)
+	status = InstanceResponse{}
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		t.Fatalf("Failed to decode event data %q: %v", data, err)
+	}
+	if event != WatchEventStatus || status.Phase != "Running" || status.ConnectionInfo != "nc 10.0.0.1 31337" {
+		t.Errorf("Expected the Running status with connection info, got %s %+v", event, status)
+	}
+
+	// A ready instance ends the stream
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Error("Expected the stream to close once the instance is ready")
+	}
+}
+
+func TestWatchInstance_NotFound(t *testing.T) {
+	h := newTestHandler(t)
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/v1/instance/101/nobody/watch", nil),
+		map[string]string{"challengeId": "101", "sourceId": "nobody"})
+	rec := httptest.NewRecorder()
+
+	h.WatchInstance(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
@@ -41,7 +41,7 @@ func BuildAttackBoxDeployment(
 	attackBoxName := AttackBoxDeploymentName(instance)
 	username := SanitizeForLabel(instance.Spec.SourceID)
 
-	labels := map[string]string{
+	labels := withBillingLabel(map[string]string{
 		"app":                          attackBoxName,
 		"component":                    "attackbox",
 		"ctf.io/challenge":             instance.Spec.ChallengeID,
@@ -50,7 +50,7 @@ func BuildAttackBoxDeployment(
 		"app.kubernetes.io/name":       "attackbox",
 		"app.kubernetes.io/instance":   instance.Name,
 		"app.kubernetes.io/managed-by": "chall-operator",
-	}
+	}, instance)
 
 	// AttackBox image and port
 	attackBoxImage := "attack-box:latest"
@@ -64,7 +64,7 @@ func BuildAttackBoxDeployment(
 	}
 
 	// Challenge service DNS name for attackbox to connect to
-	challengeSvcDNS := fmt.Sprintf("%s.%s.svc.cluster.local", ServiceName(instance), instance.Namespace)
+	challengeSvcDNS := ServiceHost(instance)
 
 	containers := []corev1.Container{}
 
@@ -78,7 +78,7 @@ func BuildAttackBoxDeployment(
 		authProxyContainer := corev1.Container{
 			Name:            "auth-proxy-attackbox",
 			Image:           authProxyImage,
-			ImagePullPolicy: corev1.PullIfNotPresent,
+			ImagePullPolicy: imagePullPolicy(authProxyImage, ""),
 			Env: []corev1.EnvVar{
 				{
 					Name:  "ALLOWED_USER",
@@ -109,7 +109,7 @@ func BuildAttackBoxDeployment(
 	attackBoxContainer := corev1.Container{
 		Name:            "attackbox",
 		Image:           attackBoxImage,
-		ImagePullPolicy: corev1.PullIfNotPresent,
+		ImagePullPolicy: imagePullPolicy(attackBoxImage, challenge.Spec.Scenario.AttackBox.ImagePullPolicy),
 		Env: []corev1.EnvVar{
 			{
 				Name:  "PS1",
@@ -119,6 +119,11 @@ func BuildAttackBoxDeployment(
 				Name:  "CHALLENGE_HOST",
 				Value: challengeSvcDNS,
 			},
+			{
+				// The Service port to dial on CHALLENGE_HOST, forwarded to the challenge port
+				Name:  "CHALLENGE_PORT",
+				Value: fmt.Sprintf("%d", challengeServicePort),
+			},
 			{
 				Name:  "TTYD_PORT",
 				Value: fmt.Sprintf("%d", ttydPort),
@@ -143,41 +148,53 @@ func BuildAttackBoxDeployment(
 				Protocol:      corev1.ProtocolTCP,
 			},
 		},
-		Resources: challenge.Spec.Scenario.AttackBox.Resources,
+		Resources:    challenge.Spec.Scenario.AttackBox.Resources,
+		VolumeMounts: []corev1.VolumeMount{instanceInfoVolumeMount()},
 		SecurityContext: &corev1.SecurityContext{
 			RunAsNonRoot:             ptr.To(true),
 			RunAsUser:                ptr.To(int64(1000)),
 			AllowPrivilegeEscalation: ptr.To(false),
 		},
 	}
+	// Players use the attack box: the flag only goes in on purpose
+	if challenge.Spec.Scenario.AttackBox.InjectFlag {
+		if flagEnv := flagEnvVar(instance, DefaultFlagEnv); flagEnv != nil {
+			attackBoxContainer.Env = append(attackBoxContainer.Env, *flagEnv)
+		}
+	}
 	containers = append(containers, attackBoxContainer)
 
-	return &appsv1.Deployment{
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      attackBoxName,
-			Namespace: instance.Namespace,
+			Namespace: TargetNamespace(instance),
 			Labels:    labels,
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: ptr.To(int32(1)),
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": attackBoxName,
-				},
+				MatchLabels: AttackBoxSelectorLabels(instance),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels: podTemplateLabels(labels),
 				},
 				Spec: corev1.PodSpec{
-					Containers:    containers,
-					RestartPolicy: corev1.RestartPolicyAlways,
+					Containers:       containers,
+					Volumes:          []corev1.Volume{instanceInfoVolume(instance)},
+					RestartPolicy:    corev1.RestartPolicyAlways,
+					ImagePullSecrets: imagePullSecrets(challenge),
 				},
 			},
 		},
 	}
+	applyScheduling(&deployment.Spec.Template.Spec, challenge)
+	return deployment
 }
 
+// attackBoxServicePort is the attackbox Service port, forwarded to ttyd or its auth-proxy
+const attackBoxServicePort int32 = 8080
+
 // BuildAttackBoxService creates a Service for the AttackBox
 func BuildAttackBoxService(
 	instance *ctfv1alpha1.ChallengeInstance,
@@ -205,23 +222,21 @@ func BuildAttackBoxService(
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceName,
-			Namespace: instance.Namespace,
-			Labels: map[string]string{
+			Namespace: TargetNamespace(instance),
+			Labels: withBillingLabel(map[string]string{
 				"app":                          attackBoxName,
 				"component":                    "attackbox",
 				"ctf.io/challenge":             instance.Spec.ChallengeID,
 				"ctf.io/instance":              instance.Name,
 				"app.kubernetes.io/managed-by": "chall-operator",
-			},
+			}, instance),
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: map[string]string{
-				"app": attackBoxName,
-			},
+			Selector: AttackBoxSelectorLabels(instance),
 			Ports: []corev1.ServicePort{
 				{
 					Name:       "http",
-					Port:       8080,
+					Port:       attackBoxServicePort,
 					Protocol:   corev1.ProtocolTCP,
 					TargetPort: intstr.FromInt32(serviceTargetPort),
 				},
@@ -0,0 +1,113 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// Compile-time check of the BuildAttackBoxDeployment signature
+var _ func(*ctfv1alpha1.ChallengeInstance, *ctfv1alpha1.Challenge) *appsv1.Deployment = BuildAttackBoxDeployment
+
+func TestBuildAttackBoxDeployment_Disabled(t *testing.T) {
+	instance, challenge := newTestObjects()
+
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: false}
+	if deploy := BuildAttackBoxDeployment(instance, challenge); deploy != nil {
+		t.Errorf("Expected nil deployment when attackbox is disabled, got %s", deploy.Name)
+	}
+
+	challenge.Spec.Scenario.AttackBox = nil
+	if deploy := BuildAttackBoxDeployment(instance, challenge); deploy != nil {
+		t.Errorf("Expected nil deployment without attackbox spec, got %s", deploy.Name)
+	}
+}
+
+func TestBuildAttackBoxDeployment_Containers(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+
+	deploy := BuildAttackBoxDeployment(instance, challenge)
+	if deploy == nil {
+		t.Fatal("Expected attackbox deployment, got nil")
+	}
+	if deploy.Name != AttackBoxDeploymentName(instance) {
+		t.Errorf("Expected name %s, got %s", AttackBoxDeploymentName(instance), deploy.Name)
+	}
+
+	containers := deploy.Spec.Template.Spec.Containers
+	if len(containers) != 1 || containers[0].Name != "attackbox" {
+		t.Fatalf("Expected a single attackbox container, got %+v", containers)
+	}
+	if containers[0].Image != "attack-box:latest" {
+		t.Errorf("Expected default image attack-box:latest, got %s", containers[0].Image)
+	}
+
+	challenge.Spec.Scenario.AuthProxy = &ctfv1alpha1.AuthProxySpec{Enabled: true}
+	containers = BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec.Containers
+	if len(containers) != 2 {
+		t.Fatalf("Expected auth proxy sidecar and attackbox containers, got %d", len(containers))
+	}
+	if containers[0].Name != "auth-proxy-attackbox" || containers[1].Name != "attackbox" {
+		t.Errorf("Expected [auth-proxy-attackbox attackbox], got [%s %s]", containers[0].Name, containers[1].Name)
+	}
+}
+
+func TestBuildAttackBoxDeployment_ChallengePort(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+	challenge.Spec.Scenario.Port = 1337
+
+	env := map[string]string{}
+	for _, e := range BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["CHALLENGE_HOST"] != "test-instance-svc.ctf-instances.svc.cluster.local" {
+		t.Errorf("Expected CHALLENGE_HOST to be the instance Service, got %q", env["CHALLENGE_HOST"])
+	}
+	if env["CHALLENGE_PORT"] != "80" {
+		t.Errorf("Expected CHALLENGE_PORT 80 (the Service port), got %q", env["CHALLENGE_PORT"])
+	}
+}
+
+func TestBuildAttackBoxDeployment_InjectFlag(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+	instance.Status.Flags = []string{"FLAG{attackbox}"}
+
+	flagEnv := func() (string, bool) {
+		for _, e := range BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec.Containers[0].Env {
+			if e.Name == "FLAG" {
+				return e.Value, true
+			}
+		}
+		return "", false
+	}
+
+	if value, ok := flagEnv(); ok {
+		t.Errorf("Expected no FLAG in the attackbox by default, got %q", value)
+	}
+
+	challenge.Spec.Scenario.AttackBox.InjectFlag = true
+	if value, ok := flagEnv(); !ok || value != "FLAG{attackbox}" {
+		t.Errorf("Expected FLAG{attackbox} in the attackbox when injectFlag is set, got %q", value)
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DeletePropagationPolicy reads the instance delete propagation policy from DELETE_PROPAGATION_POLICY
+// Foreground waits for the instance resources to be gone, Background returns right away;
+// anything else keeps the API server default
+func DeletePropagationPolicy() metav1.DeletionPropagation {
+	switch policy := metav1.DeletionPropagation(os.Getenv("DELETE_PROPAGATION_POLICY")); policy {
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground:
+		return policy
+	}
+	return ""
+}
+
+// DeleteOptions returns the instance delete options applying a propagation policy, if any
+func DeleteOptions(policy metav1.DeletionPropagation) []client.DeleteOption {
+	if policy == "" {
+		return nil
+	}
+	return []client.DeleteOption{client.PropagationPolicy(policy)}
+}
@@ -17,34 +17,62 @@ limitations under the License.
 package builder
 
 import (
+	"crypto/sha256"
+	"encoding/base32"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/utils/ptr"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 )
 
-// SanitizeForLabel converts a string to be DNS-safe for Kubernetes labels
-// Example: "uwu@uwu.uwu" -> "uwu-at-uwu-uwu"
+// SanitizeForLabel converts a string to be DNS-safe for Kubernetes names and labels
+// Inputs that are already valid DNS labels are returned unchanged; anything else keeps a readable
+// prefix and gets a short base32 hash suffix of the original, so distinct inputs never collide
+// Example: "uwu@uwu.uwu" -> "uwu-at-uwu-uwu-<hash>"
 func SanitizeForLabel(s string) string {
-	result := strings.ReplaceAll(s, "@", "-at-")
-	result = strings.ReplaceAll(result, ".", "-")
-	result = strings.ToLower(result)
-	// Truncate to 63 chars (K8s label limit)
-	if len(result) > 63 {
-		result = result[:63]
-	}
-	return result
+	if len(validation.IsDNS1123Label(s)) == 0 {
+		return s
+	}
+
+	prefix := strings.ReplaceAll(strings.ToLower(s), "@", "-at-")
+	prefix = invalidLabelChars.ReplaceAllString(prefix, "-")
+	prefix = strings.Trim(prefix, "-")
+
+	sum := sha256.Sum256([]byte(s))
+	suffix := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:5]))
+
+	// Keep the result within the 63 chars label limit
+	if maxPrefix := validation.DNS1123LabelMaxLength - len(suffix) - 1; len(prefix) > maxPrefix {
+		prefix = strings.TrimRight(prefix[:maxPrefix], "-")
+	}
+	if prefix == "" {
+		return suffix
+	}
+	return prefix + "-" + suffix
 }
 
+// invalidLabelChars matches runs of characters not allowed in a DNS label
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
 // BuildDeployment creates a Deployment for a ChallengeInstance based on the Challenge template
 // If AuthProxy is enabled, adds a sidecar container that verifies user identity
-func BuildDeployment(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) *appsv1.Deployment {
-	labels := map[string]string{
+// Returns an error when the scenario asks for several replicas of a stateful challenge
+func BuildDeployment(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) (*appsv1.Deployment, error) {
+	replicas := ChallengeReplicas(challenge)
+	if replicas > 1 && challenge.Spec.Scenario.Persistence != nil {
+		return nil, fmt.Errorf("replicas %d: a persistent challenge runs a single replica, its ReadWriteOnce volume can't be shared", replicas)
+	}
+
+	labels := withBillingLabel(map[string]string{
 		"app":                          "challenge",
 		"ctf.io/challenge":             instance.Spec.ChallengeID,
 		"ctf.io/instance":              instance.Name,
@@ -52,35 +80,12 @@ func BuildDeployment(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1al
 		"app.kubernetes.io/name":       "challenge-instance",
 		"app.kubernetes.io/instance":   instance.Name,
 		"app.kubernetes.io/managed-by": "chall-operator",
-	}
+	}, instance)
 
-	// Copy environment variables from challenge spec
+	// Copy environment variables from challenge spec, then inject the instance metadata
 	env := make([]corev1.EnvVar, len(challenge.Spec.Scenario.Env))
 	copy(env, challenge.Spec.Scenario.Env)
-
-	// Inject flag into environment if available
-	if len(instance.Status.Flags) > 0 {
-		env = append(env, corev1.EnvVar{
-			Name:  "FLAG",
-			Value: instance.Status.Flags[0],
-		})
-	}
-
-	// Inject instance metadata as environment variables
-	env = append(env,
-		corev1.EnvVar{
-			Name:  "INSTANCE_ID",
-			Value: instance.Name,
-		},
-		corev1.EnvVar{
-			Name:  "SOURCE_ID",
-			Value: instance.Spec.SourceID,
-		},
-		corev1.EnvVar{
-			Name:  "CHALLENGE_ID",
-			Value: instance.Spec.ChallengeID,
-		},
-	)
+	env = append(env, instanceEnv(instance, challenge)...)
 
 	deploymentName := DeploymentName(instance)
 
@@ -101,7 +106,7 @@ func BuildDeployment(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1al
 		authProxyContainer := corev1.Container{
 			Name:            "auth-proxy",
 			Image:           authProxyImage,
-			ImagePullPolicy: corev1.PullIfNotPresent,
+			ImagePullPolicy: imagePullPolicy(authProxyImage, ""),
 			Env: []corev1.EnvVar{
 				{
 					Name:  "ALLOWED_USER",
@@ -128,47 +133,298 @@ func BuildDeployment(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1al
 		containers = append(containers, authProxyContainer)
 	}
 
-	// Main challenge container
+	// Main challenge container, with the operator resource defaults and cap applied
+	resources, _ := ChallengeResources(challenge)
 	challengeContainer := corev1.Container{
 		Name:            "challenge",
 		Image:           challenge.Spec.Scenario.Image,
-		ImagePullPolicy: corev1.PullIfNotPresent,
+		ImagePullPolicy: imagePullPolicy(challenge.Spec.Scenario.Image, challenge.Spec.Scenario.ImagePullPolicy),
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "challenge",
 				ContainerPort: challengePort,
-				Protocol:      corev1.ProtocolTCP,
+				Protocol:      ChallengeProtocol(challenge),
 			},
 		},
-		Env:       env,
-		Resources: challenge.Spec.Scenario.Resources,
+		Env:             env,
+		EnvFrom:         append([]corev1.EnvFromSource(nil), challenge.Spec.Scenario.EnvFrom...),
+		Resources:       resources,
+		ReadinessProbe:  BuildProbe(challenge.Spec.Scenario.ReadinessProbe, challengePort),
+		LivenessProbe:   BuildProbe(challenge.Spec.Scenario.LivenessProbe, challengePort),
+		StartupProbe:    BuildProbe(challenge.Spec.Scenario.StartupProbe, challengePort),
+		VolumeMounts:    append([]corev1.VolumeMount(nil), challenge.Spec.Scenario.VolumeMounts...),
+		SecurityContext: challengeSecurityContext(challenge),
+	}
+	// Worker-only challenges have no inbound port; only explicitly configured probes apply
+	if !ExposesService(challenge) {
+		challengeContainer.Ports = nil
+	} else if challengeContainer.ReadinessProbe == nil && ChallengeProtocol(challenge) == corev1.ProtocolTCP {
+		// Without an explicit readiness probe, wait for the challenge port to accept connections
+		// UDP ports can't be checked with a TCP probe, so they get none
+		challengeContainer.ReadinessProbe = BuildProbe(&ctfv1alpha1.ProbeSpec{Type: "TCP"}, challengePort)
+	}
+
+	// Expose the instance connection details as files
+	volumes := append(buildVolumes(challenge.Spec.Scenario.Volumes), instanceInfoVolume(instance))
+	challengeContainer.VolumeMounts = append(challengeContainer.VolumeMounts, instanceInfoVolumeMount())
+	if FlagDeliveredAsFile(challenge) {
+		volumes = append(volumes, flagVolume(instance))
+		challengeContainer.VolumeMounts = append(challengeContainer.VolumeMounts, flagVolumeMounts(challenge)...)
+	}
+	// Keep /tmp writable when the root filesystem is read-only
+	if sc := challengeContainer.SecurityContext; sc != nil && ptr.Deref(sc.ReadOnlyRootFilesystem, false) && !hasMountPath(challengeContainer, tmpMountPath) {
+		volumes = append(volumes, corev1.Volume{
+			Name:         tmpVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		challengeContainer.VolumeMounts = append(challengeContainer.VolumeMounts, corev1.VolumeMount{
+			Name:      tmpVolumeName,
+			MountPath: tmpMountPath,
+		})
+	}
+	strategy := appsv1.DeploymentStrategy{}
+
+	// Mount the instance PVC for stateful challenges
+	if persistence := challenge.Spec.Scenario.Persistence; persistence != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: persistenceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: PersistentVolumeClaimName(instance),
+				},
+			},
+		})
+		challengeContainer.VolumeMounts = append(challengeContainer.VolumeMounts, corev1.VolumeMount{
+			Name:      persistenceVolumeName,
+			MountPath: persistence.MountPath,
+		})
+		// A ReadWriteOnce claim can't be attached to the old and new pod at once during a rollout
+		strategy.Type = appsv1.RecreateDeploymentStrategyType
 	}
 	containers = append(containers, challengeContainer)
 
-	return &appsv1.Deployment{
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      deploymentName,
-			Namespace: instance.Namespace,
+			Namespace: TargetNamespace(instance),
 			Labels:    labels,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: ptr.To(int32(1)),
+			Replicas: ptr.To(replicas),
+			Strategy: strategy,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"ctf.io/instance": instance.Name,
-				},
+				MatchLabels: ChallengeSelectorLabels(instance),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels: podTemplateLabels(labels),
 				},
 				Spec: corev1.PodSpec{
-					Containers:    containers,
-					RestartPolicy: corev1.RestartPolicyAlways,
+					InitContainers:   buildInitContainers(instance, challenge),
+					Containers:       containers,
+					Volumes:          volumes,
+					RestartPolicy:    corev1.RestartPolicyAlways,
+					ImagePullSecrets: imagePullSecrets(challenge),
+					SecurityContext:  challenge.Spec.Scenario.PodSecurityContext.DeepCopy(),
 				},
 			},
 		},
 	}
+	applyScheduling(&deployment.Spec.Template.Spec, challenge)
+	return deployment, nil
+}
+
+// ChallengeReplicas returns the number of challenge pods per instance, 1 unless the scenario sets it
+func ChallengeReplicas(challenge *ctfv1alpha1.Challenge) int32 {
+	if replicas := challenge.Spec.Scenario.Replicas; replicas != nil && *replicas > 0 {
+		return *replicas
+	}
+	return 1
+}
+
+// instanceEnv returns the instance metadata env vars shared by the challenge and init containers
+// The flag is set once per env output
+func instanceEnv(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, output := range FlagOutputs(challenge) {
+		if output.Type != "env" {
+			continue
+		}
+		if flagEnv := flagEnvVar(instance, output.Name); flagEnv != nil {
+			env = append(env, *flagEnv)
+		}
+	}
+	return append(env,
+		corev1.EnvVar{
+			Name:  "INSTANCE_ID",
+			Value: instance.Name,
+		},
+		corev1.EnvVar{
+			Name:  "SOURCE_ID",
+			Value: instance.Spec.SourceID,
+		},
+		corev1.EnvVar{
+			Name:  "CHALLENGE_ID",
+			Value: instance.Spec.ChallengeID,
+		},
+	)
+}
+
+// buildInitContainers copies the scenario init containers and injects the instance metadata env vars
+func buildInitContainers(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) []corev1.Container {
+	if len(challenge.Spec.Scenario.InitContainers) == 0 {
+		return nil
+	}
+
+	initContainers := make([]corev1.Container, 0, len(challenge.Spec.Scenario.InitContainers))
+	for _, spec := range challenge.Spec.Scenario.InitContainers {
+		container := *spec.DeepCopy()
+		container.Env = append(container.Env, instanceEnv(instance, challenge)...)
+		initContainers = append(initContainers, container)
+	}
+	return initContainers
+}
+
+// tmpVolumeName is the emptyDir mounted on /tmp when the root filesystem is read-only
+const tmpVolumeName = "tmp"
+
+// tmpMountPath is the writable scratch directory of read-only challenge containers
+const tmpMountPath = "/tmp"
+
+// getReadOnlyRootFilesystem reports whether hardened challenge containers get a read-only root filesystem
+// Enabled unless READONLY_ROOT_FILESYSTEM=false
+func getReadOnlyRootFilesystem() bool {
+	return os.Getenv("READONLY_ROOT_FILESYSTEM") != "false"
+}
+
+// hasMountPath reports whether the container already mounts a volume at path
+func hasMountPath(container corev1.Container, path string) bool {
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// challengeSecurityContext returns the challenge container security context
+// Without an override the container runs hardened: non-root, read-only root filesystem,
+// no privilege escalation, no capabilities and the runtime default seccomp profile
+func challengeSecurityContext(challenge *ctfv1alpha1.Challenge) *corev1.SecurityContext {
+	if challenge.Spec.Scenario.SecurityContext != nil {
+		return challenge.Spec.Scenario.SecurityContext.DeepCopy()
+	}
+	if challenge.Spec.Scenario.Privileged {
+		return nil
+	}
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             ptr.To(true),
+		ReadOnlyRootFilesystem:   ptr.To(getReadOnlyRootFilesystem()),
+		AllowPrivilegeEscalation: ptr.To(false),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// ValidateVolumes checks the scenario volumes and mounts reference non-empty, existing names
+func ValidateVolumes(challenge *ctfv1alpha1.Challenge) error {
+	volumes := map[string]bool{}
+	for i, volume := range challenge.Spec.Scenario.Volumes {
+		if volume.Name == "" {
+			return fmt.Errorf("volumes[%d]: name is required", i)
+		}
+
+		sources := 0
+		if volume.ConfigMap != nil {
+			sources++
+			if volume.ConfigMap.Name == "" {
+				return fmt.Errorf("volume %s: configMap name is required", volume.Name)
+			}
+		}
+		if volume.Secret != nil {
+			sources++
+			if volume.Secret.SecretName == "" {
+				return fmt.Errorf("volume %s: secretName is required", volume.Name)
+			}
+		}
+		if volume.EmptyDir != nil {
+			sources++
+		}
+		if sources != 1 {
+			return fmt.Errorf("volume %s: exactly one of configMap, secret or emptyDir must be set", volume.Name)
+		}
+		volumes[volume.Name] = true
+	}
+
+	for i, mount := range challenge.Spec.Scenario.VolumeMounts {
+		if mount.Name == "" || mount.MountPath == "" {
+			return fmt.Errorf("volumeMounts[%d]: name and mountPath are required", i)
+		}
+		if !volumes[mount.Name] {
+			return fmt.Errorf("volumeMounts[%d]: unknown volume %s", i, mount.Name)
+		}
+	}
+	return nil
+}
+
+// buildVolumes converts the scenario volumes into pod volumes
+func buildVolumes(specs []ctfv1alpha1.VolumeSpec) []corev1.Volume {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	volumes := make([]corev1.Volume, 0, len(specs))
+	for _, spec := range specs {
+		volumes = append(volumes, corev1.Volume{
+			Name: spec.Name,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: spec.ConfigMap,
+				Secret:    spec.Secret,
+				EmptyDir:  spec.EmptyDir,
+			},
+		})
+	}
+	return volumes
+}
+
+// BuildProbe converts a ProbeSpec into a container probe, defaulting to the given port
+// Returns nil if spec is nil
+func BuildProbe(spec *ctfv1alpha1.ProbeSpec, defaultPort int32) *corev1.Probe {
+	if spec == nil {
+		return nil
+	}
+
+	port := defaultPort
+	if spec.Port != 0 {
+		port = spec.Port
+	}
+
+	probe := &corev1.Probe{
+		InitialDelaySeconds: spec.InitialDelaySeconds,
+		PeriodSeconds:       spec.PeriodSeconds,
+		FailureThreshold:    spec.FailureThreshold,
+	}
+
+	if spec.Type == "HTTP" {
+		path := spec.Path
+		if path == "" {
+			path = "/"
+		}
+		probe.HTTPGet = &corev1.HTTPGetAction{
+			Path: path,
+			Port: intstr.FromInt32(port),
+		}
+	} else {
+		probe.TCPSocket = &corev1.TCPSocketAction{
+			Port: intstr.FromInt32(port),
+		}
+	}
+
+	return probe
 }
 
 // DeploymentName returns the name of the deployment for an instance
@@ -17,15 +17,29 @@ limitations under the License.
 package builder
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 )
 
+// mustBuildDeployment builds the instance Deployment, failing the test on a builder error
+func mustBuildDeployment(t *testing.T, instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) *appsv1.Deployment {
+	t.Helper()
+	deployment, err := BuildDeployment(instance, challenge)
+	if err != nil {
+		t.Fatalf("Failed to build Deployment: %v", err)
+	}
+	return deployment
+}
+
 func TestBuildDeployment(t *testing.T) {
 	instance := &ctfv1alpha1.ChallengeInstance{
 		ObjectMeta: metav1.ObjectMeta{
@@ -66,7 +80,7 @@ func TestBuildDeployment(t *testing.T) {
 		},
 	}
 
-	deployment := BuildDeployment(instance, challenge)
+	deployment := mustBuildDeployment(t, instance, challenge)
 
 	// Check deployment name
 	expectedName := "test-instance-deployment"
@@ -135,3 +149,738 @@ func TestDeploymentName(t *testing.T) {
 		t.Errorf("Expected my-instance-deployment, got %s", name)
 	}
 }
+
+func TestBuildDeployment_DefaultReadinessProbe(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nc-chall:latest", Port: 1337},
+		},
+	}
+
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+
+	probe := container.ReadinessProbe
+	if probe == nil || probe.TCPSocket == nil {
+		t.Fatalf("Expected default TCP readiness probe, got %v", probe)
+	}
+	if probe.TCPSocket.Port.IntValue() != 1337 {
+		t.Errorf("Expected readiness probe on port 1337, got %s", probe.TCPSocket.Port.String())
+	}
+	if container.LivenessProbe != nil {
+		t.Errorf("Expected no liveness probe by default, got %v", container.LivenessProbe)
+	}
+}
+
+func TestBuildDeployment_CustomProbes(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:          "web-chall:latest",
+				Port:           8080,
+				ReadinessProbe: &ctfv1alpha1.ProbeSpec{Type: "HTTP", Path: "/healthz", PeriodSeconds: 2},
+				LivenessProbe:  &ctfv1alpha1.ProbeSpec{Type: "TCP", Port: 9000, FailureThreshold: 5},
+			},
+		},
+	}
+
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+
+	readiness := container.ReadinessProbe
+	if readiness == nil || readiness.HTTPGet == nil {
+		t.Fatalf("Expected HTTP readiness probe, got %v", readiness)
+	}
+	if readiness.HTTPGet.Path != "/healthz" || readiness.HTTPGet.Port.IntValue() != 8080 {
+		t.Errorf("Expected GET /healthz on 8080, got %s on %s", readiness.HTTPGet.Path, readiness.HTTPGet.Port.String())
+	}
+	if readiness.PeriodSeconds != 2 {
+		t.Errorf("Expected period 2s, got %d", readiness.PeriodSeconds)
+	}
+
+	liveness := container.LivenessProbe
+	if liveness == nil || liveness.TCPSocket == nil {
+		t.Fatalf("Expected TCP liveness probe, got %v", liveness)
+	}
+	if liveness.TCPSocket.Port.IntValue() != 9000 || liveness.FailureThreshold != 5 {
+		t.Errorf("Expected TCP probe on 9000 with threshold 5, got %s/%d", liveness.TCPSocket.Port.String(), liveness.FailureThreshold)
+	}
+}
+
+func TestSanitizeForLabel_KeepsValidNames(t *testing.T) {
+	for _, input := range []string{"user-123", "alice", "team42"} {
+		if got := SanitizeForLabel(input); got != input {
+			t.Errorf("Expected %q to be kept, got %q", input, got)
+		}
+	}
+}
+
+func TestSanitizeForLabel_InvalidInputs(t *testing.T) {
+	tests := []struct {
+		input  string
+		prefix string
+	}{
+		{"alice@ctf.local", "alice-at-ctf-local-"},
+		{"Alice", "alice-"},
+		{"Équipe Rouge", "quipe-rouge-"},
+		{"日本語チーム", ""},
+		{strings.Repeat("long.name", 20), "long-namelong"},
+	}
+
+	for _, tt := range tests {
+		got := SanitizeForLabel(tt.input)
+		if errs := validation.IsDNS1123Label(got); len(errs) > 0 {
+			t.Errorf("Expected valid DNS label for %q, got %q: %v", tt.input, got, errs)
+		}
+		if !strings.HasPrefix(got, tt.prefix) {
+			t.Errorf("Expected %q to start with %q, got %q", tt.input, tt.prefix, got)
+		}
+		if SanitizeForLabel(tt.input) != got {
+			t.Errorf("Expected sanitizing %q to be stable", tt.input)
+		}
+	}
+}
+
+func TestSanitizeForLabel_NoCollisions(t *testing.T) {
+	// These all collapsed to the same value with plain character replacement
+	inputs := []string{"alice@ctf.local", "alice-at-ctf-local", "Alice@ctf.local", "alice@ctf-local", "alice.at.ctf.local"}
+
+	seen := map[string]string{}
+	for _, input := range inputs {
+		got := SanitizeForLabel(input)
+		if other, ok := seen[got]; ok {
+			t.Errorf("Expected unique results, %q and %q both gave %q", other, input, got)
+		}
+		seen[got] = input
+	}
+}
+
+func TestBuildDeployment_Volumes(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "ssh-chall:latest",
+				Port:  22,
+				Volumes: []ctfv1alpha1.VolumeSpec{
+					{Name: "keys", Secret: &corev1.SecretVolumeSource{SecretName: "ssh-host-keys"}},
+					{Name: "config", ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "sshd-config"},
+					}},
+				},
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "keys", MountPath: "/etc/ssh/keys", ReadOnly: true},
+					{Name: "config", MountPath: "/etc/ssh/sshd_config.d"},
+				},
+			},
+		},
+	}
+
+	if err := ValidateVolumes(challenge); err != nil {
+		t.Fatalf("Expected valid volumes, got %v", err)
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+
+	// The two scenario volumes come first, followed by the instance info and /tmp volumes
+	if len(podSpec.Volumes) != 4 {
+		t.Fatalf("Expected 4 volumes, got %d", len(podSpec.Volumes))
+	}
+	if podSpec.Volumes[0].Secret == nil || podSpec.Volumes[0].Secret.SecretName != "ssh-host-keys" {
+		t.Errorf("Expected secret volume ssh-host-keys, got %v", podSpec.Volumes[0].VolumeSource)
+	}
+	if podSpec.Volumes[1].ConfigMap == nil || podSpec.Volumes[1].ConfigMap.Name != "sshd-config" {
+		t.Errorf("Expected configMap volume sshd-config, got %v", podSpec.Volumes[1].VolumeSource)
+	}
+
+	mounts := podSpec.Containers[0].VolumeMounts
+	if len(mounts) != 4 || mounts[0].MountPath != "/etc/ssh/keys" || !mounts[0].ReadOnly {
+		t.Errorf("Expected read-only mount at /etc/ssh/keys, got %v", mounts)
+	}
+}
+
+func TestValidateVolumes_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario ctfv1alpha1.ChallengeScenarioSpec
+	}{
+		{"empty volume name", ctfv1alpha1.ChallengeScenarioSpec{
+			Volumes: []ctfv1alpha1.VolumeSpec{{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}},
+		{"empty secret name", ctfv1alpha1.ChallengeScenarioSpec{
+			Volumes: []ctfv1alpha1.VolumeSpec{{Name: "keys", Secret: &corev1.SecretVolumeSource{}}},
+		}},
+		{"empty configMap name", ctfv1alpha1.ChallengeScenarioSpec{
+			Volumes: []ctfv1alpha1.VolumeSpec{{Name: "config", ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+		}},
+		{"no source", ctfv1alpha1.ChallengeScenarioSpec{
+			Volumes: []ctfv1alpha1.VolumeSpec{{Name: "data"}},
+		}},
+		{"unknown mount", ctfv1alpha1.ChallengeScenarioSpec{
+			VolumeMounts: []corev1.VolumeMount{{Name: "missing", MountPath: "/data"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		challenge := &ctfv1alpha1.Challenge{Spec: ctfv1alpha1.ChallengeSpec{Scenario: tt.scenario}}
+		if err := ValidateVolumes(challenge); err == nil {
+			t.Errorf("Expected %s to be rejected", tt.name)
+		}
+	}
+}
+
+func TestBuildDeployment_HashedFlagFromSecret(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{
+			FlagSalt:   "salt",
+			FlagHashes: []string{"abc123"},
+		},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:alpine", Port: 8080},
+		},
+	}
+
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+	for _, env := range container.Env {
+		if env.Name != "FLAG" {
+			continue
+		}
+		if env.Value != "" {
+			t.Errorf("Expected no plaintext FLAG value, got %q", env.Value)
+		}
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil || env.ValueFrom.SecretKeyRef.Name != "test-instance-flag" {
+			t.Errorf("Expected FLAG from Secret test-instance-flag, got %+v", env.ValueFrom)
+		}
+		return
+	}
+	t.Errorf("Expected a FLAG env var")
+}
+
+func TestBuildDeployment_FlagFile(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+		Status:     ctfv1alpha1.ChallengeInstanceStatus{Flags: []string{"FLAG{test}"}},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:        "nginx:alpine",
+				Port:         8080,
+				FlagDelivery: "file",
+				FlagPath:     "/home/ctf/flag.txt",
+			},
+		},
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+	container := podSpec.Containers[0]
+	for _, env := range container.Env {
+		if env.Name == "FLAG" {
+			t.Errorf("Expected no FLAG env var in file mode, got %+v", env)
+		}
+	}
+
+	var mount *corev1.VolumeMount
+	for i := range container.VolumeMounts {
+		if container.VolumeMounts[i].MountPath == "/home/ctf/flag.txt" {
+			mount = &container.VolumeMounts[i]
+		}
+	}
+	if mount == nil {
+		t.Fatalf("Expected a flag mount at /home/ctf/flag.txt, got %+v", container.VolumeMounts)
+	}
+	if mount.SubPath != FlagSecretKey || !mount.ReadOnly {
+		t.Errorf("Expected read-only subPath %s mount, got %+v", FlagSecretKey, mount)
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if volume.Name != mount.Name {
+			continue
+		}
+		if volume.Projected == nil || len(volume.Projected.Sources) != 1 || volume.Projected.Sources[0].Secret == nil {
+			t.Fatalf("Expected a projected Secret volume, got %+v", volume.VolumeSource)
+		}
+		if name := volume.Projected.Sources[0].Secret.Name; name != "test-instance-flag" {
+			t.Errorf("Expected Secret test-instance-flag, got %s", name)
+		}
+		return
+	}
+	t.Errorf("Expected a volume named %s", mount.Name)
+}
+
+func TestBuildDeployment_FlagOutputs(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+		Status:     ctfv1alpha1.ChallengeInstanceStatus{Flags: []string{"FLAG{test}"}},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:        "nginx:alpine",
+				Port:         8080,
+				FlagDelivery: "file",
+				FlagOutputs: []ctfv1alpha1.FlagOutputSpec{
+					{Type: "env"},
+					{Type: "env", Name: "CTF_FLAG"},
+					{Type: "file"},
+					{Type: "file", Path: "/home/ctf/flag.txt"},
+					{Type: "none"},
+				},
+			},
+		},
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+	container := podSpec.Containers[0]
+
+	flagEnvs := map[string]string{}
+	for _, env := range container.Env {
+		if env.Value == "FLAG{test}" {
+			flagEnvs[env.Name] = env.Value
+		}
+	}
+	if len(flagEnvs) != 2 || flagEnvs["FLAG"] == "" || flagEnvs["CTF_FLAG"] == "" {
+		t.Errorf("Expected the flag in FLAG and CTF_FLAG, got %v", flagEnvs)
+	}
+
+	var flagMounts []string
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == flagVolumeName {
+			flagMounts = append(flagMounts, mount.MountPath)
+		}
+	}
+	if len(flagMounts) != 2 || flagMounts[0] != DefaultFlagPath || flagMounts[1] != "/home/ctf/flag.txt" {
+		t.Errorf("Expected flag mounts at %s and /home/ctf/flag.txt, got %v", DefaultFlagPath, flagMounts)
+	}
+
+	volumes := 0
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == flagVolumeName {
+			volumes++
+		}
+	}
+	if volumes != 1 {
+		t.Errorf("Expected a single flag volume, got %d", volumes)
+	}
+}
+
+func TestBuildDeployment_FlagOutputNone(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+		Status:     ctfv1alpha1.ChallengeInstanceStatus{Flags: []string{"FLAG{test}"}},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:       "nginx:alpine",
+				Port:        8080,
+				FlagOutputs: []ctfv1alpha1.FlagOutputSpec{{Type: "none"}},
+			},
+		},
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+	for _, env := range podSpec.Containers[0].Env {
+		if env.Value == "FLAG{test}" {
+			t.Errorf("Expected no flag env var, got %+v", env)
+		}
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == flagVolumeName {
+			t.Errorf("Expected no flag volume, got %+v", volume)
+		}
+	}
+}
+
+func TestBuildDeployment_ImagePullSecrets(t *testing.T) {
+	t.Setenv("DEFAULT_PULL_SECRET", "registry-default")
+
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:            "registry.local/chall:latest",
+				Port:             8080,
+				ImagePullSecrets: []string{"registry-creds", "registry-default"},
+				AttackBox:        &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+			},
+		},
+	}
+
+	expected := []corev1.LocalObjectReference{{Name: "registry-default"}, {Name: "registry-creds"}}
+	pods := map[string]corev1.PodSpec{
+		"challenge": mustBuildDeployment(t, instance, challenge).Spec.Template.Spec,
+		"attackbox": BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec,
+	}
+	for name, podSpec := range pods {
+		if !reflect.DeepEqual(podSpec.ImagePullSecrets, expected) {
+			t.Errorf("Expected %s pull secrets %v, got %v", name, expected, podSpec.ImagePullSecrets)
+		}
+	}
+}
+
+func TestBuildDeployment_ImagePullPolicy(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+
+	tests := []struct {
+		image    string
+		policy   corev1.PullPolicy
+		expected corev1.PullPolicy
+	}{
+		{"registry.local/chall:latest", "", corev1.PullAlways},
+		{"registry.local:5000/chall", "", corev1.PullAlways},
+		{"registry.local:5000/chall:v1.2", "", corev1.PullIfNotPresent},
+		{"chall@sha256:0123456789abcdef", "", corev1.PullIfNotPresent},
+		{"registry.local/chall:latest", corev1.PullNever, corev1.PullNever},
+	}
+
+	for _, tt := range tests {
+		challenge := &ctfv1alpha1.Challenge{
+			Spec: ctfv1alpha1.ChallengeSpec{
+				ID: "chall-1",
+				Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+					Image:           tt.image,
+					ImagePullPolicy: tt.policy,
+					Port:            8080,
+					AttackBox:       &ctfv1alpha1.AttackBoxSpec{Enabled: true, Image: tt.image, ImagePullPolicy: tt.policy},
+				},
+			},
+		}
+
+		container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+		if container.ImagePullPolicy != tt.expected {
+			t.Errorf("Expected challenge pull policy %s for %s, got %s", tt.expected, tt.image, container.ImagePullPolicy)
+		}
+		container = BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec.Containers[0]
+		if container.ImagePullPolicy != tt.expected {
+			t.Errorf("Expected attackbox pull policy %s for %s, got %s", tt.expected, tt.image, container.ImagePullPolicy)
+		}
+	}
+}
+
+func TestBuildDeployment_DefaultSecurityContext(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:alpine", Port: 8080},
+		},
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+	sc := podSpec.Containers[0].SecurityContext
+	if sc == nil {
+		t.Fatalf("Expected a default security context")
+	}
+	if sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot {
+		t.Errorf("Expected runAsNonRoot true, got %v", sc.RunAsNonRoot)
+	}
+	if sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+		t.Errorf("Expected readOnlyRootFilesystem true, got %v", sc.ReadOnlyRootFilesystem)
+	}
+	if !hasMountPath(podSpec.Containers[0], "/tmp") {
+		t.Errorf("Expected a writable /tmp mount, got %v", podSpec.Containers[0].VolumeMounts)
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		t.Errorf("Expected allowPrivilegeEscalation false, got %v", sc.AllowPrivilegeEscalation)
+	}
+	if sc.Capabilities == nil || !reflect.DeepEqual(sc.Capabilities.Drop, []corev1.Capability{"ALL"}) {
+		t.Errorf("Expected all capabilities dropped, got %+v", sc.Capabilities)
+	}
+	if sc.SeccompProfile == nil || sc.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		t.Errorf("Expected RuntimeDefault seccomp profile, got %+v", sc.SeccompProfile)
+	}
+	if podSpec.SecurityContext != nil {
+		t.Errorf("Expected no pod security context, got %+v", podSpec.SecurityContext)
+	}
+}
+
+func TestBuildDeployment_PrivilegedOptOut(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "pwn-chall:v1", Port: 1337, Privileged: true},
+		},
+	}
+
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+	if container.SecurityContext != nil {
+		t.Errorf("Expected no security context for privileged challenge, got %+v", container.SecurityContext)
+	}
+	if hasMountPath(container, "/tmp") {
+		t.Errorf("Expected no /tmp mount with a writable root filesystem, got %v", container.VolumeMounts)
+	}
+
+	// The read-only root filesystem can be turned off operator-wide
+	t.Setenv("READONLY_ROOT_FILESYSTEM", "false")
+	challenge.Spec.Scenario.Privileged = false
+	container = mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+	if sc := container.SecurityContext; sc == nil || sc.ReadOnlyRootFilesystem == nil || *sc.ReadOnlyRootFilesystem {
+		t.Errorf("Expected hardened context with writable root filesystem, got %+v", sc)
+	}
+}
+
+func TestBuildDeployment_SecurityContextOverride(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	root := int64(0)
+	fsGroup := int64(1000)
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "pwn:latest",
+				Port:  1337,
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser:    &root,
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+				},
+				PodSecurityContext: &corev1.PodSecurityContext{FSGroup: &fsGroup},
+			},
+		},
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+	sc := podSpec.Containers[0].SecurityContext
+	if !reflect.DeepEqual(sc, challenge.Spec.Scenario.SecurityContext) {
+		t.Errorf("Expected the scenario security context, got %+v", sc)
+	}
+	if sc == challenge.Spec.Scenario.SecurityContext {
+		t.Errorf("Expected the security context to be copied, not shared with the Challenge")
+	}
+	if podSpec.SecurityContext == nil || podSpec.SecurityContext.FSGroup == nil || *podSpec.SecurityContext.FSGroup != 1000 {
+		t.Errorf("Expected pod fsGroup 1000, got %+v", podSpec.SecurityContext)
+	}
+}
+
+func TestBuildDeployment_ComplianceLabels(t *testing.T) {
+	t.Setenv("POD_LABELS", "security-tier=ctf, cost-center=events,app=override,bad key=x")
+
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:     "nginx:alpine",
+				Port:      8080,
+				AttackBox: &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+			},
+		},
+	}
+
+	for name, labels := range map[string]map[string]string{
+		"challenge": mustBuildDeployment(t, instance, challenge).Spec.Template.Labels,
+		"attackbox": BuildAttackBoxDeployment(instance, challenge).Spec.Template.Labels,
+	} {
+		if labels["security-tier"] != "ctf" || labels["cost-center"] != "events" {
+			t.Errorf("Expected compliance labels on the %s pod template, got %v", name, labels)
+		}
+		if labels["app"] == "override" {
+			t.Errorf("Expected operator labels to win over configured ones on the %s pod template", name)
+		}
+		if _, ok := labels["bad key"]; ok {
+			t.Errorf("Expected invalid label keys to be skipped on the %s pod template", name)
+		}
+	}
+}
+
+func TestBuildDeployment_BillingLabel(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "Team Rocket"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:     "nginx:alpine",
+				Port:      8080,
+				AttackBox: &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+			},
+		},
+	}
+
+	if _, ok := mustBuildDeployment(t, instance, challenge).Spec.Template.Labels["billing.ctf.io/team"]; ok {
+		t.Errorf("Expected no billing label when BILLING_LABEL is unset")
+	}
+
+	t.Setenv("BILLING_LABEL", "billing.ctf.io/team")
+	expected := SanitizeForLabel("Team Rocket")
+	for name, labels := range map[string]map[string]string{
+		"challenge pod":     mustBuildDeployment(t, instance, challenge).Spec.Template.Labels,
+		"challenge service": BuildService(instance, challenge).Labels,
+		"attackbox pod":     BuildAttackBoxDeployment(instance, challenge).Spec.Template.Labels,
+		"attackbox service": BuildAttackBoxService(instance, challenge).Labels,
+	} {
+		if labels["billing.ctf.io/team"] != expected {
+			t.Errorf("Expected billing label %q on the %s, got %v", expected, name, labels)
+		}
+	}
+
+	t.Setenv("BILLING_LABEL", "not a label key")
+	if _, ok := mustBuildDeployment(t, instance, challenge).Spec.Template.Labels["not a label key"]; ok {
+		t.Errorf("Expected an invalid BILLING_LABEL key to be ignored")
+	}
+}
+
+func TestBuildDeployment_EnvFrom(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "db-chall:latest",
+				Port:  5432,
+				EnvFrom: []corev1.EnvFromSource{
+					{SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"},
+					}},
+					{Prefix: "APP_", ConfigMapRef: &corev1.ConfigMapEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"},
+					}},
+				},
+			},
+		},
+	}
+
+	envFrom := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0].EnvFrom
+	if len(envFrom) != 2 {
+		t.Fatalf("Expected 2 envFrom sources, got %d", len(envFrom))
+	}
+	if envFrom[0].SecretRef == nil || envFrom[0].SecretRef.Name != "db-credentials" {
+		t.Errorf("Expected envFrom Secret db-credentials, got %+v", envFrom[0])
+	}
+	if envFrom[1].ConfigMapRef == nil || envFrom[1].ConfigMapRef.Name != "app-config" || envFrom[1].Prefix != "APP_" {
+		t.Errorf("Expected envFrom ConfigMap app-config with prefix APP_, got %+v", envFrom[1])
+	}
+}
+
+func TestBuildDeployment_InitContainers(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+		Status:     ctfv1alpha1.ChallengeInstanceStatus{Flags: []string{"FLAG{seed}"}},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "db-chall:latest",
+				Port:  5432,
+				InitContainers: []corev1.Container{{
+					Name:  "seed-db",
+					Image: "db-seed:latest",
+					Env:   []corev1.EnvVar{{Name: "SEED_SIZE", Value: "small"}},
+				}},
+			},
+		},
+	}
+
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+	if len(podSpec.InitContainers) != 1 {
+		t.Fatalf("Expected 1 init container, got %d", len(podSpec.InitContainers))
+	}
+	initContainer := podSpec.InitContainers[0]
+	if initContainer.Name != "seed-db" || initContainer.Image != "db-seed:latest" {
+		t.Errorf("Expected init container seed-db with image db-seed:latest, got %s with %s", initContainer.Name, initContainer.Image)
+	}
+
+	env := map[string]string{}
+	for _, e := range initContainer.Env {
+		env[e.Name] = e.Value
+	}
+	expected := map[string]string{
+		"SEED_SIZE":    "small",
+		"FLAG":         "FLAG{seed}",
+		"INSTANCE_ID":  "test-instance",
+		"SOURCE_ID":    "user-123",
+		"CHALLENGE_ID": "chall-1",
+	}
+	for name, value := range expected {
+		if env[name] != value {
+			t.Errorf("Expected init container env %s=%s, got %q", name, value, env[name])
+		}
+	}
+
+	// The scenario template must not be mutated by the injected env vars
+	if len(challenge.Spec.Scenario.InitContainers[0].Env) != 1 {
+		t.Errorf("Expected scenario init container env to stay untouched, got %v", challenge.Spec.Scenario.InitContainers[0].Env)
+	}
+}
+
+func TestBuildDeployment_Replicas(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	replicas := int32(3)
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "web-chall:latest",
+				Port:  8080,
+			},
+		},
+	}
+
+	if got := *mustBuildDeployment(t, instance, challenge).Spec.Replicas; got != 1 {
+		t.Errorf("Expected 1 replica by default, got %d", got)
+	}
+
+	challenge.Spec.Scenario.Replicas = &replicas
+	if got := *mustBuildDeployment(t, instance, challenge).Spec.Replicas; got != 3 {
+		t.Errorf("Expected 3 replicas, got %d", got)
+	}
+
+	// A ReadWriteOnce volume can't back several replicas
+	challenge.Spec.Scenario.Persistence = &ctfv1alpha1.PersistenceSpec{MountPath: "/data"}
+	if _, err := BuildDeployment(instance, challenge); err == nil {
+		t.Error("Expected an error for several replicas of a persistent challenge")
+	}
+}
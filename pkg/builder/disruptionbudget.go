@@ -0,0 +1,62 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// BuildPodDisruptionBudget creates a PodDisruptionBudget for the challenge pods of an instance
+// Returns nil if the challenge doesn't configure a disruption budget
+func BuildPodDisruptionBudget(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) *policyv1.PodDisruptionBudget {
+	budget := challenge.Spec.Scenario.DisruptionBudget
+	if budget == nil {
+		return nil
+	}
+
+	minAvailable := intstr.FromInt32(1)
+	if budget.MinAvailable != nil {
+		minAvailable = *budget.MinAvailable
+	}
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PodDisruptionBudgetName(instance),
+			Namespace: TargetNamespace(instance),
+			Labels: map[string]string{
+				"ctf.io/challenge": instance.Spec.ChallengeID,
+				"ctf.io/instance":  instance.Name,
+				"ctf.io/source":    SanitizeForLabel(instance.Spec.SourceID),
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ChallengeSelectorLabels(instance),
+			},
+		},
+	}
+}
+
+// PodDisruptionBudgetName returns the name of the PodDisruptionBudget for an instance
+func PodDisruptionBudgetName(instance *ctfv1alpha1.ChallengeInstance) string {
+	return instance.Name + "-pdb"
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// FlagSecretKey is the Secret key holding the plaintext flag
+const FlagSecretKey = "flag"
+
+// DefaultFlagPath is where the flag file is mounted when no FlagPath is set
+const DefaultFlagPath = "/flag"
+
+// flagVolumeName is the pod volume holding the flag file
+const flagVolumeName = "flag"
+
+// BuildFlagSecret creates the Secret holding the plaintext flag
+// It backs the FLAG env var when only hashes are kept in status, and the flag file in file delivery
+func BuildFlagSecret(instance *ctfv1alpha1.ChallengeInstance, flag string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      FlagSecretName(instance),
+			Namespace: TargetNamespace(instance),
+			Labels: map[string]string{
+				"ctf.io/challenge":             instance.Spec.ChallengeID,
+				"ctf.io/instance":              instance.Name,
+				"app.kubernetes.io/managed-by": "chall-operator",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			FlagSecretKey: flag,
+		},
+	}
+}
+
+// FlagSecretName returns the name of the flag Secret for an instance
+func FlagSecretName(instance *ctfv1alpha1.ChallengeInstance) string {
+	return instance.Name + "-flag"
+}
+
+// DefaultFlagEnv is the env var holding the flag when an env output names none
+const DefaultFlagEnv = "FLAG"
+
+// FlagOutputs returns where the flag is delivered, with defaults filled in
+// Without FlagOutputs, FlagDelivery and FlagPath declare a single env or file output
+func FlagOutputs(challenge *ctfv1alpha1.Challenge) []ctfv1alpha1.FlagOutputSpec {
+	scenario := challenge.Spec.Scenario
+	outputs := scenario.FlagOutputs
+	if len(outputs) == 0 {
+		if scenario.FlagDelivery == "file" {
+			outputs = []ctfv1alpha1.FlagOutputSpec{{Type: "file", Path: scenario.FlagPath}}
+		} else {
+			outputs = []ctfv1alpha1.FlagOutputSpec{{Type: "env"}}
+		}
+	}
+
+	resolved := make([]ctfv1alpha1.FlagOutputSpec, 0, len(outputs))
+	for _, output := range outputs {
+		switch output.Type {
+		case "env":
+			if output.Name == "" {
+				output.Name = DefaultFlagEnv
+			}
+		case "file":
+			if output.Path == "" {
+				output.Path = DefaultFlagPath
+			}
+		}
+		resolved = append(resolved, output)
+	}
+	return resolved
+}
+
+// flagEnvVar returns the named flag env var, read from the flag Secret when the status only has hashes
+// Returns nil until a flag was generated
+func flagEnvVar(instance *ctfv1alpha1.ChallengeInstance, name string) *corev1.EnvVar {
+	if len(instance.Status.Flags) > 0 {
+		return &corev1.EnvVar{Name: name, Value: instance.Status.Flags[0]}
+	}
+	if len(instance.Status.FlagHashes) > 0 {
+		return &corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: FlagSecretName(instance)},
+					Key:                  FlagSecretKey,
+				},
+			},
+		}
+	}
+	return nil
+}
+
+// FlagDeliveredAsFile reports whether any flag output is a file mounted from the flag Secret
+func FlagDeliveredAsFile(challenge *ctfv1alpha1.Challenge) bool {
+	for _, output := range FlagOutputs(challenge) {
+		if output.Type == "file" {
+			return true
+		}
+	}
+	return false
+}
+
+// flagVolume returns the projected volume exposing the flag Secret
+func flagVolume(instance *ctfv1alpha1.ChallengeInstance) corev1.Volume {
+	return corev1.Volume{
+		Name: flagVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: FlagSecretName(instance)},
+							Items:                []corev1.KeyToPath{{Key: FlagSecretKey, Path: FlagSecretKey}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// flagVolumeMounts mounts the flag file at the path of every file output
+func flagVolumeMounts(challenge *ctfv1alpha1.Challenge) []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	for _, output := range FlagOutputs(challenge) {
+		if output.Type != "file" {
+			continue
+		}
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      flagVolumeName,
+			MountPath: output.Path,
+			SubPath:   FlagSecretKey,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SpecHashAnnotation records the hash of the desired spec a resource was built from
+const SpecHashAnnotation = "ctf.io/spec-hash"
+
+// SetSpecHash annotates obj with a stable hash of the given desired state
+// The controller compares it with the existing resource to skip needless updates
+func SetSpecHash(obj metav1.Object, desired ...interface{}) error {
+	data, err := json.Marshal(desired)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SpecHashAnnotation] = hex.EncodeToString(sum[:8])
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// SpecHashChanged reports whether existing was built from a different spec than desired
+func SpecHashChanged(existing, desired metav1.Object) bool {
+	return existing.GetAnnotations()[SpecHashAnnotation] != desired.GetAnnotations()[SpecHashAnnotation]
+}
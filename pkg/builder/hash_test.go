@@ -0,0 +1,57 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestSpecHash_ChangesWithImage(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:1.26", Port: 80},
+		},
+	}
+
+	build := func() *metav1.ObjectMeta {
+		deployment := mustBuildDeployment(t, instance, challenge)
+		if err := SetSpecHash(deployment, deployment.Spec); err != nil {
+			t.Fatalf("Failed to hash spec: %v", err)
+		}
+		return &deployment.ObjectMeta
+	}
+
+	first, second := build(), build()
+	if SpecHashChanged(first, second) {
+		t.Errorf("Expected identical specs to hash the same, got %s and %s",
+			first.Annotations[SpecHashAnnotation], second.Annotations[SpecHashAnnotation])
+	}
+
+	challenge.Spec.Scenario.Image = "nginx:1.27"
+	if !SpecHashChanged(first, build()) {
+		t.Error("Expected image change to change the spec hash")
+	}
+}
@@ -4,11 +4,14 @@ package builder
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"text/template"
 
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 )
@@ -29,6 +32,64 @@ func getAuthURL() string {
 	return "auth.devleo.local"
 }
 
+// getBaseDomain returns the base domain for instance hostnames from env or fallback
+func getBaseDomain() string {
+	if baseDomain := os.Getenv("BASE_DOMAIN"); baseDomain != "" {
+		return baseDomain
+	}
+	return "devleo.local"
+}
+
+// getPublicBaseURL returns the public scheme and domain of the external gateway in front of the ingress from env
+// e.g. PUBLIC_BASE_URL=https://ctf.example.com; empty keeps the ingress hostnames
+func getPublicBaseURL() string {
+	return os.Getenv("PUBLIC_BASE_URL")
+}
+
+// PublicURL returns the URL players use to reach an ingress hostname
+// With PUBLIC_BASE_URL set, its scheme and path apply and its host replaces the BASE_DOMAIN suffix of the
+// hostname; a hostname outside BASE_DOMAIN (custom host template) is replaced entirely by the public host
+func PublicURL(hostname string) string {
+	public, err := url.Parse(getPublicBaseURL())
+	if err != nil || public.Host == "" {
+		return "http://" + hostname
+	}
+
+	baseDomain := getBaseDomain()
+	if strings.HasSuffix(hostname, "."+baseDomain) {
+		hostname = strings.TrimSuffix(hostname, baseDomain) + public.Host
+	} else {
+		hostname = public.Host
+	}
+	return public.Scheme + "://" + hostname + strings.TrimSuffix(public.Path, "/")
+}
+
+// IngressConnectionInfo returns the connection info of an ingress-exposed instance, with the terminal URL
+// when the attack box is enabled; empty without an ingress hostname
+func IngressConnectionInfo(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) string {
+	hostname := GetIngressHostname(instance, challenge)
+	if hostname == "" {
+		return ""
+	}
+	challengeURL := PublicURL(hostname)
+	if challenge.Spec.Scenario.AttackBox != nil && challenge.Spec.Scenario.AttackBox.Enabled {
+		return fmt.Sprintf("Challenge: %s\nTerminal: %s/terminal", challengeURL, challengeURL)
+	}
+	return challengeURL
+}
+
+// CustomHostname validates a user-requested hostname and returns the full host under the base domain
+// Accepts a single DNS label ("myteam") or that label under the base domain ("myteam.devleo.local")
+func CustomHostname(requested string) (string, error) {
+	baseDomain := strings.ToLower(getBaseDomain())
+	label := strings.TrimSuffix(strings.ToLower(requested), "."+baseDomain)
+	if errs := validation.IsDNS1123Label(label); len(errs) > 0 {
+		return "", fmt.Errorf("hostname %q must be a single DNS label under %s: %s",
+			requested, baseDomain, strings.Join(errs, "; "))
+	}
+	return label + "." + baseDomain, nil
+}
+
 // Shorter constants for long annotation values (avoid lll >120 chars)
 
 // HostContext contains variables available for host template rendering
@@ -39,37 +100,30 @@ type HostContext struct {
 	SourceID     string
 }
 
+// IngressEnabled reports whether the challenge is exposed through an Ingress
+func IngressEnabled(challenge *ctfv1alpha1.Challenge) bool {
+	return challenge.Spec.Scenario.Ingress != nil && challenge.Spec.Scenario.Ingress.Enabled && ExposesService(challenge)
+}
+
 // BuildIngress creates an Ingress for a ChallengeInstance
 // The Ingress exposes both the challenge (/) and attackbox (/terminal) paths
 func BuildIngress(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) *networkingv1.Ingress {
-	if challenge.Spec.Scenario.Ingress == nil || !challenge.Spec.Scenario.Ingress.Enabled {
+	if !IngressEnabled(challenge) {
 		return nil
 	}
 
 	ingressName := IngressName(instance)
 	username := SanitizeForLabel(instance.Spec.SourceID)
 
-	// Generate hostname from template
-	hostTemplate := getDefaultHostTemplate()
-	if challenge.Spec.Scenario.Ingress.HostTemplate != "" {
-		hostTemplate = challenge.Spec.Scenario.Ingress.HostTemplate
-	}
-
-	hostname, err := renderHostTemplate(hostTemplate, HostContext{
-		InstanceName: instance.Name,
-		Username:     username,
-		ChallengeID:  instance.Spec.ChallengeID,
-		SourceID:     instance.Spec.SourceID,
-	})
-	if err != nil {
-		// Fallback to simple hostname
-		hostname = instance.Name + ".ctf.local"
-	}
+	// Generate hostname from the instance override or the host template
+	hostname := GetIngressHostname(instance, challenge)
 
 	// Build annotations
-	annotations := map[string]string{
-		"kubernetes.io/ingress.class": challenge.Spec.Scenario.Ingress.IngressClassName,
+	annotations := map[string]string{}
+	if challenge.Spec.Scenario.Ingress.IngressClassName != "" {
+		annotations["kubernetes.io/ingress.class"] = challenge.Spec.Scenario.Ingress.IngressClassName
 	}
+	attackBoxEnabled := challenge.Spec.Scenario.AttackBox != nil && challenge.Spec.Scenario.AttackBox.Enabled
 
 	// Default OAuth2 annotations for CTF authentication
 	authURL := getAuthURL()
@@ -85,9 +139,13 @@ func BuildIngress(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha
 		"nginx.ingress.kubernetes.io/proxy-buffers-number":    "4",
 		"nginx.ingress.kubernetes.io/proxy-busy-buffers-size": "24k",
 	}
+	// Redirect to HTTPS once the Ingress serves a certificate
+	if challenge.Spec.Scenario.Ingress.TLS {
+		defaultAnnotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	}
 
 	// Add websocket support if attackbox is enabled
-	if challenge.Spec.Scenario.AttackBox != nil && challenge.Spec.Scenario.AttackBox.Enabled {
+	if attackBoxEnabled {
 		defaultAnnotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] = "3600"
 		defaultAnnotations["nginx.ingress.kubernetes.io/proxy-send-timeout"] = "3600"
 		defaultAnnotations["nginx.ingress.kubernetes.io/websocket-services"] = AttackBoxServiceName(instance)
@@ -119,8 +177,22 @@ func BuildIngress(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha
 
 	var paths []networkingv1.HTTPIngressPath
 
+	// Challenge path (/) - catches everything else
+	challengePath := networkingv1.HTTPIngressPath{
+		Path:     "/",
+		PathType: &pathTypePrefix,
+		Backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: ServiceName(instance),
+				Port: networkingv1.ServiceBackendPort{
+					Number: challengeServicePort,
+				},
+			},
+		},
+	}
+
 	// Add attackbox path if enabled (must come first for regex matching)
-	if challenge.Spec.Scenario.AttackBox != nil && challenge.Spec.Scenario.AttackBox.Enabled {
+	if attackBoxEnabled {
 		// Use regex to capture and rewrite /terminal/* to /*
 		paths = append(paths, networkingv1.HTTPIngressPath{
 			Path:     "/terminal(/|$)(.*)",
@@ -129,31 +201,23 @@ func BuildIngress(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha
 				Service: &networkingv1.IngressServiceBackend{
 					Name: AttackBoxServiceName(instance),
 					Port: networkingv1.ServiceBackendPort{
-						Number: 8080,
+						Number: attackBoxServicePort,
 					},
 				},
 			},
 		})
-	}
 
-	// Challenge path (/) - catches everything else
-	paths = append(paths, networkingv1.HTTPIngressPath{
-		Path:     "/",
-		PathType: &pathTypePrefix,
-		Backend: networkingv1.IngressBackend{
-			Service: &networkingv1.IngressServiceBackend{
-				Name: ServiceName(instance),
-				Port: networkingv1.ServiceBackendPort{
-					Number: 80,
-				},
-			},
-		},
-	})
+		// rewrite-target applies to every path of the Ingress: capture the whole
+		// challenge path as $2 so it is forwarded unchanged instead of rewritten to /
+		challengePath.Path = "/()(.*)"
+		challengePath.PathType = &pathTypeImplementationSpecific
+	}
+	paths = append(paths, challengePath)
 
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        ingressName,
-			Namespace:   instance.Namespace,
+			Namespace:   TargetNamespace(instance),
 			Annotations: annotations,
 			Labels: map[string]string{
 				"ctf.io/challenge":             instance.Spec.ChallengeID,
@@ -195,11 +259,16 @@ func IngressName(instance *ctfv1alpha1.ChallengeInstance) string {
 }
 
 // GetIngressHostname returns the hostname for an instance's ingress
+// A custom hostname set on the instance spec takes precedence over the host template
 func GetIngressHostname(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) string {
 	if challenge.Spec.Scenario.Ingress == nil {
 		return ""
 	}
 
+	if instance.Spec.Hostname != "" {
+		return instance.Spec.Hostname
+	}
+
 	hostTemplate := getDefaultHostTemplate()
 	if challenge.Spec.Scenario.Ingress.HostTemplate != "" {
 		hostTemplate = challenge.Spec.Scenario.Ingress.HostTemplate
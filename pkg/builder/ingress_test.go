@@ -0,0 +1,304 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestCustomHostname_Valid(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+
+	tests := map[string]string{
+		"myteam":              "myteam.devleo.local",
+		"MyTeam":              "myteam.devleo.local",
+		"myteam.devleo.local": "myteam.devleo.local",
+	}
+
+	for requested, expected := range tests {
+		host, err := CustomHostname(requested)
+		if err != nil {
+			t.Errorf("CustomHostname(%q) returned error: %v", requested, err)
+			continue
+		}
+		if host != expected {
+			t.Errorf("CustomHostname(%q) = %s, expected %s", requested, host, expected)
+		}
+	}
+}
+
+func TestCustomHostname_Rejected(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+
+	for _, requested := range []string{
+		"evil.com",
+		"a.b.devleo.local",
+		"devleo.local.evil.com",
+		"-bad",
+		"under_score",
+	} {
+		if host, err := CustomHostname(requested); err == nil {
+			t.Errorf("Expected CustomHostname(%q) to be rejected, got %s", requested, host)
+		}
+	}
+}
+
+func TestBuildIngress_CustomHostname(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-instance",
+			Namespace: "ctf-instances",
+		},
+		Spec: ctfv1alpha1.ChallengeInstanceSpec{
+			ChallengeID: "chall-1",
+			SourceID:    "user-123",
+			Hostname:    "myteam.devleo.local",
+		},
+	}
+
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:      "nginx:alpine",
+				Port:       80,
+				ExposeType: "Ingress",
+				Ingress: &ctfv1alpha1.IngressSpec{
+					Enabled:      true,
+					HostTemplate: "{{.InstanceName}}.example.com",
+				},
+			},
+		},
+	}
+
+	ingress := BuildIngress(instance, challenge)
+	if ingress == nil {
+		t.Fatal("Expected Ingress to be built")
+	}
+
+	if host := ingress.Spec.Rules[0].Host; host != "myteam.devleo.local" {
+		t.Errorf("Expected custom host myteam.devleo.local, got %s", host)
+	}
+
+	// Without the override the host template applies
+	instance.Spec.Hostname = ""
+	if host := GetIngressHostname(instance, challenge); host != "test-instance.example.com" {
+		t.Errorf("Expected templated host test-instance.example.com, got %s", host)
+	}
+}
+
+func TestBuildIngress_Disabled(t *testing.T) {
+	instance, challenge := newTestObjects()
+
+	challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{Enabled: false}
+	if ingress := BuildIngress(instance, challenge); ingress != nil {
+		t.Errorf("Expected no Ingress when disabled, got %s", ingress.Name)
+	}
+
+	challenge.Spec.Scenario.Ingress = nil
+	if ingress := BuildIngress(instance, challenge); ingress != nil {
+		t.Errorf("Expected no Ingress without ingress spec, got %s", ingress.Name)
+	}
+}
+
+func TestBuildIngress_WithoutAttackBox(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{
+		Enabled:          true,
+		HostTemplate:     "{{.InstanceName}}.example.com",
+		IngressClassName: "nginx",
+	}
+
+	ingress := BuildIngress(instance, challenge)
+	if ingress == nil {
+		t.Fatal("Expected Ingress to be built")
+	}
+	if ingress.Name != "test-instance-ingress" {
+		t.Errorf("Expected name test-instance-ingress, got %s", ingress.Name)
+	}
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	if len(paths) != 1 {
+		t.Fatalf("Expected a single challenge path, got %d", len(paths))
+	}
+	if paths[0].Path != "/" || *paths[0].PathType != networkingv1.PathTypePrefix {
+		t.Errorf("Expected Prefix path /, got %s %s", *paths[0].PathType, paths[0].Path)
+	}
+	service := BuildService(instance, challenge)
+	backend := paths[0].Backend.Service
+	if backend.Name != service.Name || backend.Port.Number != service.Spec.Ports[0].Port {
+		t.Errorf("Expected backend %s:%d, got %s:%d", service.Name, service.Spec.Ports[0].Port, backend.Name, backend.Port.Number)
+	}
+
+	for _, key := range []string{"nginx.ingress.kubernetes.io/use-regex", "nginx.ingress.kubernetes.io/rewrite-target"} {
+		if _, ok := ingress.Annotations[key]; ok {
+			t.Errorf("Expected no %s annotation without attackbox", key)
+		}
+	}
+	if ingress.Annotations["kubernetes.io/ingress.class"] != "nginx" {
+		t.Errorf("Expected ingress class nginx, got %q", ingress.Annotations["kubernetes.io/ingress.class"])
+	}
+}
+
+func TestBuildIngress_WithAttackBox(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{
+		Enabled:      true,
+		HostTemplate: "{{.InstanceName}}.example.com",
+	}
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+
+	ingress := BuildIngress(instance, challenge)
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	if len(paths) != 2 {
+		t.Fatalf("Expected attackbox and challenge paths, got %d", len(paths))
+	}
+
+	// The attackbox regex path must come first and target the attackbox Service port
+	terminal := paths[0]
+	if terminal.Path != "/terminal(/|$)(.*)" || *terminal.PathType != networkingv1.PathTypeImplementationSpecific {
+		t.Errorf("Expected regex path /terminal(/|$)(.*), got %s %s", *terminal.PathType, terminal.Path)
+	}
+	attackBoxService := BuildAttackBoxService(instance, challenge)
+	if backend := terminal.Backend.Service; backend.Name != attackBoxService.Name || backend.Port.Number != attackBoxService.Spec.Ports[0].Port {
+		t.Errorf("Expected backend %s:%d, got %s:%d",
+			attackBoxService.Name, attackBoxService.Spec.Ports[0].Port, backend.Name, backend.Port.Number)
+	}
+
+	// rewrite-target /$2 applies to the challenge path too, which must capture the full path as $2
+	if ingress.Annotations["nginx.ingress.kubernetes.io/use-regex"] != "true" {
+		t.Errorf("Expected use-regex annotation, got %v", ingress.Annotations)
+	}
+	if ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"] != "/$2" {
+		t.Errorf("Expected rewrite-target /$2, got %q", ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"])
+	}
+	if paths[1].Path != "/()(.*)" || paths[1].Backend.Service.Name != ServiceName(instance) {
+		t.Errorf("Expected challenge path /()(.*) to %s, got %s to %s", ServiceName(instance), paths[1].Path, paths[1].Backend.Service.Name)
+	}
+	if ingress.Annotations["nginx.ingress.kubernetes.io/websocket-services"] != AttackBoxServiceName(instance) {
+		t.Errorf("Expected websocket support for %s, got %v", AttackBoxServiceName(instance), ingress.Annotations)
+	}
+}
+
+func TestBuildIngress_TLS(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{
+		Enabled:      true,
+		HostTemplate: "{{.InstanceName}}.example.com",
+	}
+	challenge.Spec.Scenario.Ingress.TLS = true
+	challenge.Spec.Scenario.Ingress.ClusterIssuer = "letsencrypt"
+
+	ingress := BuildIngress(instance, challenge)
+	if len(ingress.Spec.TLS) != 1 {
+		t.Fatalf("Expected one TLS entry, got %d", len(ingress.Spec.TLS))
+	}
+	tls := ingress.Spec.TLS[0]
+	if len(tls.Hosts) != 1 || tls.Hosts[0] != "test-instance.example.com" || tls.SecretName != "test-instance-ingress-tls" {
+		t.Errorf("Expected TLS for test-instance.example.com in test-instance-ingress-tls, got %+v", tls)
+	}
+	if ingress.Annotations["cert-manager.io/cluster-issuer"] != "letsencrypt" {
+		t.Errorf("Expected cluster issuer letsencrypt, got %q", ingress.Annotations["cert-manager.io/cluster-issuer"])
+	}
+	if ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"] != "true" {
+		t.Errorf("Expected ssl-redirect with TLS, got %q", ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"])
+	}
+}
+
+func TestBuildIngress_CustomAnnotations(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{
+		Enabled:      true,
+		HostTemplate: "{{.InstanceName}}.example.com",
+	}
+	challenge.Spec.Scenario.Ingress.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-buffer-size": "64k",
+		"nginx.ingress.kubernetes.io/auth-url":          "",
+		"example.com/team":                              "blue",
+	}
+
+	annotations := BuildIngress(instance, challenge).Annotations
+	expected := map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-buffer-size": "64k",
+		"nginx.ingress.kubernetes.io/auth-url":          "",
+		"example.com/team":                              "blue",
+	}
+	for key, value := range expected {
+		if got, ok := annotations[key]; !ok || got != value {
+			t.Errorf("Expected annotation %s=%q, got %q", key, value, got)
+		}
+	}
+	if annotations["nginx.ingress.kubernetes.io/proxy-buffers-number"] != "4" {
+		t.Errorf("Expected untouched defaults to remain, got %v", annotations)
+	}
+}
+
+func TestPublicURL(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+
+	if got := PublicURL("ctf.chal-1.devleo.local"); got != "http://ctf.chal-1.devleo.local" {
+		t.Errorf("Expected the ingress host without PUBLIC_BASE_URL, got %s", got)
+	}
+
+	t.Setenv("PUBLIC_BASE_URL", "https://play.example.com:8443")
+	tests := []struct {
+		hostname string
+		expected string
+	}{
+		{"ctf.chal-1.devleo.local", "https://ctf.chal-1.play.example.com:8443"},
+		{"devleo.local", "https://play.example.com:8443"},
+		// Hosts outside the base domain are only reachable through the public host
+		{"myteam.other.org", "https://play.example.com:8443"},
+	}
+	for _, tt := range tests {
+		if got := PublicURL(tt.hostname); got != tt.expected {
+			t.Errorf("PublicURL(%q) = %s, expected %s", tt.hostname, got, tt.expected)
+		}
+	}
+
+	// The path of the gateway is kept
+	t.Setenv("PUBLIC_BASE_URL", "https://play.example.com/ctf/")
+	if got := PublicURL("ctf.chal-1.devleo.local"); got != "https://ctf.chal-1.play.example.com/ctf" {
+		t.Errorf("Expected the public path to be kept, got %s", got)
+	}
+}
+
+func TestIngressConnectionInfo_PublicBaseURL(t *testing.T) {
+	t.Setenv("BASE_DOMAIN", "devleo.local")
+	t.Setenv("PUBLIC_BASE_URL", "https://ctf.example.com")
+
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.Ingress = &ctfv1alpha1.IngressSpec{
+		Enabled:      true,
+		HostTemplate: "{{.InstanceName}}.devleo.local",
+	}
+
+	if info := IngressConnectionInfo(instance, challenge); info != "https://test-instance.ctf.example.com" {
+		t.Errorf("Expected public connection info, got %q", info)
+	}
+
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+	expected := "Challenge: https://test-instance.ctf.example.com\nTerminal: https://test-instance.ctf.example.com/terminal"
+	if info := IngressConnectionInfo(instance, challenge); info != expected {
+		t.Errorf("Expected %q, got %q", expected, info)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"regexp"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// InstanceInfoMountPath is where the instance info ConfigMap is mounted in challenge and attackbox containers
+// Each key is a file, e.g. /etc/ctf-instance/connection-info; files refresh when the ConfigMap changes
+const InstanceInfoMountPath = "/etc/ctf-instance"
+
+// instanceInfoVolumeName is the pod volume backed by the instance info ConfigMap
+const instanceInfoVolumeName = "instance-info"
+
+// urlPattern extracts the first URL of the connection info, e.g. for rendering a QR code in the terminal
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// BuildInstanceInfoConfigMap creates the ConfigMap exposing the instance connection details inside its pods
+func BuildInstanceInfoConfigMap(instance *ctfv1alpha1.ChallengeInstance) *corev1.ConfigMap {
+	data := map[string]string{
+		"connection-info": instance.Status.ConnectionInfo,
+		"url":             urlPattern.FindString(instance.Status.ConnectionInfo),
+		"instance-id":     instance.Name,
+		"challenge-id":    instance.Spec.ChallengeID,
+		"source-id":       instance.Spec.SourceID,
+	}
+	if instance.Spec.Until != nil {
+		data["until"] = instance.Spec.Until.UTC().Format(time.RFC3339)
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      InstanceInfoConfigMapName(instance),
+			Namespace: TargetNamespace(instance),
+			Labels: map[string]string{
+				"ctf.io/challenge":             instance.Spec.ChallengeID,
+				"ctf.io/instance":              instance.Name,
+				"ctf.io/source":                SanitizeForLabel(instance.Spec.SourceID),
+				"app.kubernetes.io/name":       "challenge-instance",
+				"app.kubernetes.io/instance":   instance.Name,
+				"app.kubernetes.io/managed-by": "chall-operator",
+			},
+		},
+		Data: data,
+	}
+}
+
+// InstanceInfoConfigMapName returns the name of the instance info ConfigMap
+func InstanceInfoConfigMapName(instance *ctfv1alpha1.ChallengeInstance) string {
+	return instance.Name + "-info"
+}
+
+// instanceInfoVolume returns the pod volume for the instance info ConfigMap
+// It is optional so pods can start before the controller has written the ConfigMap
+func instanceInfoVolume(instance *ctfv1alpha1.ChallengeInstance) corev1.Volume {
+	return corev1.Volume{
+		Name: instanceInfoVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: InstanceInfoConfigMapName(instance)},
+				Optional:             ptr.To(true),
+			},
+		},
+	}
+}
+
+// instanceInfoVolumeMount returns the read-only mount of the instance info volume
+func instanceInfoVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      instanceInfoVolumeName,
+		MountPath: InstanceInfoMountPath,
+		ReadOnly:  true,
+	}
+}
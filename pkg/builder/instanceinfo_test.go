@@ -0,0 +1,49 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestBuildInstanceInfoConfigMap(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+		Status: ctfv1alpha1.ChallengeInstanceStatus{
+			ConnectionInfo: "Challenge: http://ctf.test.devleo.local\nTerminal: http://ctf.test.devleo.local/terminal",
+		},
+	}
+
+	cm := BuildInstanceInfoConfigMap(instance)
+	if cm.Name != "test-instance-info" {
+		t.Errorf("Expected name test-instance-info, got %s", cm.Name)
+	}
+	if cm.Data["connection-info"] != instance.Status.ConnectionInfo {
+		t.Errorf("Expected connection info to be copied, got %q", cm.Data["connection-info"])
+	}
+	if cm.Data["url"] != "http://ctf.test.devleo.local" {
+		t.Errorf("Expected first URL http://ctf.test.devleo.local, got %q", cm.Data["url"])
+	}
+	if cm.Data["source-id"] != "user-123" {
+		t.Errorf("Expected source-id user-123, got %q", cm.Data["source-id"])
+	}
+}
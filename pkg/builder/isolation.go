@@ -0,0 +1,91 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// SourceQuotaName is the name of the ResourceQuota capping a source namespace
+const SourceQuotaName = "ctf-source-quota"
+
+// NamespaceIsolation reports whether instance resources go to a per-source namespace
+// Enabled with NAMESPACE_ISOLATION=true; the ChallengeInstances themselves stay in the shared namespace
+func NamespaceIsolation() bool {
+	return os.Getenv("NAMESPACE_ISOLATION") == "true"
+}
+
+// getSourceQuota returns the hard limits of the per-source ResourceQuota from env
+// SOURCE_QUOTA is a comma-separated list of quota resources, e.g. "requests.cpu=2,limits.memory=4Gi,pods=10"
+func getSourceQuota() corev1.ResourceList {
+	return parseResourceList(os.Getenv("SOURCE_QUOTA"))
+}
+
+// TargetNamespace returns the namespace the instance resources are created in
+func TargetNamespace(instance *ctfv1alpha1.ChallengeInstance) string {
+	if NamespaceIsolation() {
+		return SourceNamespaceName(instance.Spec.SourceID)
+	}
+	return instance.Namespace
+}
+
+// SourceNamespaceName returns the name of the per-source namespace
+func SourceNamespaceName(sourceID string) string {
+	return SanitizeForLabel("ctf-src-" + sourceID)
+}
+
+// BuildSourceNamespace creates the per-source namespace holding the instances of a user or team
+func BuildSourceNamespace(instance *ctfv1alpha1.ChallengeInstance) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   SourceNamespaceName(instance.Spec.SourceID),
+			Labels: sourceLabels(instance),
+		},
+	}
+}
+
+// BuildSourceQuota creates the ResourceQuota capping everything a source runs
+// Returns nil if SOURCE_QUOTA is not set
+func BuildSourceQuota(instance *ctfv1alpha1.ChallengeInstance) *corev1.ResourceQuota {
+	hard := getSourceQuota()
+	if len(hard) == 0 {
+		return nil
+	}
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SourceQuotaName,
+			Namespace: SourceNamespaceName(instance.Spec.SourceID),
+			Labels:    sourceLabels(instance),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}
+}
+
+// sourceLabels returns the labels of the per-source namespace and quota
+func sourceLabels(instance *ctfv1alpha1.ChallengeInstance) map[string]string {
+	return map[string]string{
+		"ctf.io/source":                SanitizeForLabel(instance.Spec.SourceID),
+		"app.kubernetes.io/managed-by": "chall-operator",
+	}
+}
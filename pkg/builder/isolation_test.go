@@ -0,0 +1,80 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/package builder
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestTargetNamespace(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-web-alice", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "web", SourceID: "Alice@example.com"},
+	}
+
+	if ns := TargetNamespace(instance); ns != "ctf-instances" {
+		t.Errorf("Expected the shared namespace without isolation, got %s", ns)
+	}
+
+	t.Setenv("NAMESPACE_ISOLATION", "true")
+	ns := TargetNamespace(instance)
+	if ns == "ctf-instances" || ns != SourceNamespaceName("Alice@example.com") {
+		t.Errorf("Expected the source namespace, got %s", ns)
+	}
+
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID:       "web",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{Image: "nginx:alpine", Port: 8080},
+		},
+	}
+	if got := mustBuildDeployment(t, instance, challenge).Namespace; got != ns {
+		t.Errorf("Expected the Deployment in %s, got %s", ns, got)
+	}
+}
+
+func TestBuildSourceQuota(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-web-alice", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "web", SourceID: "alice"},
+	}
+
+	if quota := BuildSourceQuota(instance); quota != nil {
+		t.Errorf("Expected no quota without SOURCE_QUOTA, got %+v", quota)
+	}
+
+	t.Setenv("SOURCE_QUOTA", "requests.cpu=2,limits.memory=4Gi,pods=10")
+	quota := BuildSourceQuota(instance)
+	if quota == nil {
+		t.Fatalf("Expected a quota")
+	}
+	if quota.Namespace != "ctf-src-alice" {
+		t.Errorf("Expected quota in ctf-src-alice, got %s", quota.Namespace)
+	}
+	if cpu := quota.Spec.Hard[corev1.ResourceRequestsCPU]; cpu.String() != "2" {
+		t.Errorf("Expected requests.cpu 2, got %s", cpu.String())
+	}
+	if pods := quota.Spec.Hard[corev1.ResourcePods]; pods.String() != "10" {
+		t.Errorf("Expected pods 10, got %s", pods.String())
+	}
+	if quota.Labels["ctf.io/source"] != "alice" {
+		t.Errorf("Expected ctf.io/source label alice, got %v", quota.Labels)
+	}
+}
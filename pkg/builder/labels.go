@@ -0,0 +1,112 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// DisabledAnnotation is the Challenge kill-switch: "true" tears down its instances and refuses new ones
+const DisabledAnnotation = "ctf.io/disabled"
+
+// ChallengeDisabled reports whether organizers switched the challenge off with DisabledAnnotation
+func ChallengeDisabled(challenge *ctfv1alpha1.Challenge) bool {
+	return challenge.Annotations[DisabledAnnotation] == "true"
+}
+
+// DestroyOnFlag reports whether solved instances of the challenge are deleted, the default
+func DestroyOnFlag(challenge *ctfv1alpha1.Challenge) bool {
+	return ptr.Deref(challenge.Spec.DestroyOnFlag, true)
+}
+
+// Selector contract: workloads select their pods by ChallengeSelectorLabels or AttackBoxSelectorLabels only.
+// These labels are fixed per instance, never configurable (POD_LABELS, BILLING_LABEL) nor derived from mutable data,
+// because a Deployment selector is immutable: changing them makes the controller recreate existing Deployments.
+// The challenge selector includes "app" so it doesn't match the attackbox pods of the same instance.
+
+// ChallengeSelectorLabels returns the labels selecting the challenge pods of an instance
+func ChallengeSelectorLabels(instance *ctfv1alpha1.ChallengeInstance) map[string]string {
+	return map[string]string{
+		"app":             "challenge",
+		"ctf.io/instance": instance.Name,
+	}
+}
+
+// AttackBoxSelectorLabels returns the labels selecting the attackbox pod of an instance
+func AttackBoxSelectorLabels(instance *ctfv1alpha1.ChallengeInstance) map[string]string {
+	return map[string]string{
+		"app": AttackBoxDeploymentName(instance),
+	}
+}
+
+// getPodLabels returns the compliance labels added to every generated pod from env
+// POD_LABELS is a comma-separated list of key=value pairs, e.g. "security-tier=ctf,cost-center=events";
+// invalid label keys or values are skipped
+func getPodLabels() map[string]string {
+	return parseLabelPairs(os.Getenv("POD_LABELS"))
+}
+
+// parseLabelPairs parses a comma-separated list of key=value pairs, skipping invalid label keys or values
+func parseLabelPairs(pairs string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(pairs, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if len(validation.IsQualifiedName(key)) > 0 || len(validation.IsValidLabelValue(value)) > 0 {
+			continue
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// getBillingLabel returns the label key carrying the sanitized source on billable resources from env
+// e.g. BILLING_LABEL=billing.ctf.io/team; empty or an invalid label key disables it
+func getBillingLabel() string {
+	key := os.Getenv("BILLING_LABEL")
+	if len(validation.IsQualifiedName(key)) > 0 {
+		return ""
+	}
+	return key
+}
+
+// withBillingLabel adds the billing label of the instance source to labels, so cost tools can aggregate per team
+func withBillingLabel(labels map[string]string, instance *ctfv1alpha1.ChallengeInstance) map[string]string {
+	if key := getBillingLabel(); key != "" {
+		labels[key] = SanitizeForLabel(instance.Spec.SourceID)
+	}
+	return labels
+}
+
+// podTemplateLabels returns the pod template labels: the configured compliance labels plus
+// the operator's own labels, which take precedence so selectors keep matching
+func podTemplateLabels(labels map[string]string) map[string]string {
+	podLabels := getPodLabels()
+	for k, v := range labels {
+		podLabels[k] = v
+	}
+	return podLabels
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// TestSelectorContract pins the pod selectors: Deployment selectors are immutable, so any change here
+// makes the controller recreate every existing instance Deployment on upgrade
+func TestSelectorContract(t *testing.T) {
+	t.Setenv("POD_LABELS", "security-tier=ctf")
+	t.Setenv("BILLING_LABEL", "billing.ctf.io/team")
+
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:     "nginx:alpine",
+				Port:      8080,
+				AttackBox: &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+			},
+		},
+	}
+
+	challengeSelector := map[string]string{"app": "challenge", "ctf.io/instance": "test-instance"}
+	attackBoxSelector := map[string]string{"app": "test-instance-attackbox"}
+
+	deployment := mustBuildDeployment(t, instance, challenge)
+	attackBox := BuildAttackBoxDeployment(instance, challenge)
+	if !reflect.DeepEqual(deployment.Spec.Selector.MatchLabels, challengeSelector) {
+		t.Errorf("Expected challenge selector %v, got %v", challengeSelector, deployment.Spec.Selector.MatchLabels)
+	}
+	if !reflect.DeepEqual(attackBox.Spec.Selector.MatchLabels, attackBoxSelector) {
+		t.Errorf("Expected attackbox selector %v, got %v", attackBoxSelector, attackBox.Spec.Selector.MatchLabels)
+	}
+
+	// Services select the same pods as their Deployment
+	if service := BuildService(instance, challenge); !reflect.DeepEqual(service.Spec.Selector, challengeSelector) {
+		t.Errorf("Expected challenge Service selector %v, got %v", challengeSelector, service.Spec.Selector)
+	}
+	if service := BuildAttackBoxService(instance, challenge); !reflect.DeepEqual(service.Spec.Selector, attackBoxSelector) {
+		t.Errorf("Expected attackbox Service selector %v, got %v", attackBoxSelector, service.Spec.Selector)
+	}
+
+	// Each selector matches its own pod template and not the other one
+	challengePods := labels.Set(deployment.Spec.Template.Labels)
+	attackBoxPods := labels.Set(attackBox.Spec.Template.Labels)
+	if s := labels.SelectorFromSet(challengeSelector); !s.Matches(challengePods) || s.Matches(attackBoxPods) {
+		t.Errorf("Expected the challenge selector to match only challenge pods")
+	}
+	if s := labels.SelectorFromSet(attackBoxSelector); !s.Matches(attackBoxPods) || s.Matches(challengePods) {
+		t.Errorf("Expected the attackbox selector to match only attackbox pods")
+	}
+}
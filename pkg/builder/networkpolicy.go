@@ -17,10 +17,15 @@ limitations under the License.
 package builder
 
 import (
+	"net/netip"
+	"os"
+	"slices"
+
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 )
@@ -30,6 +35,7 @@ import (
 // - Its own challenge (same instance)
 // - DNS (kube-dns)
 // - Internet (optional, excluding private ranges)
+// and only accepts traffic from the ingress controller and pods of the same instance
 func BuildNetworkPolicy(
 	instance *ctfv1alpha1.ChallengeInstance,
 	challenge *ctfv1alpha1.Challenge,
@@ -42,7 +48,6 @@ func BuildNetworkPolicy(
 		return nil
 	}
 
-	attackBoxName := AttackBoxDeploymentName(instance)
 	policyName := NetworkPolicyName(instance)
 	username := SanitizeForLabel(instance.Spec.SourceID)
 
@@ -51,77 +56,35 @@ func BuildNetworkPolicy(
 
 	// Rule 1: Allow DNS (kube-dns in kube-system)
 	if challenge.Spec.Scenario.NetworkPolicy.AllowDNS {
-		port53 := intstr.FromInt32(53)
-		udp := corev1.ProtocolUDP
-		tcp := corev1.ProtocolTCP
-
-		dnsRule := networkingv1.NetworkPolicyEgressRule{
-			To: []networkingv1.NetworkPolicyPeer{
-				{
-					NamespaceSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							"kubernetes.io/metadata.name": "kube-system",
-						},
-					},
-					PodSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							"k8s-app": "kube-dns",
-						},
-					},
-				},
-			},
-			Ports: []networkingv1.NetworkPolicyPort{
-				{
-					Protocol: &udp,
-					Port:     &port53,
-				},
-				{
-					Protocol: &tcp,
-					Port:     &port53,
-				},
-			},
-		}
-		egressRules = append(egressRules, dnsRule)
+		egressRules = append(egressRules, dnsEgressRule())
 	}
 
-	// Rule 2: Allow access to the challenge of the same instance
+	// Rule 2: Allow access to the challenge of the same instance, on the port its Service forwards to only
+	// (policies see the pod port, after the Service translated CHALLENGE_PORT)
+	targetPort, protocol := challengeTargetPort(challenge)
 	challengeRule := networkingv1.NetworkPolicyEgressRule{
 		To: []networkingv1.NetworkPolicyPeer{
 			{
 				PodSelector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{
-						"ctf.io/instance": instance.Name,
-						"app":             "challenge",
-					},
+					MatchLabels: ChallengeSelectorLabels(instance),
 				},
 			},
 		},
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: ptr.To(protocol), Port: ptr.To(intstr.FromInt32(targetPort))},
+		},
 	}
 	egressRules = append(egressRules, challengeRule)
 
-	// Rule 3: Allow Internet access (excluding private ranges)
-	if challenge.Spec.Scenario.NetworkPolicy.AllowInternet {
-		internetRule := networkingv1.NetworkPolicyEgressRule{
-			To: []networkingv1.NetworkPolicyPeer{
-				{
-					IPBlock: &networkingv1.IPBlock{
-						CIDR: "0.0.0.0/0",
-						Except: []string{
-							"10.0.0.0/8",     // Private range
-							"172.16.0.0/12",  // Private range
-							"192.168.0.0/16", // Private range
-						},
-					},
-				},
-			},
-		}
-		egressRules = append(egressRules, internetRule)
+	// Rule 3: Allow Internet access (excluding private ranges) and the author's allowed CIDRs
+	if rule := ipBlockEgressRule(challenge.Spec.Scenario.NetworkPolicy); rule != nil {
+		egressRules = append(egressRules, *rule)
 	}
 
 	return &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      policyName,
-			Namespace: instance.Namespace,
+			Namespace: TargetNamespace(instance),
 			Labels: map[string]string{
 				"component":                    "attackbox",
 				"ctf.io/challenge":             instance.Spec.ChallengeID,
@@ -132,13 +95,16 @@ func BuildNetworkPolicy(
 		},
 		Spec: networkingv1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": attackBoxName,
-				},
+				MatchLabels: AttackBoxSelectorLabels(instance),
 			},
 			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
 				networkingv1.PolicyTypeEgress,
 			},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				ingressControllerRule(),
+				sameInstanceRule(instance),
+			},
 			Egress: egressRules,
 		},
 	}
@@ -148,3 +114,249 @@ func BuildNetworkPolicy(
 func NetworkPolicyName(instance *ctfv1alpha1.ChallengeInstance) string {
 	return instance.Name + "-attackbox-netpol"
 }
+
+// BuildChallengeNetworkPolicy creates a NetworkPolicy isolating the challenge pod of an instance
+// Ingress is denied except from the ingress controller, pods of the same instance (its attackbox) and, for
+// NodePort/LoadBalancer challenges, external clients on the challenge port; egress follows the
+// DNS and internet options. Other instances, including other teams', can't reach the pod.
+func BuildChallengeNetworkPolicy(
+	instance *ctfv1alpha1.ChallengeInstance,
+	challenge *ctfv1alpha1.Challenge,
+) *networkingv1.NetworkPolicy {
+	if challenge.Spec.Scenario.NetworkPolicy == nil || !challenge.Spec.Scenario.NetworkPolicy.Enabled {
+		return nil
+	}
+
+	ingressRules := []networkingv1.NetworkPolicyIngressRule{
+		ingressControllerRule(),
+		sameInstanceRule(instance),
+	}
+
+	// NodePort and LoadBalancer traffic comes straight from the players, on the port the Service forwards to
+	// Any source is allowed: players may be on private ranges, and externalTrafficPolicy Cluster SNATs to node IPs
+	if ExposesService(challenge) && ChallengeServiceType(challenge) != corev1.ServiceTypeClusterIP {
+		targetPort, protocol := challengeTargetPort(challenge)
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			From: []networkingv1.NetworkPolicyPeer{
+				{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0"}},
+			},
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: ptr.To(protocol), Port: ptr.To(intstr.FromInt32(targetPort))},
+			},
+		})
+	}
+
+	egressRules := []networkingv1.NetworkPolicyEgressRule{}
+	if challenge.Spec.Scenario.NetworkPolicy.AllowDNS {
+		egressRules = append(egressRules, dnsEgressRule())
+	}
+	if rule := ipBlockEgressRule(challenge.Spec.Scenario.NetworkPolicy); rule != nil {
+		egressRules = append(egressRules, *rule)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ChallengeNetworkPolicyName(instance),
+			Namespace: TargetNamespace(instance),
+			Labels: map[string]string{
+				"component":                    "challenge",
+				"ctf.io/challenge":             instance.Spec.ChallengeID,
+				"ctf.io/instance":              instance.Name,
+				"ctf.io/source":                SanitizeForLabel(instance.Spec.SourceID),
+				"app.kubernetes.io/managed-by": "chall-operator",
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: ChallengeSelectorLabels(instance),
+			},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+				networkingv1.PolicyTypeEgress,
+			},
+			Ingress: ingressRules,
+			Egress:  egressRules,
+		},
+	}
+}
+
+// ChallengeNetworkPolicyName returns the name of the challenge pod network policy for an instance
+func ChallengeNetworkPolicyName(instance *ctfv1alpha1.ChallengeInstance) string {
+	return instance.Name + "-challenge-netpol"
+}
+
+// getIngressControllerNamespace returns the namespace of the ingress controller from env or fallback
+func getIngressControllerNamespace() string {
+	if namespace := os.Getenv("INGRESS_CONTROLLER_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return "ingress-nginx"
+}
+
+// ingressControllerRule accepts traffic from the ingress controller namespace
+func ingressControllerRule() networkingv1.NetworkPolicyIngressRule {
+	return networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"kubernetes.io/metadata.name": getIngressControllerNamespace(),
+					},
+				},
+			},
+		},
+	}
+}
+
+// sameInstanceRule accepts traffic from pods of the same instance only, so players can't
+// reach another team's pods laterally
+func sameInstanceRule(instance *ctfv1alpha1.ChallengeInstance) networkingv1.NetworkPolicyIngressRule {
+	return networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{
+			{
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"ctf.io/instance": instance.Name,
+					},
+				},
+			},
+		},
+	}
+}
+
+// dnsEgressRule allows DNS queries to kube-dns in kube-system
+func dnsEgressRule() networkingv1.NetworkPolicyEgressRule {
+	port53 := intstr.FromInt32(53)
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+
+	return networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"kubernetes.io/metadata.name": "kube-system",
+					},
+				},
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"k8s-app": "kube-dns",
+					},
+				},
+			},
+		},
+		Ports: []networkingv1.NetworkPolicyPort{
+			{
+				Protocol: &udp,
+				Port:     &port53,
+			},
+			{
+				Protocol: &tcp,
+				Port:     &port53,
+			},
+		},
+	}
+}
+
+// ipBlockEgressRule merges the internet egress (excluding private ranges) with the allowed CIDRs,
+// the denied CIDRs being carved out of both. Returns nil when neither applies.
+func ipBlockEgressRule(policy *ctfv1alpha1.NetworkPolicySpec) *networkingv1.NetworkPolicyEgressRule {
+	allowed, _ := parseCIDRs(policy.AllowedEgressCIDRs)
+	denied, _ := parseCIDRs(policy.DeniedEgressCIDRs)
+
+	var peers []networkingv1.NetworkPolicyPeer
+	if InternetEgressAllowed(policy) {
+		internet := publicIPBlock()
+		internet.Except = appendUncovered(internet.Except, cidrsWithin(netip.MustParsePrefix(internet.CIDR), denied))
+		peers = append(peers, networkingv1.NetworkPolicyPeer{IPBlock: internet})
+	}
+	for _, prefix := range allowed {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{
+				CIDR:   prefix.String(),
+				Except: cidrsWithin(prefix, denied),
+			},
+		})
+	}
+
+	if len(peers) == 0 {
+		return nil
+	}
+	return &networkingv1.NetworkPolicyEgressRule{To: peers}
+}
+
+// EgressModeStrict denies the internet to the instance pods, leaving DNS, the challenge and the allowed CIDRs
+const EgressModeStrict = "Strict"
+
+// InternetEgressAllowed reports whether the instance pods may reach the internet
+// A boolean defaulted to true can't be turned off by clients that omit false values, so Strict wins
+func InternetEgressAllowed(policy *ctfv1alpha1.NetworkPolicySpec) bool {
+	return policy.AllowInternet && policy.EgressMode != EgressModeStrict
+}
+
+// InvalidEgressCIDRs returns the allowed and denied egress CIDRs that can't be parsed and are skipped
+func InvalidEgressCIDRs(challenge *ctfv1alpha1.Challenge) []string {
+	policy := challenge.Spec.Scenario.NetworkPolicy
+	if policy == nil {
+		return nil
+	}
+	_, invalidAllowed := parseCIDRs(policy.AllowedEgressCIDRs)
+	_, invalidDenied := parseCIDRs(policy.DeniedEgressCIDRs)
+	return append(invalidAllowed, invalidDenied...)
+}
+
+// parseCIDRs splits CIDR strings into parsed prefixes, normalized to their network address, and invalid entries
+func parseCIDRs(cidrs []string) ([]netip.Prefix, []string) {
+	var prefixes []netip.Prefix
+	var invalid []string
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			invalid = append(invalid, cidr)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, invalid
+}
+
+// cidrsWithin returns the prefixes strictly inside block, the only ones an IPBlock accepts as exceptions
+func cidrsWithin(block netip.Prefix, prefixes []netip.Prefix) []string {
+	var within []string
+	for _, prefix := range prefixes {
+		if prefix.Addr().Is4() == block.Addr().Is4() && prefix.Bits() > block.Bits() && block.Contains(prefix.Addr()) {
+			within = append(within, prefix.String())
+		}
+	}
+	return within
+}
+
+// appendUncovered appends the prefixes not already contained in an except entry, e.g. a denied private address
+func appendUncovered(except []string, prefixes []string) []string {
+	covered := make([]netip.Prefix, 0, len(except))
+	for _, cidr := range except {
+		covered = append(covered, netip.MustParsePrefix(cidr))
+	}
+	for _, cidr := range prefixes {
+		prefix := netip.MustParsePrefix(cidr)
+		if !slices.ContainsFunc(covered, func(block netip.Prefix) bool {
+			return block.Bits() <= prefix.Bits() && block.Contains(prefix.Addr())
+		}) {
+			except = append(except, cidr)
+			covered = append(covered, prefix)
+		}
+	}
+	return except
+}
+
+// publicIPBlock matches every IPv4 address outside the private ranges
+func publicIPBlock() *networkingv1.IPBlock {
+	return &networkingv1.IPBlock{
+		CIDR: "0.0.0.0/0",
+		Except: []string{
+			"10.0.0.0/8",     // Private range
+			"172.16.0.0/12",  // Private range
+			"192.168.0.0/16", // Private range
+		},
+	}
+}
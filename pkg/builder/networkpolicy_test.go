@@ -0,0 +1,321 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestBuildChallengeNetworkPolicy(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:      "web-chall:latest",
+				Port:       8080,
+				ExposeType: "Ingress",
+			},
+		},
+	}
+
+	if netpol := BuildChallengeNetworkPolicy(instance, challenge); netpol != nil {
+		t.Fatalf("Expected no NetworkPolicy without networkPolicy.enabled, got %s", netpol.Name)
+	}
+
+	// Isolation applies without an attackbox
+	challenge.Spec.Scenario.NetworkPolicy = &ctfv1alpha1.NetworkPolicySpec{Enabled: true, AllowDNS: true}
+	netpol := BuildChallengeNetworkPolicy(instance, challenge)
+	if netpol == nil {
+		t.Fatal("Expected a NetworkPolicy for the challenge pod")
+	}
+	selector := netpol.Spec.PodSelector.MatchLabels
+	if selector["app"] != "challenge" || selector["ctf.io/instance"] != "test-instance" {
+		t.Errorf("Expected the policy to select the instance challenge pod, got %v", selector)
+	}
+	if len(netpol.Spec.PolicyTypes) != 2 {
+		t.Errorf("Expected Ingress and Egress policy types, got %v", netpol.Spec.PolicyTypes)
+	}
+	if len(netpol.Spec.Ingress) != 2 {
+		t.Fatalf("Expected the ingress controller and same instance rules for an Ingress challenge, got %d rules", len(netpol.Spec.Ingress))
+	}
+	if ns := netpol.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"]; ns != "ingress-nginx" {
+		t.Errorf("Expected ingress from the ingress-nginx namespace, got %q", ns)
+	}
+	if len(netpol.Spec.Egress) != 1 {
+		t.Errorf("Expected only the DNS egress rule, got %d rules", len(netpol.Spec.Egress))
+	}
+
+	// NodePort clients are let in on the challenge port
+	challenge.Spec.Scenario.ExposeType = "NodePort"
+	netpol = BuildChallengeNetworkPolicy(instance, challenge)
+	if len(netpol.Spec.Ingress) != 3 {
+		t.Fatalf("Expected ingress controller, same instance and NodePort rules, got %d rules", len(netpol.Spec.Ingress))
+	}
+	nodePortRule := netpol.Spec.Ingress[2]
+	if nodePortRule.Ports[0].Port.IntValue() != 8080 || nodePortRule.From[0].IPBlock == nil {
+		t.Errorf("Expected external ingress on the challenge port, got %+v", nodePortRule)
+	}
+	// Players on private ranges and SNATed node IPs must get in too
+	if block := nodePortRule.From[0].IPBlock; block.CIDR != "0.0.0.0/0" || len(block.Except) != 0 {
+		t.Errorf("Expected ingress from any address, got %+v", block)
+	}
+
+	// Behind the auth proxy, the Service forwards to the proxy port
+	challenge.Spec.Scenario.AuthProxy = &ctfv1alpha1.AuthProxySpec{Enabled: true}
+	netpol = BuildChallengeNetworkPolicy(instance, challenge)
+	if port := netpol.Spec.Ingress[2].Ports[0].Port.IntValue(); port != 8888 {
+		t.Errorf("Expected external ingress on the auth proxy port 8888, got %d", port)
+	}
+}
+
+func TestBuildChallengeNetworkPolicy_IngressControllerNamespace(t *testing.T) {
+	t.Setenv("INGRESS_CONTROLLER_NAMESPACE", "traefik")
+
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Port:          8080,
+				ExposeType:    "Ingress",
+				NetworkPolicy: &ctfv1alpha1.NetworkPolicySpec{Enabled: true},
+			},
+		},
+	}
+
+	rule := BuildChallengeNetworkPolicy(instance, challenge).Spec.Ingress[0]
+	if ns := rule.From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"]; ns != "traefik" {
+		t.Errorf("Expected ingress from the traefik namespace, got %q", ns)
+	}
+}
+
+func TestBuildNetworkPolicy_IngressRules(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "chal-101-alice", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "101", SourceID: "alice"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "101",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:         "web-chall:latest",
+				Port:          8080,
+				AttackBox:     &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+				NetworkPolicy: &ctfv1alpha1.NetworkPolicySpec{Enabled: true},
+			},
+		},
+	}
+
+	policies := map[string]*networkingv1.NetworkPolicy{
+		"attackbox": BuildNetworkPolicy(instance, challenge),
+		"challenge": BuildChallengeNetworkPolicy(instance, challenge),
+	}
+	sameInstancePod := labels.Set{"app": "challenge", "ctf.io/instance": "chal-101-alice"}
+	otherInstancePod := labels.Set{"app": "challenge", "ctf.io/instance": "chal-101-bob"}
+
+	for name, netpol := range policies {
+		hasIngressType := false
+		for _, policyType := range netpol.Spec.PolicyTypes {
+			hasIngressType = hasIngressType || policyType == networkingv1.PolicyTypeIngress
+		}
+		if !hasIngressType {
+			t.Errorf("Expected %s policy to restrict ingress, got types %v", name, netpol.Spec.PolicyTypes)
+		}
+
+		// Pod peers are only selected when they belong to the same instance
+		sameAllowed, otherAllowed := false, false
+		for _, rule := range netpol.Spec.Ingress {
+			for _, peer := range rule.From {
+				if peer.PodSelector == nil || peer.NamespaceSelector != nil {
+					continue
+				}
+				selector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
+				if err != nil {
+					t.Fatalf("Invalid %s pod selector: %v", name, err)
+				}
+				sameAllowed = sameAllowed || selector.Matches(sameInstancePod)
+				otherAllowed = otherAllowed || selector.Matches(otherInstancePod)
+			}
+		}
+		if !sameAllowed {
+			t.Errorf("Expected %s policy to accept pods of the same instance", name)
+		}
+		if otherAllowed {
+			t.Errorf("Expected %s policy to reject pods of another instance", name)
+		}
+	}
+}
+
+func TestBuildNetworkPolicy_EgressCIDRs(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Port:      8080,
+				AttackBox: &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+				NetworkPolicy: &ctfv1alpha1.NetworkPolicySpec{
+					Enabled:            true,
+					AllowInternet:      true,
+					AllowedEgressCIDRs: []string{"10.20.0.0/16", "not-a-cidr"},
+					DeniedEgressCIDRs:  []string{"8.8.8.8/32", "10.20.5.1/32", "300.0.0.0/8"},
+				},
+			},
+		},
+	}
+
+	if invalid := InvalidEgressCIDRs(challenge); !reflect.DeepEqual(invalid, []string{"not-a-cidr", "300.0.0.0/8"}) {
+		t.Errorf("Expected invalid CIDRs [not-a-cidr 300.0.0.0/8], got %v", invalid)
+	}
+
+	netpol := BuildNetworkPolicy(instance, challenge)
+	rule := netpol.Spec.Egress[len(netpol.Spec.Egress)-1]
+	if len(rule.To) != 2 {
+		t.Fatalf("Expected the internet and allowed CIDR peers in one rule, got %+v", rule.To)
+	}
+
+	internet := rule.To[0].IPBlock
+	if internet.CIDR != "0.0.0.0/0" || internet.Except[len(internet.Except)-1] != "8.8.8.8/32" {
+		t.Errorf("Expected the denied public CIDR carved out of the internet, got %+v", internet)
+	}
+
+	allowed := rule.To[1].IPBlock
+	if allowed.CIDR != "10.20.0.0/16" {
+		t.Errorf("Expected allowed CIDR 10.20.0.0/16, got %s", allowed.CIDR)
+	}
+	if !reflect.DeepEqual(allowed.Except, []string{"10.20.5.1/32"}) {
+		t.Errorf("Expected only the denied CIDR inside the allowed range as exception, got %v", allowed.Except)
+	}
+
+	// Allowed CIDRs stay reachable without internet access
+	challenge.Spec.Scenario.NetworkPolicy.AllowInternet = false
+	rule = BuildChallengeNetworkPolicy(instance, challenge).Spec.Egress[0]
+	if len(rule.To) != 1 || rule.To[0].IPBlock.CIDR != "10.20.0.0/16" {
+		t.Errorf("Expected only the allowed CIDR peer, got %+v", rule.To)
+	}
+}
+
+func TestBuildNetworkPolicy_ChallengePort(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Port:          1337,
+				Protocol:      "UDP",
+				AttackBox:     &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+				NetworkPolicy: &ctfv1alpha1.NetworkPolicySpec{Enabled: true},
+			},
+		},
+	}
+
+	challengeRule := func() networkingv1.NetworkPolicyEgressRule {
+		for _, rule := range BuildNetworkPolicy(instance, challenge).Spec.Egress {
+			if len(rule.To) == 1 && rule.To[0].PodSelector != nil {
+				return rule
+			}
+		}
+		t.Fatal("Expected an egress rule to the challenge pod")
+		return networkingv1.NetworkPolicyEgressRule{}
+	}
+
+	rule := challengeRule()
+	if len(rule.Ports) != 1 || rule.Ports[0].Port.IntValue() != 1337 || *rule.Ports[0].Protocol != corev1.ProtocolUDP {
+		t.Errorf("Expected egress restricted to UDP/1337, got %+v", rule.Ports)
+	}
+
+	// Behind the auth-proxy, the Service forwards to the proxy port instead
+	challenge.Spec.Scenario.AuthProxy = &ctfv1alpha1.AuthProxySpec{Enabled: true}
+	rule = challengeRule()
+	if len(rule.Ports) != 1 || rule.Ports[0].Port.IntValue() != 8888 || *rule.Ports[0].Protocol != corev1.ProtocolTCP {
+		t.Errorf("Expected egress restricted to TCP/8888, got %+v", rule.Ports)
+	}
+}
+
+func TestBuildNetworkPolicy_StrictEgress(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Port:      8080,
+				AttackBox: &ctfv1alpha1.AttackBoxSpec{Enabled: true},
+				NetworkPolicy: &ctfv1alpha1.NetworkPolicySpec{
+					Enabled:       true,
+					AllowDNS:      true,
+					AllowInternet: true, // Defaulted by the API server, overridden by Strict
+					EgressMode:    EgressModeStrict,
+				},
+			},
+		},
+	}
+
+	// Only DNS and the instance's challenge remain
+	egress := BuildNetworkPolicy(instance, challenge).Spec.Egress
+	if len(egress) != 2 {
+		t.Fatalf("Expected DNS and challenge egress rules only, got %+v", egress)
+	}
+	if egress[0].To[0].NamespaceSelector == nil || len(egress[0].Ports) == 0 {
+		t.Errorf("Expected the first rule to allow DNS, got %+v", egress[0])
+	}
+	if egress[1].To[0].PodSelector == nil || egress[1].To[0].PodSelector.MatchLabels["ctf.io/instance"] != "test-instance" {
+		t.Errorf("Expected the second rule to allow the instance's challenge, got %+v", egress[1])
+	}
+	for _, rule := range egress {
+		for _, peer := range rule.To {
+			if peer.IPBlock != nil {
+				t.Errorf("Expected no IP block egress in strict mode, got %+v", peer.IPBlock)
+			}
+		}
+	}
+
+	// The allowlist is the only way out
+	challenge.Spec.Scenario.NetworkPolicy.AllowedEgressCIDRs = []string{"203.0.113.0/24"}
+	egress = BuildNetworkPolicy(instance, challenge).Spec.Egress
+	if len(egress) != 3 {
+		t.Fatalf("Expected DNS, challenge and allowlist egress rules, got %+v", egress)
+	}
+	allowlist := egress[2].To
+	if len(allowlist) != 1 || allowlist[0].IPBlock == nil || allowlist[0].IPBlock.CIDR != "203.0.113.0/24" {
+		t.Errorf("Expected only the allowed CIDR, got %+v", allowlist)
+	}
+
+	// The challenge pod is held to the same mode
+	for _, rule := range BuildChallengeNetworkPolicy(instance, challenge).Spec.Egress {
+		for _, peer := range rule.To {
+			if peer.IPBlock != nil && peer.IPBlock.CIDR == "0.0.0.0/0" {
+				t.Error("Expected no internet egress for the challenge pod in strict mode")
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// persistenceVolumeName is the pod volume backed by the instance PVC
+const persistenceVolumeName = "challenge-data"
+
+// BuildPersistentVolumeClaim creates the PVC for a stateful challenge instance
+// Returns nil if persistence is not configured
+func BuildPersistentVolumeClaim(instance *ctfv1alpha1.ChallengeInstance, challenge *ctfv1alpha1.Challenge) *corev1.PersistentVolumeClaim {
+	persistence := challenge.Spec.Scenario.Persistence
+	if persistence == nil {
+		return nil
+	}
+
+	size := persistence.Size
+	if size.IsZero() {
+		size = resource.MustParse("1Gi")
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PersistentVolumeClaimName(instance),
+			Namespace: TargetNamespace(instance),
+			Labels: withBillingLabel(map[string]string{
+				"ctf.io/challenge":             instance.Spec.ChallengeID,
+				"ctf.io/instance":              instance.Name,
+				"ctf.io/source":                SanitizeForLabel(instance.Spec.SourceID),
+				"app.kubernetes.io/name":       "challenge-instance",
+				"app.kubernetes.io/instance":   instance.Name,
+				"app.kubernetes.io/managed-by": "chall-operator",
+			}, instance),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if persistence.StorageClassName != "" {
+		pvc.Spec.StorageClassName = &persistence.StorageClassName
+	}
+	return pvc
+}
+
+// PersistentVolumeClaimName returns the name of the PVC for an instance
+func PersistentVolumeClaimName(instance *ctfv1alpha1.ChallengeInstance) string {
+	return instance.Name + "-data"
+}
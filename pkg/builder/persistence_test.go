@@ -0,0 +1,86 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestBuildPersistentVolumeClaim(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-1", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-1",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "postgres:16",
+				Port:  5432,
+				Persistence: &ctfv1alpha1.PersistenceSpec{
+					Size:             resource.MustParse("2Gi"),
+					StorageClassName: "fast",
+					MountPath:        "/var/lib/postgresql/data",
+				},
+			},
+		},
+	}
+
+	pvc := BuildPersistentVolumeClaim(instance, challenge)
+	if pvc == nil {
+		t.Fatal("Expected PVC, got nil")
+	}
+	if pvc.Name != "test-instance-data" {
+		t.Errorf("Expected PVC name test-instance-data, got %s", pvc.Name)
+	}
+	if storage := pvc.Spec.Resources.Requests.Storage(); storage.String() != "2Gi" {
+		t.Errorf("Expected 2Gi request, got %s", storage.String())
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "fast" {
+		t.Errorf("Expected storage class fast, got %v", pvc.Spec.StorageClassName)
+	}
+
+	deployment := mustBuildDeployment(t, instance, challenge)
+	if deployment.Spec.Strategy.Type != appsv1.RecreateDeploymentStrategyType {
+		t.Errorf("Expected Recreate strategy, got %s", deployment.Spec.Strategy.Type)
+	}
+
+	podSpec := deployment.Spec.Template.Spec
+	last := podSpec.Volumes[len(podSpec.Volumes)-1]
+	if last.PersistentVolumeClaim == nil || last.PersistentVolumeClaim.ClaimName != "test-instance-data" {
+		t.Errorf("Expected volume backed by test-instance-data, got %v", podSpec.Volumes)
+	}
+	mounts := podSpec.Containers[0].VolumeMounts
+	if mounts[len(mounts)-1].MountPath != "/var/lib/postgresql/data" {
+		t.Errorf("Expected mount at /var/lib/postgresql/data, got %v", mounts)
+	}
+}
+
+func TestBuildPersistentVolumeClaim_Disabled(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{ObjectMeta: metav1.ObjectMeta{Name: "test-instance"}}
+	challenge := &ctfv1alpha1.Challenge{}
+
+	if pvc := BuildPersistentVolumeClaim(instance, challenge); pvc != nil {
+		t.Errorf("Expected no PVC without persistence, got %v", pvc)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// prepullPauseImage keeps the prepull pod alive after the challenge image was pulled
+const prepullPauseImage = "registry.k8s.io/pause:3.10"
+
+// prepullToolsImage provides the static busybox binary the challenge image is started with
+const prepullToolsImage = "busybox:1.36"
+
+// prepullToolsVolumeName is the emptyDir the busybox binary is copied to
+const prepullToolsVolumeName = "prepull-tools"
+
+// prepullToolsPath is where the busybox binary is mounted in both init containers
+const prepullToolsPath = "/prepull"
+
+// BuildPrepullDaemonSet creates a DaemonSet that pulls the challenge image onto every node it can run on
+// The challenge image runs as an init container started with a static busybox copied in from
+// an emptyDir, so it exits cleanly even on distroless or scratch images without a shell
+// Returns nil if prepull is not enabled
+func BuildPrepullDaemonSet(challenge *ctfv1alpha1.Challenge) *appsv1.DaemonSet {
+	if !challenge.Spec.Scenario.Prepull {
+		return nil
+	}
+
+	labels := map[string]string{
+		"app":                          "challenge-prepull",
+		"ctf.io/challenge":             challenge.Spec.ID,
+		"app.kubernetes.io/name":       "challenge-prepull",
+		"app.kubernetes.io/instance":   challenge.Name,
+		"app.kubernetes.io/managed-by": "chall-operator",
+	}
+
+	minimal := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1m"),
+			corev1.ResourceMemory: resource.MustParse("8Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+		},
+	}
+
+	daemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PrepullDaemonSetName(challenge),
+			Namespace: challenge.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app":                        "challenge-prepull",
+					"app.kubernetes.io/instance": challenge.Name,
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: podTemplateLabels(labels),
+				},
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: imagePullSecrets(challenge),
+					InitContainers: []corev1.Container{
+						{
+							Name:         "tools",
+							Image:        prepullToolsImage,
+							Command:      []string{"cp", "/bin/busybox", prepullToolsPath + "/busybox"},
+							Resources:    minimal,
+							VolumeMounts: []corev1.VolumeMount{{Name: prepullToolsVolumeName, MountPath: prepullToolsPath}},
+						},
+						{
+							Name:            "prepull",
+							Image:           challenge.Spec.Scenario.Image,
+							ImagePullPolicy: imagePullPolicy(challenge.Spec.Scenario.Image, challenge.Spec.Scenario.ImagePullPolicy),
+							Command:         []string{prepullToolsPath + "/busybox", "true"},
+							Resources:       minimal,
+							VolumeMounts:    []corev1.VolumeMount{{Name: prepullToolsVolumeName, MountPath: prepullToolsPath, ReadOnly: true}},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:      "pause",
+							Image:     prepullPauseImage,
+							Resources: minimal,
+						},
+					},
+					Volumes: []corev1.Volume{{
+						Name:         prepullToolsVolumeName,
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}
+	// Land on the nodes the challenge pods are scheduled on, tainted challenge nodes included
+	applyScheduling(&daemonSet.Spec.Template.Spec, challenge)
+	return daemonSet
+}
+
+// PrepullDaemonSetName returns the name of the prepull DaemonSet for a challenge
+func PrepullDaemonSetName(challenge *ctfv1alpha1.Challenge) string {
+	return challenge.Name + "-prepull"
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestBuildPrepullDaemonSet(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "big-chall", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "big-chall",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:   "registry.local/big:latest",
+				Port:    1337,
+				Prepull: true,
+			},
+		},
+	}
+
+	daemonSet := BuildPrepullDaemonSet(challenge)
+	if daemonSet == nil {
+		t.Fatal("Expected a prepull DaemonSet")
+	}
+	if daemonSet.Name != "big-chall-prepull" {
+		t.Errorf("Expected name big-chall-prepull, got %s", daemonSet.Name)
+	}
+	initContainers := daemonSet.Spec.Template.Spec.InitContainers
+	if len(initContainers) != 2 || initContainers[1].Image != "registry.local/big:latest" {
+		t.Fatalf("Expected the challenge image as last init container, got %v", initContainers)
+	}
+	// The challenge image may have no shell: it must run the busybox copied in by the first init container
+	if command := initContainers[1].Command; len(command) == 0 || command[0] != "/prepull/busybox" {
+		t.Errorf("Expected the challenge image to run the copied busybox, got %v", command)
+	}
+	if len(initContainers[1].VolumeMounts) != 1 || initContainers[1].VolumeMounts[0].Name != initContainers[0].VolumeMounts[0].Name {
+		t.Errorf("Expected both init containers to share the tools volume, got %v", initContainers)
+	}
+
+	challenge.Spec.Scenario.Prepull = false
+	if daemonSet := BuildPrepullDaemonSet(challenge); daemonSet != nil {
+		t.Errorf("Expected no DaemonSet without prepull, got %v", daemonSet)
+	}
+}
+
+func TestBuildPrepullDaemonSet_Scheduling(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		ObjectMeta: metav1.ObjectMeta{Name: "big-chall", Namespace: "ctf-instances"},
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "big-chall",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:        "registry.local/big:latest",
+				Port:         1337,
+				Prepull:      true,
+				NodeSelector: map[string]string{"ctf.io/pool": "challenges"},
+				Tolerations: []corev1.Toleration{
+					{Key: "ctf.io/challenges", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				},
+			},
+		},
+	}
+
+	podSpec := BuildPrepullDaemonSet(challenge).Spec.Template.Spec
+	if podSpec.NodeSelector["ctf.io/pool"] != "challenges" {
+		t.Errorf("Expected the challenge nodeSelector, got %v", podSpec.NodeSelector)
+	}
+	if len(podSpec.Tolerations) != 1 || podSpec.Tolerations[0].Key != "ctf.io/challenges" {
+		t.Errorf("Expected the challenge tolerations, got %v", podSpec.Tolerations)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// getDefaultPullSecret returns the pull Secret added to every generated pod from env
+// Empty disables it
+func getDefaultPullSecret() string {
+	return os.Getenv("DEFAULT_PULL_SECRET")
+}
+
+// imagePullSecrets returns the pod pull Secrets: the challenge ones plus the operator default
+func imagePullSecrets(challenge *ctfv1alpha1.Challenge) []corev1.LocalObjectReference {
+	var refs []corev1.LocalObjectReference
+	seen := map[string]bool{}
+	names := append([]string{getDefaultPullSecret()}, challenge.Spec.Scenario.ImagePullSecrets...)
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		refs = append(refs, corev1.LocalObjectReference{Name: name})
+	}
+	return refs
+}
+
+// imagePullPolicy returns the explicit policy, or Always for :latest images and IfNotPresent otherwise
+func imagePullPolicy(image string, policy corev1.PullPolicy) corev1.PullPolicy {
+	if policy != "" {
+		return policy
+	}
+	if imageTag(image) == "latest" {
+		return corev1.PullAlways
+	}
+	return corev1.PullIfNotPresent
+}
+
+// imageTag returns the tag of an image reference, "latest" when untagged and "" when pinned by digest
+func imageTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return "latest"
+}
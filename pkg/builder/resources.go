@@ -0,0 +1,116 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// getDefaultResourceRequests returns the requests applied when a scenario leaves them unset from env
+// DEFAULT_RESOURCE_REQUESTS is a comma-separated list of resource=quantity pairs, e.g. "cpu=100m,memory=128Mi"
+func getDefaultResourceRequests() corev1.ResourceList {
+	return parseResourceList(os.Getenv("DEFAULT_RESOURCE_REQUESTS"))
+}
+
+// getDefaultResourceLimits returns the limits applied when a scenario leaves them unset from env
+// DEFAULT_RESOURCE_LIMITS uses the DEFAULT_RESOURCE_REQUESTS format
+func getDefaultResourceLimits() corev1.ResourceList {
+	return parseResourceList(os.Getenv("DEFAULT_RESOURCE_LIMITS"))
+}
+
+// getMaxResources returns the hard cap on challenge requests and limits from env
+// MAX_RESOURCES uses the DEFAULT_RESOURCE_REQUESTS format
+func getMaxResources() corev1.ResourceList {
+	return parseResourceList(os.Getenv("MAX_RESOURCES"))
+}
+
+// parseResourceList parses "name=quantity" pairs, skipping invalid entries
+func parseResourceList(value string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	for _, pair := range strings.Split(value, ",") {
+		name, quantity, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(quantity))
+		if err != nil {
+			continue
+		}
+		list[corev1.ResourceName(strings.TrimSpace(name))] = q
+	}
+	return list
+}
+
+// ChallengeResources returns the challenge container resources with the operator defaults and cap applied
+// Unset requests and limits get the defaults; anything above MAX_RESOURCES is clamped down,
+// and each clamp is described in the returned list so the caller can warn about it
+func ChallengeResources(challenge *ctfv1alpha1.Challenge) (corev1.ResourceRequirements, []string) {
+	resources := *challenge.Spec.Scenario.Resources.DeepCopy()
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+
+	for name, q := range getDefaultResourceRequests() {
+		if _, ok := resources.Requests[name]; !ok {
+			resources.Requests[name] = q
+		}
+	}
+	for name, q := range getDefaultResourceLimits() {
+		if _, ok := resources.Limits[name]; ok {
+			continue
+		}
+		// A default limit below an explicit request would make the pod invalid
+		if request, ok := resources.Requests[name]; ok && request.Cmp(q) > 0 {
+			q = request
+		}
+		resources.Limits[name] = q
+	}
+
+	var clamped []string
+	caps := getMaxResources()
+	for _, kind := range []struct {
+		field string
+		list  corev1.ResourceList
+	}{{"requests", resources.Requests}, {"limits", resources.Limits}} {
+		for name, q := range kind.list {
+			if capped, ok := caps[name]; ok && q.Cmp(capped) > 0 {
+				clamped = append(clamped, fmt.Sprintf("%s.%s %s to %s", kind.field, name, q.String(), capped.String()))
+				kind.list[name] = capped
+			}
+		}
+	}
+	sort.Strings(clamped)
+
+	if len(resources.Requests) == 0 {
+		resources.Requests = nil
+	}
+	if len(resources.Limits) == 0 {
+		resources.Limits = nil
+	}
+	return resources, clamped
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/package builder
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestChallengeResources_Defaults(t *testing.T) {
+	t.Setenv("DEFAULT_RESOURCE_REQUESTS", "cpu=100m,memory=128Mi")
+	t.Setenv("DEFAULT_RESOURCE_LIMITS", "cpu=500m, memory=512Mi,bogus")
+
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "nginx:alpine",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			},
+		},
+	}
+
+	resources, clamped := ChallengeResources(challenge)
+	if len(clamped) != 0 {
+		t.Errorf("Expected no clamping, got %v", clamped)
+	}
+
+	expected := map[string]string{
+		"requests.cpu":    "1",
+		"requests.memory": "128Mi",
+		"limits.cpu":      "1", // raised to the explicit request
+		"limits.memory":   "512Mi",
+	}
+	got := map[string]string{}
+	for name, q := range resources.Requests {
+		got["requests."+string(name)] = q.String()
+	}
+	for name, q := range resources.Limits {
+		got["limits."+string(name)] = q.String()
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected resources %v, got %v", expected, got)
+	}
+
+	if _, ok := challenge.Spec.Scenario.Resources.Requests[corev1.ResourceMemory]; ok {
+		t.Errorf("Expected the Challenge spec to be left untouched")
+	}
+}
+
+func TestChallengeResources_Clamp(t *testing.T) {
+	t.Setenv("MAX_RESOURCES", "cpu=2,memory=1Gi")
+
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image: "nginx:alpine",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("8"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				},
+			},
+		},
+	}
+
+	resources, clamped := ChallengeResources(challenge)
+
+	expectedClamps := []string{"limits.cpu 8 to 2", "limits.memory 4Gi to 1Gi"}
+	if !reflect.DeepEqual(clamped, expectedClamps) {
+		t.Errorf("Expected clamps %v, got %v", expectedClamps, clamped)
+	}
+	if cpu := resources.Limits[corev1.ResourceCPU]; cpu.String() != "2" {
+		t.Errorf("Expected cpu limit 2, got %s", cpu.String())
+	}
+	if memory := resources.Requests[corev1.ResourceMemory]; memory.String() != "512Mi" {
+		t.Errorf("Expected memory request below the cap to be kept, got %s", memory.String())
+	}
+
+	instance := &ctfv1alpha1.ChallengeInstance{}
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+	if memory := container.Resources.Limits[corev1.ResourceMemory]; memory.String() != "1Gi" {
+		t.Errorf("Expected the Deployment to use the clamped memory limit, got %s", memory.String())
+	}
+}
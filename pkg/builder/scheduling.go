@@ -0,0 +1,90 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+// getDefaultNodeSelector returns the node selector of challenges that don't set their own from env
+// DEFAULT_NODE_SELECTOR is a comma-separated list of key=value pairs, e.g. "node-role=ctf"
+func getDefaultNodeSelector() map[string]string {
+	return parseLabelPairs(os.Getenv("DEFAULT_NODE_SELECTOR"))
+}
+
+// getDefaultTolerations returns the tolerations of challenges that don't set their own from env
+// DEFAULT_TOLERATIONS uses the kubectl taint syntax, comma-separated: "dedicated=ctf:NoSchedule,gpu:NoExecute"
+func getDefaultTolerations() []corev1.Toleration {
+	return parseTolerations(os.Getenv("DEFAULT_TOLERATIONS"))
+}
+
+// parseTolerations parses key[=value]:effect entries; a key without value tolerates any value
+func parseTolerations(value string) []corev1.Toleration {
+	var tolerations []corev1.Toleration
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		taint, effect, _ := strings.Cut(entry, ":")
+		key, val, hasValue := strings.Cut(taint, "=")
+		toleration := corev1.Toleration{
+			Key:      strings.TrimSpace(key),
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffect(strings.TrimSpace(effect)),
+		}
+		if hasValue {
+			toleration.Operator = corev1.TolerationOpEqual
+			toleration.Value = strings.TrimSpace(val)
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	return tolerations
+}
+
+// applyScheduling sets the challenge node selector, tolerations and affinity on a pod spec,
+// falling back to the operator defaults for the node selector and tolerations
+func applyScheduling(podSpec *corev1.PodSpec, challenge *ctfv1alpha1.Challenge) {
+	scenario := challenge.Spec.Scenario
+
+	podSpec.NodeSelector = getDefaultNodeSelector()
+	if len(scenario.NodeSelector) > 0 {
+		podSpec.NodeSelector = make(map[string]string, len(scenario.NodeSelector))
+		for k, v := range scenario.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	if len(podSpec.NodeSelector) == 0 {
+		podSpec.NodeSelector = nil
+	}
+
+	podSpec.Tolerations = getDefaultTolerations()
+	if len(scenario.Tolerations) > 0 {
+		// Deep copies, tolerationSeconds must not point into the cached Challenge
+		podSpec.Tolerations = make([]corev1.Toleration, len(scenario.Tolerations))
+		for i := range scenario.Tolerations {
+			scenario.Tolerations[i].DeepCopyInto(&podSpec.Tolerations[i])
+		}
+	}
+
+	podSpec.Affinity = scenario.Affinity.DeepCopy()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
+)
+
+func TestBuildDeployment_Scheduling(t *testing.T) {
+	t.Setenv("DEFAULT_NODE_SELECTOR", "node-role=shared")
+	t.Setenv("DEFAULT_TOLERATIONS", "shared:NoSchedule")
+
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+	challenge.Spec.Scenario.NodeSelector = map[string]string{"node-role": "ctf"}
+	challenge.Spec.Scenario.Tolerations = []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ctf", Effect: corev1.TaintEffectNoSchedule},
+	}
+	challenge.Spec.Scenario.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"}},
+					},
+				}},
+			},
+		},
+	}
+
+	pods := map[string]corev1.PodSpec{
+		"challenge": mustBuildDeployment(t, instance, challenge).Spec.Template.Spec,
+		"attackbox": BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec,
+	}
+	for name, podSpec := range pods {
+		if !reflect.DeepEqual(podSpec.NodeSelector, challenge.Spec.Scenario.NodeSelector) {
+			t.Errorf("Expected %s node selector %v, got %v", name, challenge.Spec.Scenario.NodeSelector, podSpec.NodeSelector)
+		}
+		if !reflect.DeepEqual(podSpec.Tolerations, challenge.Spec.Scenario.Tolerations) {
+			t.Errorf("Expected %s tolerations %v, got %v", name, challenge.Spec.Scenario.Tolerations, podSpec.Tolerations)
+		}
+		if !reflect.DeepEqual(podSpec.Affinity, challenge.Spec.Scenario.Affinity) {
+			t.Errorf("Expected %s affinity %+v, got %+v", name, challenge.Spec.Scenario.Affinity, podSpec.Affinity)
+		}
+	}
+}
+
+func TestBuildDeployment_TolerationSurvives(t *testing.T) {
+	instance, challenge := newTestObjects()
+	challenge.Spec.Scenario.AttackBox = &ctfv1alpha1.AttackBoxSpec{Enabled: true}
+	challenge.Spec.Scenario.Tolerations = []corev1.Toleration{{
+		Key:               "sandbox",
+		Operator:          corev1.TolerationOpExists,
+		Effect:            corev1.TaintEffectNoExecute,
+		TolerationSeconds: ptr.To(int64(300)),
+	}}
+
+	for name, podSpec := range map[string]corev1.PodSpec{
+		"challenge": mustBuildDeployment(t, instance, challenge).Spec.Template.Spec,
+		"attackbox": BuildAttackBoxDeployment(instance, challenge).Spec.Template.Spec,
+	} {
+		if !reflect.DeepEqual(podSpec.Tolerations, challenge.Spec.Scenario.Tolerations) {
+			t.Fatalf("Expected %s tolerations %v, got %v", name, challenge.Spec.Scenario.Tolerations, podSpec.Tolerations)
+		}
+		if podSpec.Tolerations[0].TolerationSeconds == challenge.Spec.Scenario.Tolerations[0].TolerationSeconds {
+			t.Errorf("Expected %s tolerationSeconds to be copied, not shared with the Challenge", name)
+		}
+	}
+}
+
+func TestBuildDeployment_DefaultScheduling(t *testing.T) {
+	t.Setenv("DEFAULT_NODE_SELECTOR", "node-role=ctf")
+	t.Setenv("DEFAULT_TOLERATIONS", "dedicated=ctf:NoSchedule")
+
+	instance, challenge := newTestObjects()
+	podSpec := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec
+
+	if !reflect.DeepEqual(podSpec.NodeSelector, map[string]string{"node-role": "ctf"}) {
+		t.Errorf("Expected default node selector node-role=ctf, got %v", podSpec.NodeSelector)
+	}
+	expected := []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ctf", Effect: corev1.TaintEffectNoSchedule},
+	}
+	if !reflect.DeepEqual(podSpec.Tolerations, expected) {
+		t.Errorf("Expected default tolerations %v, got %v", expected, podSpec.Tolerations)
+	}
+	if podSpec.Affinity != nil {
+		t.Errorf("Expected no affinity, got %+v", podSpec.Affinity)
+	}
+}
+
+func TestParseTolerations(t *testing.T) {
+	tolerations := parseTolerations("dedicated=ctf:NoSchedule, gpu:NoExecute,maintenance")
+	expected := []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ctf", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: "maintenance", Operator: corev1.TolerationOpExists},
+	}
+	if !reflect.DeepEqual(tolerations, expected) {
+		t.Errorf("Expected %v, got %v", expected, tolerations)
+	}
+
+	if tolerations := parseTolerations(""); tolerations != nil {
+		t.Errorf("Expected no tolerations for empty value, got %v", tolerations)
+	}
+}
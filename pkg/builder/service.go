@@ -18,20 +18,64 @@ package builder
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 )
 
+// ExposeTypeNone marks worker-only challenges, which get no Service or Ingress
+const ExposeTypeNone = "None"
+
+// ConnectionInfoNone is the connection info reported for worker-only challenges
+const ConnectionInfoNone = "N/A"
+
+// ExposesService reports whether the challenge accepts inbound connections through a Service
+func ExposesService(challenge *ctfv1alpha1.Challenge) bool {
+	return challenge.Spec.Scenario.ExposeType != ExposeTypeNone
+}
+
+// ChallengeProtocol returns the protocol of the challenge port, defaulting to TCP
+func ChallengeProtocol(challenge *ctfv1alpha1.Challenge) corev1.Protocol {
+	if challenge.Spec.Scenario.Protocol == string(corev1.ProtocolUDP) {
+		return corev1.ProtocolUDP
+	}
+	return corev1.ProtocolTCP
+}
+
+// ChallengeServiceType returns the type of the instance Service
+// Without an explicit exposeType, ingress-enabled challenges get a ClusterIP Service and others a NodePort
+func ChallengeServiceType(challenge *ctfv1alpha1.Challenge) corev1.ServiceType {
+	switch challenge.Spec.Scenario.ExposeType {
+	case "LoadBalancer":
+		return corev1.ServiceTypeLoadBalancer
+	case "ClusterIP", "Ingress":
+		return corev1.ServiceTypeClusterIP
+	case "":
+		if challenge.Spec.Scenario.Ingress != nil && challenge.Spec.Scenario.Ingress.Enabled {
+			return corev1.ServiceTypeClusterIP
+		}
+	}
+	return corev1.ServiceTypeNodePort
+}
+
 // BuildService creates a Service for a ChallengeInstance based on the Challenge template
+// Returns nil for worker-only challenges (ExposeType None)
 func BuildService(
 	instance *ctfv1alpha1.ChallengeInstance,
 	challenge *ctfv1alpha1.Challenge,
 ) *corev1.Service {
-	labels := map[string]string{
+	if !ExposesService(challenge) {
+		return nil
+	}
+
+	labels := withBillingLabel(map[string]string{
 		"app":                          "challenge",
 		"ctf.io/challenge":             instance.Spec.ChallengeID,
 		"ctf.io/instance":              instance.Name,
@@ -39,47 +83,68 @@ func BuildService(
 		"app.kubernetes.io/name":       "challenge-instance",
 		"app.kubernetes.io/instance":   instance.Name,
 		"app.kubernetes.io/managed-by": "chall-operator",
-	}
+	}, instance)
 
 	// Determine service type based on challenge config
-	serviceType := corev1.ServiceTypeNodePort
-	switch challenge.Spec.Scenario.ExposeType {
-	case "LoadBalancer":
-		serviceType = corev1.ServiceTypeLoadBalancer
-	case "Ingress":
-		serviceType = corev1.ServiceTypeClusterIP
-	}
+	serviceType := ChallengeServiceType(challenge)
 
 	serviceName := ServiceName(instance)
 
-	// Determine target port: if auth-proxy is enabled, target port 8888 (auth-proxy)
-	// otherwise target the challenge port directly
-	targetPort := challenge.Spec.Scenario.Port
-	if challenge.Spec.Scenario.AuthProxy != nil && challenge.Spec.Scenario.AuthProxy.Enabled {
-		targetPort = 8888 // Auth proxy listens on 8888
+	targetPort, protocol := challengeTargetPort(challenge)
+	portName := "http"
+	if protocol == corev1.ProtocolUDP {
+		portName = "udp"
 	}
 
-	return &corev1.Service{
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceName,
-			Namespace: instance.Namespace,
+			Namespace: TargetNamespace(instance),
 			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
-			Type: serviceType,
-			Selector: map[string]string{
-				"ctf.io/instance": instance.Name,
-			},
+			Type:     serviceType,
+			Selector: ChallengeSelectorLabels(instance),
 			Ports: []corev1.ServicePort{
 				{
-					Name:       "http",
-					Port:       80,
+					Name:       portName,
+					Port:       challengeServicePort,
 					TargetPort: intstr.FromInt32(targetPort),
-					Protocol:   corev1.ProtocolTCP,
+					Protocol:   protocol,
 				},
 			},
 		},
 	}
+
+	// The API server rejects an external traffic policy on ClusterIP Services
+	if serviceType != corev1.ServiceTypeClusterIP {
+		service.Spec.ExternalTrafficPolicy = challenge.Spec.Scenario.ExternalTrafficPolicy
+	}
+
+	// Keep clients on the same pod when replicas don't share session storage
+	if affinity := challenge.Spec.Scenario.SessionAffinity; affinity != nil && affinity.Enabled {
+		timeout := affinity.TimeoutSeconds
+		if timeout <= 0 {
+			timeout = corev1.DefaultClientIPServiceAffinitySeconds
+		}
+		service.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+		service.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+			ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: ptr.To(timeout)},
+		}
+	}
+	return service
+}
+
+// challengeServicePort is the instance Service port, forwarded to the challenge or auth-proxy port
+const challengeServicePort int32 = 80
+
+// challengeTargetPort returns the pod port and protocol the instance Service forwards to:
+// the auth-proxy port 8888 when it is enabled, otherwise the challenge port
+func challengeTargetPort(challenge *ctfv1alpha1.Challenge) (int32, corev1.Protocol) {
+	if challenge.Spec.Scenario.AuthProxy != nil && challenge.Spec.Scenario.AuthProxy.Enabled {
+		return 8888, corev1.ProtocolTCP
+	}
+	return challenge.Spec.Scenario.Port, ChallengeProtocol(challenge)
 }
 
 // ServiceName returns the name of the service for an instance
@@ -87,20 +152,48 @@ func ServiceName(instance *ctfv1alpha1.ChallengeInstance) string {
 	return instance.Name + "-svc"
 }
 
+// ServiceHost returns the in-cluster DNS name of the instance Service
+func ServiceHost(instance *ctfv1alpha1.ChallengeInstance) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", ServiceName(instance), TargetNamespace(instance))
+}
+
+// WarmupURL returns the URL of the challenge warmup request, straight to the challenge port of the pod
+// so an auth proxy in front of it doesn't answer instead
+// Returns "" when the challenge has no warmup or isn't a TCP challenge behind a Service
+func WarmupURL(challenge *ctfv1alpha1.Challenge, podIP string) string {
+	warmup := challenge.Spec.Scenario.Warmup
+	if warmup == nil || !ExposesService(challenge) || ChallengeProtocol(challenge) != corev1.ProtocolTCP {
+		return ""
+	}
+	path := warmup.Path
+	if path == "" {
+		path = "/"
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "http://" + net.JoinHostPort(podIP, strconv.Itoa(int(challenge.Spec.Scenario.Port))) + path
+}
+
 // GetConnectionInfo extracts connection information from a Service
 // Returns a string like "nc <nodeIP> <nodePort>" for NodePort services
-// or "nc <loadBalancerIP> <port>" for LoadBalancer services
+// or "nc <loadBalancerIP> <port>" for LoadBalancer services, with "nc -u" for UDP ports.
+// Internal-only ClusterIP services get a "kubectl port-forward" hint for admins and CI
+// (TCP only, port-forward does not carry UDP)
 func GetConnectionInfo(service *corev1.Service, nodeIP string) string {
 	if service == nil || len(service.Spec.Ports) == 0 {
 		return ""
 	}
 
 	port := service.Spec.Ports[0]
+	nc := "nc"
+	if port.Protocol == corev1.ProtocolUDP {
+		nc = "nc -u"
+	}
 
 	switch service.Spec.Type {
 	case corev1.ServiceTypeNodePort:
 		if port.NodePort > 0 {
-			return fmt.Sprintf("nc %s %d", nodeIP, port.NodePort)
+			return fmt.Sprintf("%s %s %d", nc, nodeIP, port.NodePort)
 		}
 	case corev1.ServiceTypeLoadBalancer:
 		if len(service.Status.LoadBalancer.Ingress) > 0 {
@@ -110,9 +203,13 @@ func GetConnectionInfo(service *corev1.Service, nodeIP string) string {
 				host = ingress.Hostname
 			}
 			if host != "" {
-				return fmt.Sprintf("nc %s %d", host, port.Port)
+				return fmt.Sprintf("%s %s %d", nc, host, port.Port)
 			}
 		}
+	case corev1.ServiceTypeClusterIP:
+		if port.Protocol != corev1.ProtocolUDP {
+			return fmt.Sprintf("kubectl port-forward -n %s svc/%s :%d", service.Namespace, service.Name, port.Port)
+		}
 	}
 
 	return ""
@@ -21,6 +21,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 
 	ctfv1alpha1 "github.com/leo/chall-operator/api/v1alpha1"
 )
@@ -106,6 +107,82 @@ func TestBuildService_LoadBalancer(t *testing.T) {
 	}
 }
 
+func TestBuildService_ClusterIP(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-3", SourceID: "team-1"},
+	}
+
+	tests := []struct {
+		name       string
+		exposeType string
+		ingress    *ctfv1alpha1.IngressSpec
+		expected   corev1.ServiceType
+	}{
+		{"explicit ClusterIP", "ClusterIP", nil, corev1.ServiceTypeClusterIP},
+		{"unset with ingress", "", &ctfv1alpha1.IngressSpec{Enabled: true}, corev1.ServiceTypeClusterIP},
+		{"unset without ingress", "", &ctfv1alpha1.IngressSpec{Enabled: false}, corev1.ServiceTypeNodePort},
+		{"explicit NodePort with ingress", "NodePort", &ctfv1alpha1.IngressSpec{Enabled: true}, corev1.ServiceTypeNodePort},
+	}
+
+	for _, tt := range tests {
+		challenge := &ctfv1alpha1.Challenge{
+			Spec: ctfv1alpha1.ChallengeSpec{
+				ID: "chall-3",
+				Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+					Image:      "nginx:alpine",
+					Port:       80,
+					ExposeType: tt.exposeType,
+					Ingress:    tt.ingress,
+				},
+			},
+		}
+		if service := BuildService(instance, challenge); service.Spec.Type != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, service.Spec.Type)
+		}
+	}
+}
+
+func TestBuildService_SessionAffinity(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-3", SourceID: "team-1"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-3",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:           "web-chall:v1",
+				Port:            8080,
+				Replicas:        ptr.To(int32(3)),
+				SessionAffinity: &ctfv1alpha1.SessionAffinitySpec{Enabled: true, TimeoutSeconds: 600},
+			},
+		},
+	}
+
+	service := BuildService(instance, challenge)
+	if service.Spec.SessionAffinity != corev1.ServiceAffinityClientIP {
+		t.Errorf("Expected ClientIP session affinity, got %q", service.Spec.SessionAffinity)
+	}
+	config := service.Spec.SessionAffinityConfig
+	if config == nil || config.ClientIP == nil || ptr.Deref(config.ClientIP.TimeoutSeconds, 0) != 600 {
+		t.Errorf("Expected a 600s affinity timeout, got %+v", config)
+	}
+
+	// Without a timeout the Kubernetes default applies
+	challenge.Spec.Scenario.SessionAffinity.TimeoutSeconds = 0
+	config = BuildService(instance, challenge).Spec.SessionAffinityConfig
+	if ptr.Deref(config.ClientIP.TimeoutSeconds, 0) != corev1.DefaultClientIPServiceAffinitySeconds {
+		t.Errorf("Expected default timeout %d, got %v", corev1.DefaultClientIPServiceAffinitySeconds, config.ClientIP.TimeoutSeconds)
+	}
+
+	challenge.Spec.Scenario.SessionAffinity.Enabled = false
+	service = BuildService(instance, challenge)
+	if service.Spec.SessionAffinity != "" || service.Spec.SessionAffinityConfig != nil {
+		t.Errorf("Expected no session affinity when disabled, got %q %+v", service.Spec.SessionAffinity, service.Spec.SessionAffinityConfig)
+	}
+}
+
 func TestServiceName(t *testing.T) {
 	instance := &ctfv1alpha1.ChallengeInstance{
 		ObjectMeta: metav1.ObjectMeta{
@@ -119,6 +196,31 @@ func TestServiceName(t *testing.T) {
 	}
 }
 
+func TestWarmupURL(t *testing.T) {
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Port:      3000,
+				Warmup:    &ctfv1alpha1.WarmupSpec{Path: "warm"},
+				AuthProxy: &ctfv1alpha1.AuthProxySpec{Enabled: true},
+			},
+		},
+	}
+
+	// The auth proxy is bypassed: the request goes to the challenge port of the pod
+	if url := WarmupURL(challenge, "10.0.0.7"); url != "http://10.0.0.7:3000/warm" {
+		t.Errorf("Expected http://10.0.0.7:3000/warm, got %s", url)
+	}
+	if url := WarmupURL(challenge, "fd00::7"); url != "http://[fd00::7]:3000/warm" {
+		t.Errorf("Expected http://[fd00::7]:3000/warm, got %s", url)
+	}
+
+	challenge.Spec.Scenario.Protocol = "UDP"
+	if url := WarmupURL(challenge, "10.0.0.7"); url != "" {
+		t.Errorf("Expected no warmup for a UDP challenge, got %s", url)
+	}
+}
+
 func TestGetConnectionInfo_NodePort(t *testing.T) {
 	service := &corev1.Service{
 		Spec: corev1.ServiceSpec{
@@ -184,9 +286,147 @@ func TestGetConnectionInfo_NoNodePort(t *testing.T) {
 	}
 }
 
+func TestGetConnectionInfo_ClusterIP(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal-instance-svc", Namespace: "ctf-instances"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+
+	connInfo := GetConnectionInfo(service, "192.168.1.100")
+	expected := "kubectl port-forward -n ctf-instances svc/internal-instance-svc :80"
+	if connInfo != expected {
+		t.Errorf("Expected %s, got %s", expected, connInfo)
+	}
+
+	// kubectl port-forward does not forward UDP
+	service.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+	if connInfo := GetConnectionInfo(service, "192.168.1.100"); connInfo != "" {
+		t.Errorf("Expected empty connection info for a UDP ClusterIP service, got %s", connInfo)
+	}
+}
+
 func TestGetConnectionInfo_NilService(t *testing.T) {
 	connInfo := GetConnectionInfo(nil, "192.168.1.100")
 	if connInfo != "" {
 		t.Errorf("Expected empty string for nil service, got %s", connInfo)
 	}
 }
+
+func TestBuildService_ExternalTrafficPolicy(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "pwn-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "chall-4", SourceID: "team-1"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "chall-4",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:                 "pwn-chall:v1",
+				Port:                  1337,
+				ExposeType:            "NodePort",
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+			},
+		},
+	}
+
+	service := BuildService(instance, challenge)
+	if service.Spec.Type != corev1.ServiceTypeNodePort {
+		t.Fatalf("Expected NodePort service, got %s", service.Spec.Type)
+	}
+	if service.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyLocal {
+		t.Errorf("Expected Local external traffic policy, got %q", service.Spec.ExternalTrafficPolicy)
+	}
+
+	// ClusterIP Services can't carry one
+	challenge.Spec.Scenario.ExposeType = "ClusterIP"
+	if policy := BuildService(instance, challenge).Spec.ExternalTrafficPolicy; policy != "" {
+		t.Errorf("Expected no external traffic policy on a ClusterIP service, got %q", policy)
+	}
+}
+
+func TestBuildService_ExposeTypeNone(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "worker", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "worker",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:      "worker:latest",
+				ExposeType: ExposeTypeNone,
+				Ingress:    &ctfv1alpha1.IngressSpec{Enabled: true},
+			},
+		},
+	}
+
+	if service := BuildService(instance, challenge); service != nil {
+		t.Errorf("Expected no Service for ExposeType None, got %v", service)
+	}
+	if ingress := BuildIngress(instance, challenge); ingress != nil {
+		t.Errorf("Expected no Ingress for ExposeType None, got %v", ingress)
+	}
+
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 0 {
+		t.Errorf("Expected no container ports, got %v", container.Ports)
+	}
+	if container.ReadinessProbe != nil {
+		t.Errorf("Expected no default readiness probe, got %v", container.ReadinessProbe)
+	}
+}
+
+func TestBuildService_UDP(t *testing.T) {
+	instance := &ctfv1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns-instance", Namespace: "ctf-instances"},
+		Spec:       ctfv1alpha1.ChallengeInstanceSpec{ChallengeID: "dns", SourceID: "user-123"},
+	}
+	challenge := &ctfv1alpha1.Challenge{
+		Spec: ctfv1alpha1.ChallengeSpec{
+			ID: "dns",
+			Scenario: ctfv1alpha1.ChallengeScenarioSpec{
+				Image:      "dns-puzzle:latest",
+				Port:       5353,
+				Protocol:   "UDP",
+				ExposeType: "NodePort",
+			},
+		},
+	}
+
+	service := BuildService(instance, challenge)
+	if service.Spec.Ports[0].Protocol != corev1.ProtocolUDP {
+		t.Errorf("Expected UDP service port, got %s", service.Spec.Ports[0].Protocol)
+	}
+
+	container := mustBuildDeployment(t, instance, challenge).Spec.Template.Spec.Containers[0]
+	if container.Ports[0].Protocol != corev1.ProtocolUDP {
+		t.Errorf("Expected UDP container port, got %s", container.Ports[0].Protocol)
+	}
+	if container.ReadinessProbe != nil {
+		t.Errorf("Expected no default TCP readiness probe on a UDP port, got %v", container.ReadinessProbe)
+	}
+}
+
+func TestGetConnectionInfo_UDP(t *testing.T) {
+	service := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{
+					Port:     80,
+					NodePort: 30053,
+					Protocol: corev1.ProtocolUDP,
+				},
+			},
+		},
+	}
+
+	connInfo := GetConnectionInfo(service, "192.168.1.100")
+	expected := "nc -u 192.168.1.100 30053"
+	if connInfo != expected {
+		t.Errorf("Expected %s, got %s", expected, connInfo)
+	}
+}
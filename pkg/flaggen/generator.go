@@ -20,10 +20,19 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"text/template"
+	"unicode"
 )
 
+// ErrUnsafeFlag is returned when a flag template yields characters that break env or shell injection
+var ErrUnsafeFlag = errors.New("flag contains shell-unsafe characters")
+
+// shellUnsafeChars break quoting when a flag is injected into a shell context (e.g. attackbox PS1)
+const shellUnsafeChars = "\"'`\\$"
+
 // FlagContext contains the variables available in flag templates
 type FlagContext struct {
 	InstanceID   string
@@ -55,11 +64,11 @@ func Generate(tmpl string, instanceID, sourceID, challengeID string) (string, er
 	}
 	randomStr := hex.EncodeToString(randomBytes)
 
-	// Create template context
+	// Create template context; identifiers come from users, so strip unsafe characters
 	ctx := FlagContext{
-		InstanceID:   instanceID,
-		SourceID:     sourceID,
-		ChallengeID:  challengeID,
+		InstanceID:   stripUnsafe(instanceID),
+		SourceID:     stripUnsafe(sourceID),
+		ChallengeID:  stripUnsafe(challengeID),
 		RandomString: randomStr,
 	}
 
@@ -74,7 +83,31 @@ func Generate(tmpl string, instanceID, sourceID, challengeID string) (string, er
 		return "", fmt.Errorf("failed to execute flag template: %w", err)
 	}
 
-	return buf.String(), nil
+	flag := buf.String()
+	if err := CheckFlag(flag); err != nil {
+		return "", err
+	}
+	return flag, nil
+}
+
+// CheckFlag rejects flags with control characters (newlines, NUL, ...), quotes, backslashes or $
+func CheckFlag(flag string) error {
+	for _, r := range flag {
+		if unicode.IsControl(r) || strings.ContainsRune(shellUnsafeChars, r) {
+			return fmt.Errorf("%w: %q", ErrUnsafeFlag, r)
+		}
+	}
+	return nil
+}
+
+// stripUnsafe removes the characters CheckFlag rejects from a template value
+func stripUnsafe(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(shellUnsafeChars, r) {
+			return -1
+		}
+		return r
+	}, value)
 }
 
 // GenerateMultiple generates multiple unique flags
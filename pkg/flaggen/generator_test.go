@@ -17,6 +17,7 @@ limitations under the License.
 package flaggen
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -134,3 +135,32 @@ func TestGenerateMultiple_ZeroCount(t *testing.T) {
 		t.Errorf("Expected 1 flag for count=0, got: %d", len(flags))
 	}
 }
+
+func TestGenerate_RejectsShellUnsafeTemplates(t *testing.T) {
+	templates := []string{
+		`FLAG{{"{"}}it's{{"}"}}`,
+		"FLAG{{\"{\"}}line\nbreak{{\"}\"}}",
+		`FLAG{{"{"}}$(id){{"}"}}`,
+		`FLAG{{"{"}}back\slash{{"}"}}`,
+	}
+
+	for _, tmpl := range templates {
+		if _, err := Generate(tmpl, "instance-1", "user-123", "challenge-1"); !errors.Is(err, ErrUnsafeFlag) {
+			t.Errorf("Expected ErrUnsafeFlag for template %q, got %v", tmpl, err)
+		}
+	}
+}
+
+func TestGenerate_StripsUnsafeIdentifiers(t *testing.T) {
+	flag, err := Generate("", "instance-1", "o'brien\n\"$USER\"", "challenge-1")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if !strings.Contains(flag, "_obrienUSER_") {
+		t.Errorf("Expected unsafe characters stripped from the source ID, got: %s", flag)
+	}
+	if err := CheckFlag(flag); err != nil {
+		t.Errorf("Expected a safe flag, got %v", err)
+	}
+}
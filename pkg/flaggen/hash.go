@@ -0,0 +1,51 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flaggen
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+)
+
+// HashFlag returns the hex-encoded SHA-256 of salt followed by flag
+func HashFlag(flag, salt string) string {
+	sum := sha256.Sum256([]byte(salt + flag))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSalt returns a random hex salt for HashFlag
+func GenerateSalt() (string, error) {
+	saltBytes := make([]byte, 16)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hex.EncodeToString(saltBytes), nil
+}
+
+// MatchesHash reports whether flag hashes to one of hashes with salt, in constant time per hash
+func MatchesHash(flag, salt string, hashes []string) bool {
+	submitted := []byte(HashFlag(flag, salt))
+	for _, hash := range hashes {
+		if subtle.ConstantTimeCompare(submitted, []byte(hash)) == 1 {
+			return true
+		}
+	}
+	return false
+}
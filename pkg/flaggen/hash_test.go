@@ -0,0 +1,55 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flaggen
+
+import (
+	"testing"
+)
+
+func TestHashFlag(t *testing.T) {
+	// sha256("saltFLAG{x}")
+	expected := "2871bc4ab8326b5bbb985e81031af382afe75997e2ed484028ceebd6881aeece"
+
+	hash := HashFlag("FLAG{x}", "salt")
+	if hash != expected {
+		t.Errorf("Expected %s, got %s", expected, hash)
+	}
+	if hash == HashFlag("FLAG{x}", "other-salt") {
+		t.Errorf("Expected different salts to give different hashes")
+	}
+	if hash == HashFlag("FLAG{y}", "salt") {
+		t.Errorf("Expected different flags to give different hashes")
+	}
+}
+
+func TestMatchesHash(t *testing.T) {
+	salt, err := GenerateSalt()
+	if err != nil {
+		t.Fatalf("GenerateSalt failed: %v", err)
+	}
+	hashes := []string{HashFlag("FLAG{right}", salt)}
+
+	if !MatchesHash("FLAG{right}", salt, hashes) {
+		t.Errorf("Expected the correct flag to match")
+	}
+	if MatchesHash("FLAG{wrong}", salt, hashes) {
+		t.Errorf("Expected a wrong flag not to match")
+	}
+	if MatchesHash("FLAG{right}", "", hashes) {
+		t.Errorf("Expected a missing salt not to match")
+	}
+}
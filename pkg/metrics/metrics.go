@@ -0,0 +1,91 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics for the instance lifecycle
+// They are registered with the controller-runtime registry, so the manager exposes them
+// on its metrics endpoint and the API gateway serves the same registry on /metrics
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// InstancesCreated counts ChallengeInstances created through the API, per challenge
+	InstancesCreated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chall_operator_instances_created_total",
+			Help: "Total number of challenge instances created",
+		},
+		[]string{"challenge"},
+	)
+
+	// InstancesRunning tracks the number of Running instances, per challenge
+	InstancesRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "chall_operator_instances_running",
+			Help: "Current number of running challenge instances",
+		},
+		[]string{"challenge"},
+	)
+
+	// ReconcileErrors counts reconciliations of ChallengeInstances that returned an error
+	ReconcileErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "chall_operator_reconcile_errors_total",
+			Help: "Total number of failed ChallengeInstance reconciliations",
+		},
+	)
+
+	// FlagValidations counts flag submissions, per challenge and result (correct/incorrect)
+	FlagValidations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chall_operator_flag_validations_total",
+			Help: "Total number of flag submissions",
+		},
+		[]string{"challenge", "result"},
+	)
+
+	// InstancesExpired counts instances deleted by the controller because they expired, per challenge
+	InstancesExpired = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chall_operator_instances_expired_total",
+			Help: "Total number of challenge instances deleted after expiry",
+		},
+		[]string{"challenge"},
+	)
+
+	// InstancesOverdue tracks instances past their Until that still exist, e.g. stuck on the cleanup finalizer
+	// It is recomputed on every janitor sweep; a non-zero value over a few sweeps means expired resources aren't freed
+	InstancesOverdue = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "chall_operator_instances_overdue",
+			Help: "Current number of expired challenge instances not deleted yet",
+		},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		InstancesCreated,
+		InstancesRunning,
+		ReconcileErrors,
+		FlagValidations,
+		InstancesExpired,
+		InstancesOverdue,
+	)
+}